@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/patali/yantra/src/breaker"
 	"github.com/patali/yantra/src/config"
 	"github.com/patali/yantra/src/controllers"
 	"github.com/patali/yantra/src/db"
@@ -17,6 +18,7 @@ import (
 	"github.com/patali/yantra/src/executors"
 	"github.com/patali/yantra/src/middleware"
 	riverinternal "github.com/patali/yantra/src/river"
+	"github.com/patali/yantra/src/secrets"
 	"github.com/patali/yantra/src/services"
 )
 
@@ -31,6 +33,27 @@ func main() {
 		log.Fatalf("❌ Failed to load configuration: %v", err)
 	}
 
+	// Initialize secrets keyring (encrypts stored provider credentials)
+	var keyring *secrets.Keyring
+	if cfg.SecretsMasterKey != "" {
+		keyring, err = secrets.ParseKeyring(cfg.SecretsMasterKeyID, cfg.SecretsMasterKey, cfg.SecretsPreviousKeys)
+		if err != nil {
+			log.Fatalf("❌ Invalid secrets master key configuration: %v", err)
+		}
+		// Values stored before SECRETS_MASTER_KEY was set stay readable and are re-encrypted by the startup migration
+		keyring, err = keyring.WithPreviousKey(secrets.DevKeyID, secrets.DeriveKey(cfg.JWTSecret))
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize secrets keyring: %v", err)
+		}
+	} else {
+		log.Println("⚠️  SECRETS_MASTER_KEY not set, deriving a development key from JWT_SECRET")
+		keyring, err = secrets.NewKeyring(secrets.DevKeyID, secrets.DeriveKey(cfg.JWTSecret), nil)
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize secrets keyring: %v", err)
+		}
+	}
+	secrets.SetDefault(keyring)
+
 	// Initialize database
 	database, err := db.GetAppDB(&db.Config{
 		DatabaseURL: cfg.DatabaseURL,
@@ -51,21 +74,69 @@ func main() {
 		log.Fatalf("❌ Failed to run GORM migrations: %v", err)
 	}
 
+	// Encrypt plaintext credentials and re-wrap any under retired master keys
+	if count, err := services.MigrateEmailProviderSecrets(ctx, database.DB); err != nil {
+		log.Printf("⚠️  Warning: Failed to migrate email provider secrets: %v", err)
+	} else if count > 0 {
+		log.Printf("🔐 Encrypted credentials for %d email provider(s)", count)
+	}
+
 	// Initialize repository layer
 	repo := repositories.NewRepository(database.DB)
 
+	// Configure circuit breakers before any executor factory builds its HTTP client
+	breakers := breaker.NewRegistry(breaker.Config{
+		FailureThreshold: cfg.BreakerFailureThreshold,
+		OpenDuration:     time.Duration(cfg.BreakerOpenSeconds) * time.Second,
+	})
+	breaker.SetDefault(breakers)
+
+	// Configure egress policy before any executor factory builds its HTTP client
+	// SECURITY: Blocks SSRF to private/link-local ranges; accounts can further restrict hosts
+	egressPolicy := executors.NewEgressPolicy(cfg.EgressAllowPrivate, cfg.EgressAllowedHosts, cfg.EgressDeniedHosts)
+	egressPolicy.SetAccountRulesLoader(services.NewEgressPolicyService(database.DB).GetRules)
+	executors.SetDefaultEgressPolicy(egressPolicy)
+
+	// Configure the WASM plugin sandbox before any executor factory is created
+	// SECURITY: Plugins get no filesystem or network access; memory, fuel and time are capped
+	wasmRuntime := executors.NewWasmRuntime(executors.WasmLimits{
+		MemoryLimitMB: cfg.WasmMaxMemoryMB,
+		Fuel:          cfg.WasmMaxFuel,
+		Timeout:       time.Duration(cfg.WasmMaxTimeoutMs) * time.Millisecond,
+	})
+	wasmPluginService := services.NewWasmPluginService(database.DB, wasmRuntime)
+	wasmRuntime.SetPluginStore(wasmPluginService)
+	executors.SetDefaultWasmRuntime(wasmRuntime)
+	defer wasmRuntime.Close(context.Background())
+
 	// Initialize email service (needed by workflow engine and outbox worker)
 	emailService := services.NewEmailService(database.DB)
 
 	// Initialize workflow engine with email service dependency
 	workflowEngine := services.NewWorkflowEngineService(database.DB, emailService)
 
-	// Initialize River client with workflow engine
-	riverClient, err := riverinternal.NewClient(ctx, cfg.DatabaseURL, workflowEngine)
+	// Initialize outbox worker (delivers outbox messages as River jobs)
+	outboxService := services.NewOutboxService(database.DB)
+	executorFactory := executors.NewExecutorFactory(database.DB, emailService)
+	outboxWorker := services.NewOutboxWorkerService(outboxService, executorFactory)
+
+	// Initialize River client with workflow engine and outbox worker
+	riverClient, err := riverinternal.NewClient(ctx, cfg.DatabaseURL, workflowEngine, outboxWorker, cfg.OutboxWorkers)
 	if err != nil {
 		log.Fatalf("❌ Failed to create River client: %v", err)
 	}
 
+	// Outbox messages and their delivery jobs are inserted in one transaction
+	outboxService.SetRiverClient(riverClient.GetClient(), riverClient.GetPgxPool())
+	workflowEngine.SetOutboxService(outboxService)
+
+	// Give messages queued before deliveries became River jobs a delivery job
+	if count, err := outboxService.EnqueueOrphanedMessages(ctx); err != nil {
+		log.Printf("⚠️  Failed to enqueue orphaned outbox messages: %v", err)
+	} else if count > 0 {
+		log.Printf("📬 Enqueued delivery jobs for %d orphaned outbox messages", count)
+	}
+
 	// Start River workers
 	if err := riverClient.Start(ctx); err != nil {
 		log.Fatalf("❌ Failed to start River workers: %v", err)
@@ -89,14 +160,6 @@ func main() {
 	}
 	defer schedulerService.Stop(ctx)
 
-	// Initialize and start outbox worker
-	outboxService := services.NewOutboxService(database.DB)
-	executorFactory := executors.NewExecutorFactory(database.DB, emailService)
-
-	outboxWorker := services.NewOutboxWorkerService(outboxService, executorFactory)
-	outboxWorker.Start(ctx)
-	defer outboxWorker.Stop()
-
 	// Run cleanup routines on startup
 	cleanupService := services.NewCleanupService(repo)
 	if err := cleanupService.RunAllCleanups(ctx); err != nil {
@@ -129,6 +192,10 @@ func main() {
 		})
 	})
 
+	// Prometheus metrics (protected by METRICS_TOKEN)
+	metricsController := controllers.NewMetricsController(breakers, cfg.MetricsToken)
+	metricsController.RegisterRoutes(router)
+
 	// API routes
 	api := router.Group("/api")
 	{
@@ -155,8 +222,24 @@ func main() {
 		settingsController := controllers.NewSettingsController(database.DB)
 		settingsController.RegisterRoutes(api, authService)
 
+		// Environment routes (account-level variable overrides)
+		environmentController := controllers.NewEnvironmentController(services.NewEnvironmentService(database.DB))
+		environmentController.RegisterRoutes(api, authService)
+
+		// Expression authoring routes
+		expressionController := controllers.NewExpressionController(services.NewExpressionService(database.DB))
+		expressionController.RegisterRoutes(api, authService)
+
+		// Node type registry routes
+		nodeTypeController := controllers.NewNodeTypeController(executorFactory.Registry())
+		nodeTypeController.RegisterRoutes(api, authService)
+
+		// WASM plugin routes
+		wasmPluginController := controllers.NewWasmPluginController(wasmPluginService)
+		wasmPluginController.RegisterRoutes(api, authService)
+
 		// Recovery routes
-		recoveryController := controllers.NewRecoveryController(outboxService, workflowService, workflowEngine)
+		recoveryController := controllers.NewRecoveryController(outboxService, workflowService, workflowEngine, services.NewAuditService(database.DB), breakers)
 		recoveryController.RegisterRoutes(api, authService)
 
 		// Migration routes (protected by API key)
@@ -203,11 +286,7 @@ func main() {
 		log.Printf("⚠️  Error stopping scheduler: %v", err)
 	}
 
-	// Stop outbox worker
-	log.Println("📦 Stopping outbox worker...")
-	outboxWorker.Stop()
-
-	// Stop River workers
+	// Stop River workers (including outbox deliveries)
 	log.Println("🌊 Stopping River workers...")
 	if err := riverClient.Stop(shutdownCtx); err != nil {
 		log.Printf("⚠️  Error stopping River client: %v", err)
@@ -0,0 +1,284 @@
+// Package breaker implements circuit breakers for the external destinations workflows call.
+//
+// A breaker is kept per account and destination ("host:hooks.slack.com", "email:ses"). After
+// FailureThreshold consecutive transient failures it opens and rejects calls for OpenDuration. It
+// then lets a single probe through (half-open): a success closes it, a failure opens it again.
+// Breakers live in process memory, so each replica trips independently.
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Breaker states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// idleTTL is how long a closed breaker without failures is kept
+const idleTTL = time.Hour
+
+// Config controls when breakers open and for how long
+type Config struct {
+	FailureThreshold int           // Consecutive failures that open a breaker
+	OpenDuration     time.Duration // How long an open breaker rejects calls before probing
+}
+
+// DefaultConfig opens a breaker after 5 consecutive failures, for a minute
+func DefaultConfig() Config {
+	return Config{FailureThreshold: 5, OpenDuration: time.Minute}
+}
+
+// Key identifies the breaker of one destination for one account
+type Key struct {
+	AccountID   string
+	Destination string
+}
+
+// HostDestination returns the destination of an HTTP host (host[:port])
+func HostDestination(host string) string {
+	return "host:" + host
+}
+
+// EmailDestination returns the destination of an email provider
+func EmailDestination(provider string) string {
+	return "email:" + provider
+}
+
+// OpenError is returned for calls rejected by an open breaker; nothing was sent
+type OpenError struct {
+	Destination string
+	RetryAt     time.Time
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %s until %s", e.Destination, e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+// AsOpen unwraps a circuit breaker rejection from an error chain
+func AsOpen(err error) (*OpenError, bool) {
+	var openErr *OpenError
+	if errors.As(err, &openErr) {
+		return openErr, true
+	}
+	return nil, false
+}
+
+// Status is a snapshot of one breaker
+type Status struct {
+	AccountID           string     `json:"accountId"`
+	Destination         string     `json:"destination"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"` // When an open breaker lets the next probe through
+	Failures            int64      `json:"failures"`          // Failures recorded since the breaker was created
+	Rejected            int64      `json:"rejected"`          // Calls rejected while open
+	TimesOpened         int64      `json:"timesOpened"`
+}
+
+type breaker struct {
+	state         string
+	failures      int
+	lastError     string
+	lastFailureAt time.Time
+	openedAt      time.Time
+	retryAt       time.Time // Open: end of the open period. Half-open: end of the running probe's lease
+
+	totalFailures int64
+	rejected      int64
+	timesOpened   int64
+}
+
+// Registry holds the breakers of every account and destination
+type Registry struct {
+	config Config
+	now    func() time.Time
+
+	mu       sync.Mutex
+	breakers map[Key]*breaker
+}
+
+// NewRegistry creates a breaker registry; zero config fields take their default
+func NewRegistry(config Config) *Registry {
+	defaults := DefaultConfig()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = defaults.OpenDuration
+	}
+	return &Registry{
+		config:   config,
+		now:      time.Now,
+		breakers: make(map[Key]*breaker),
+	}
+}
+
+// Allow reports whether a call to the destination may go ahead. It returns an *OpenError while the
+// breaker is open, or while another call is probing a half-open breaker
+func (r *Registry) Allow(key Key) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[key]
+	if !ok || b.state == StateClosed {
+		return nil
+	}
+
+	now := r.now()
+	if now.Before(b.retryAt) {
+		b.rejected++
+		return &OpenError{Destination: key.Destination, RetryAt: b.retryAt}
+	}
+
+	// The open period (or an abandoned probe's lease) is over: this call is the probe
+	b.state = StateHalfOpen
+	b.retryAt = now.Add(r.config.OpenDuration)
+	return nil
+}
+
+// Success records a call that reached the destination; it closes a half-open breaker
+func (r *Registry) Success(key Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[key]
+	if !ok || b.state == StateOpen {
+		// Calls started before the breaker opened don't close it
+		return
+	}
+	b.state = StateClosed
+	b.failures = 0
+	b.openedAt = time.Time{}
+	b.retryAt = time.Time{}
+}
+
+// Failure records a transient failure (outage, timeout, 5xx, rate limit). It opens the breaker when the
+// threshold is reached, or when the half-open probe fails
+func (r *Registry) Failure(key Key, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	b, ok := r.breakers[key]
+	if !ok {
+		r.pruneLocked(now)
+		b = &breaker{state: StateClosed}
+		r.breakers[key] = b
+	}
+
+	b.totalFailures++
+	b.lastFailureAt = now
+	if err != nil {
+		b.lastError = err.Error()
+	}
+
+	switch b.state {
+	case StateOpen:
+		return
+	case StateHalfOpen:
+		r.openLocked(b, now)
+	default:
+		b.failures++
+		if b.failures >= r.config.FailureThreshold {
+			r.openLocked(b, now)
+		}
+	}
+}
+
+func (r *Registry) openLocked(b *breaker, now time.Time) {
+	b.state = StateOpen
+	b.openedAt = now
+	b.retryAt = now.Add(r.config.OpenDuration)
+	b.timesOpened++
+}
+
+// pruneLocked drops closed breakers that have not failed for idleTTL
+func (r *Registry) pruneLocked(now time.Time) {
+	for key, b := range r.breakers {
+		if b.state == StateClosed && now.Sub(b.lastFailureAt) > idleTTL {
+			delete(r.breakers, key)
+		}
+	}
+}
+
+// Reset closes a breaker; it reports whether the breaker existed
+func (r *Registry) Reset(key Key) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.breakers[key]; !ok {
+		return false
+	}
+	delete(r.breakers, key)
+	return true
+}
+
+// Statuses returns the breakers of an account (all accounts when accountID is empty), sorted by destination
+func (r *Registry) Statuses(accountID string) []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	statuses := make([]Status, 0, len(r.breakers))
+	for key, b := range r.breakers {
+		if accountID != "" && key.AccountID != accountID {
+			continue
+		}
+		status := Status{
+			AccountID:           key.AccountID,
+			Destination:         key.Destination,
+			State:               b.state,
+			ConsecutiveFailures: b.failures,
+			LastError:           b.lastError,
+			Failures:            b.totalFailures,
+			Rejected:            b.rejected,
+			TimesOpened:         b.timesOpened,
+		}
+		// An open breaker whose period is over lets the next call probe
+		if b.state == StateOpen && !now.Before(b.retryAt) {
+			status.State = StateHalfOpen
+		}
+		if !b.openedAt.IsZero() {
+			openedAt, retryAt := b.openedAt, b.retryAt
+			status.OpenedAt = &openedAt
+			status.RetryAt = &retryAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].AccountID != statuses[j].AccountID {
+			return statuses[i].AccountID < statuses[j].AccountID
+		}
+		return statuses[i].Destination < statuses[j].Destination
+	})
+	return statuses
+}
+
+var (
+	defaultRegistry   = NewRegistry(DefaultConfig())
+	defaultRegistryMu sync.RWMutex
+)
+
+// SetDefault installs the registry used by executor factories and services created afterwards
+func SetDefault(r *Registry) {
+	defaultRegistryMu.Lock()
+	defer defaultRegistryMu.Unlock()
+	defaultRegistry = r
+}
+
+// Default returns the process-wide breaker registry
+func Default() *Registry {
+	defaultRegistryMu.RLock()
+	defer defaultRegistryMu.RUnlock()
+	return defaultRegistry
+}
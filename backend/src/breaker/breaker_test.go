@@ -0,0 +1,83 @@
+package breaker
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRegistry() (*Registry, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRegistry(Config{FailureThreshold: 3, OpenDuration: time.Minute})
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestRegistry_OpensAfterConsecutiveFailures(t *testing.T) {
+	r, now := testRegistry()
+	key := Key{AccountID: "acc", Destination: HostDestination("hooks.slack.com")}
+
+	r.Failure(key, errors.New("503"))
+	r.Failure(key, errors.New("503"))
+	r.Success(key)
+	r.Failure(key, errors.New("503"))
+	r.Failure(key, errors.New("503"))
+	assert.NoError(t, r.Allow(key), "a success resets the failure count")
+
+	r.Failure(key, errors.New("timeout"))
+	err := r.Allow(key)
+	openErr, ok := AsOpen(err)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Minute), openErr.RetryAt)
+	assert.NoError(t, r.Allow(Key{AccountID: "other", Destination: key.Destination}), "breakers are per account")
+
+	statuses := r.Statuses("acc")
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, StateOpen, statuses[0].State)
+	assert.Equal(t, "timeout", statuses[0].LastError)
+	assert.Equal(t, int64(1), statuses[0].Rejected)
+	assert.Empty(t, r.Statuses("other"))
+}
+
+func TestRegistry_HalfOpenProbe(t *testing.T) {
+	r, now := testRegistry()
+	key := Key{AccountID: "acc", Destination: EmailDestination("ses")}
+	for i := 0; i < 3; i++ {
+		r.Failure(key, nil)
+	}
+
+	*now = now.Add(time.Minute)
+	assert.NoError(t, r.Allow(key), "the first call after the open period probes")
+	assert.Error(t, r.Allow(key), "other calls wait for the probe")
+
+	r.Failure(key, errors.New("still down"))
+	assert.Error(t, r.Allow(key), "a failed probe reopens the breaker")
+
+	*now = now.Add(time.Minute)
+	assert.NoError(t, r.Allow(key))
+	r.Success(key)
+	assert.NoError(t, r.Allow(key))
+	assert.NoError(t, r.Allow(key))
+	assert.Equal(t, StateClosed, r.Statuses("acc")[0].State)
+	assert.Equal(t, int64(2), r.Statuses("acc")[0].TimesOpened)
+
+	assert.True(t, r.Reset(key))
+	assert.False(t, r.Reset(key))
+}
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	r, _ := testRegistry()
+	key := Key{AccountID: "acc", Destination: HostDestination(`api."x"`)}
+	for i := 0; i < 3; i++ {
+		r.Failure(key, nil)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.WritePrometheus(&buf))
+	assert.Contains(t, buf.String(), "# TYPE yantra_circuit_breaker_state gauge\n")
+	assert.Contains(t, buf.String(), `yantra_circuit_breaker_state{account_id="acc",destination="host:api.\"x\""} 2`)
+	assert.Contains(t, buf.String(), `yantra_circuit_breaker_failures_total{account_id="acc",destination="host:api.\"x\""} 3`)
+}
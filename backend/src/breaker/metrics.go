@@ -0,0 +1,49 @@
+package breaker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// stateValues are the values of the yantra_circuit_breaker_state gauge
+var stateValues = map[string]int{StateClosed: 0, StateHalfOpen: 1, StateOpen: 2}
+
+// WritePrometheus writes the breakers in the Prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	statuses := r.Statuses("")
+
+	metrics := []struct {
+		name, help, kind string
+		value            func(Status) int64
+	}{
+		{"yantra_circuit_breaker_state", "Circuit breaker state (0 closed, 1 half-open, 2 open).", "gauge",
+			func(s Status) int64 { return int64(stateValues[s.State]) }},
+		{"yantra_circuit_breaker_consecutive_failures", "Failures since the breaker last closed.", "gauge",
+			func(s Status) int64 { return int64(s.ConsecutiveFailures) }},
+		{"yantra_circuit_breaker_failures_total", "Transient failures recorded for the destination.", "counter",
+			func(s Status) int64 { return s.Failures }},
+		{"yantra_circuit_breaker_rejected_total", "Calls rejected while the breaker was open.", "counter",
+			func(s Status) int64 { return s.Rejected }},
+		{"yantra_circuit_breaker_opened_total", "Times the breaker opened.", "counter",
+			func(s Status) int64 { return s.TimesOpened }},
+	}
+
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind); err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if _, err := fmt.Fprintf(w, "%s{account_id=\"%s\",destination=\"%s\"} %d\n",
+				m.name, escapeLabel(s.AccountID), escapeLabel(s.Destination), m.value(s)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
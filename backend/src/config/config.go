@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -23,6 +24,19 @@ type Config struct {
 	SystemEmailSMTPUser     string
 	SystemEmailSMTPPassword string
 	SystemEmailResendAPIKey string
+	SecretsMasterKey        string   // base64-encoded 32-byte key for encrypting stored credentials
+	SecretsMasterKeyID      string   // ID recorded with each encrypted value (change when rotating)
+	SecretsPreviousKeys     string   // comma-separated "id:base64key" list of retired keys still used for decryption
+	EgressAllowPrivate      bool     // allow HTTP/Slack nodes to reach private, loopback and link-local addresses
+	EgressAllowedHosts      []string // hosts exempt from the private address check (trusted internal services)
+	EgressDeniedHosts       []string // hosts no workflow may call
+	WasmMaxMemoryMB         int      // memory ceiling for a WASM plugin run
+	WasmMaxFuel             int64    // guest function call ceiling for a WASM plugin run
+	WasmMaxTimeoutMs        int      // wall-clock ceiling for a WASM plugin run
+	OutboxWorkers           int      // outbox deliveries River runs concurrently per replica
+	BreakerFailureThreshold int      // consecutive failures that open a destination's circuit breaker
+	BreakerOpenSeconds      int      // how long an open circuit breaker rejects calls before probing
+	MetricsToken            string   // bearer token for GET /metrics (disabled when empty)
 }
 
 func Load() (*Config, error) {
@@ -44,6 +58,38 @@ func Load() (*Config, error) {
 		SystemEmailSMTPUser:     os.Getenv("SYSTEM_EMAIL_SMTP_USER"),
 		SystemEmailSMTPPassword: os.Getenv("SYSTEM_EMAIL_SMTP_PASSWORD"),
 		SystemEmailResendAPIKey: os.Getenv("SYSTEM_EMAIL_RESEND_API_KEY"),
+		SecretsMasterKey:        os.Getenv("SECRETS_MASTER_KEY"),
+		SecretsMasterKeyID:      getEnvOrDefault("SECRETS_MASTER_KEY_ID", "primary"),
+		SecretsPreviousKeys:     os.Getenv("SECRETS_PREVIOUS_KEYS"),
+		EgressAllowPrivate:      getEnvOrDefault("EGRESS_ALLOW_PRIVATE_NETWORKS", "false") == "true",
+		EgressAllowedHosts:      parseCommaSeparated(os.Getenv("EGRESS_ALLOWED_HOSTS")),
+		EgressDeniedHosts:       parseCommaSeparated(os.Getenv("EGRESS_DENIED_HOSTS")),
+		MetricsToken:            os.Getenv("METRICS_TOKEN"),
+	}
+
+	var err error
+	if cfg.WasmMaxMemoryMB, err = getEnvIntOrDefault("WASM_MAX_MEMORY_MB", 64); err != nil {
+		return nil, err
+	}
+	if cfg.WasmMaxTimeoutMs, err = getEnvIntOrDefault("WASM_MAX_TIMEOUT_MS", 5000); err != nil {
+		return nil, err
+	}
+	maxFuel, err := getEnvIntOrDefault("WASM_MAX_FUEL", 50000000)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WasmMaxFuel = int64(maxFuel)
+	if cfg.OutboxWorkers, err = getEnvIntOrDefault("OUTBOX_WORKERS", 4); err != nil {
+		return nil, err
+	}
+	if cfg.OutboxWorkers < 1 {
+		return nil, fmt.Errorf("OUTBOX_WORKERS must be at least 1")
+	}
+	if cfg.BreakerFailureThreshold, err = getEnvIntOrDefault("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 5); err != nil {
+		return nil, err
+	}
+	if cfg.BreakerOpenSeconds, err = getEnvIntOrDefault("CIRCUIT_BREAKER_OPEN_SECONDS", 60); err != nil {
+		return nil, err
 	}
 
 	// Validate required config
@@ -53,6 +99,10 @@ func Load() (*Config, error) {
 	if cfg.JWTSecret == "" {
 		return nil, fmt.Errorf("JWT_SECRET is required")
 	}
+	// SECURITY: Stored credentials must be encrypted with a dedicated key in production
+	if cfg.SecretsMasterKey == "" && cfg.Environment == "production" {
+		return nil, fmt.Errorf("SECRETS_MASTER_KEY is required in production")
+	}
 
 	return cfg, nil
 }
@@ -64,17 +114,35 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvIntOrDefault parses an integer environment variable
+func getEnvIntOrDefault(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return parsed, nil
+}
+
 // parseAllowedOrigins parses a comma-separated list of allowed origins
 func parseAllowedOrigins(originsStr string) []string {
-	if originsStr == "" {
+	return parseCommaSeparated(originsStr)
+}
+
+// parseCommaSeparated splits a comma-separated list, trimming whitespace and dropping empty entries
+func parseCommaSeparated(value string) []string {
+	if value == "" {
 		return []string{}
 	}
 
-	origins := strings.Split(originsStr, ",")
-	result := make([]string, 0, len(origins))
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
 
-	for _, origin := range origins {
-		trimmed := strings.TrimSpace(origin)
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
 		if trimmed != "" {
 			result = append(result, trimmed)
 		}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/middleware"
+	"github.com/patali/yantra/src/services"
+	"gorm.io/gorm"
+)
+
+type EnvironmentController struct {
+	environmentService *services.EnvironmentService
+}
+
+func NewEnvironmentController(environmentService *services.EnvironmentService) *EnvironmentController {
+	return &EnvironmentController{environmentService: environmentService}
+}
+
+// RegisterRoutes registers environment routes
+func (ctrl *EnvironmentController) RegisterRoutes(rg *gin.RouterGroup, authService *services.AuthService) {
+	environments := rg.Group("/environments")
+	environments.Use(middleware.AuthMiddleware(authService))
+	{
+		environments.GET("", ctrl.ListEnvironments)
+		environments.POST("", ctrl.CreateEnvironment)
+		environments.PUT("/:id", ctrl.UpdateEnvironment)
+		environments.DELETE("/:id", ctrl.DeleteEnvironment)
+	}
+}
+
+// ListEnvironments lists the account's environments with their variables
+// GET /api/environments
+func (ctrl *EnvironmentController) ListEnvironments(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	environments, err := ctrl.environmentService.ListEnvironments(c.Request.Context(), accountID)
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, environments)
+}
+
+// CreateEnvironment creates an environment
+// POST /api/environments
+func (ctrl *EnvironmentController) CreateEnvironment(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req dto.CreateEnvironmentRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	environment, err := ctrl.environmentService.CreateEnvironment(c.Request.Context(), accountID, req)
+	if err != nil {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusCreated, environment)
+}
+
+// UpdateEnvironment updates an environment's name, default flag or variables
+// PUT /api/environments/:id
+func (ctrl *EnvironmentController) UpdateEnvironment(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req dto.UpdateEnvironmentRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	environment, err := ctrl.environmentService.UpdateEnvironment(c.Request.Context(), accountID, c.Param("id"), req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		middleware.RespondNotFound(c, "Environment not found")
+		return
+	}
+	if err != nil {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, environment)
+}
+
+// DeleteEnvironment deletes an environment
+// DELETE /api/environments/:id
+func (ctrl *EnvironmentController) DeleteEnvironment(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	err = ctrl.environmentService.DeleteEnvironment(c.Request.Context(), accountID, c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		middleware.RespondNotFound(c, "Environment not found")
+		return
+	}
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{"message": "Environment deleted successfully"})
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/middleware"
+	"github.com/patali/yantra/src/services"
+)
+
+type ExpressionController struct {
+	expressionService *services.ExpressionService
+}
+
+func NewExpressionController(expressionService *services.ExpressionService) *ExpressionController {
+	return &ExpressionController{expressionService: expressionService}
+}
+
+// RegisterRoutes registers expression authoring routes
+func (ctrl *ExpressionController) RegisterRoutes(rg *gin.RouterGroup, authService *services.AuthService) {
+	expressions := rg.Group("/expressions")
+	expressions.Use(middleware.AuthMiddleware(authService))
+	{
+		expressions.POST("/evaluate", ctrl.Evaluate)
+	}
+}
+
+// Evaluate evaluates an expression or template against a sample context or a past execution
+// POST /api/expressions/evaluate
+// Parse and evaluation errors are returned with valid=false (and a position for parse errors)
+func (ctrl *ExpressionController) Evaluate(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req dto.EvaluateExpressionRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	result, err := ctrl.expressionService.Evaluate(c.Request.Context(), accountID, req)
+	if errors.Is(err, services.ErrExpressionSourceNotFound) {
+		middleware.RespondNotFound(c, "Execution or node input not found")
+		return
+	}
+	if errors.Is(err, services.ErrInvalidExpressionRequest) {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, result)
+}
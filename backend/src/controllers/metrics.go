@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patali/yantra/src/breaker"
+)
+
+type MetricsController struct {
+	breakers *breaker.Registry
+	token    string
+}
+
+func NewMetricsController(breakers *breaker.Registry, token string) *MetricsController {
+	return &MetricsController{
+		breakers: breakers,
+		token:    token,
+	}
+}
+
+// RegisterRoutes registers the metrics route at the server root, where scrapers expect it
+func (ctrl *MetricsController) RegisterRoutes(router *gin.Engine) {
+	router.GET("/metrics", ctrl.metricsTokenMiddleware(), ctrl.GetMetrics)
+}
+
+// metricsTokenMiddleware validates the METRICS_TOKEN bearer token
+func (ctrl *MetricsController) metricsTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// If no token is set, disable the endpoint
+		if ctrl.token == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Metrics are disabled. Set METRICS_TOKEN environment variable to enable.",
+			})
+			c.Abort()
+			return
+		}
+
+		// SECURITY: Constant-time comparison so the token can't be guessed byte by byte
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(ctrl.token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or missing metrics token",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetMetrics returns the circuit breaker metrics in the Prometheus text format
+// GET /metrics
+func (ctrl *MetricsController) GetMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := ctrl.breakers.WritePrometheus(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
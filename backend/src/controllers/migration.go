@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/patali/yantra/src/db"
+	"github.com/patali/yantra/src/services"
 	"gorm.io/gorm"
 )
 
@@ -63,7 +64,7 @@ func migrationAPIKeyMiddleware() gin.HandlerFunc {
 	}
 }
 
-// RunMigrations runs both River and GORM migrations programmatically
+// RunMigrations runs River and GORM migrations and encrypts stored credentials
 func (ctrl *MigrationController) RunMigrations(c *gin.Context) {
 	ctx := context.Background()
 	databaseURL := os.Getenv("DATABASE_URL")
@@ -98,8 +99,18 @@ func (ctrl *MigrationController) RunMigrations(c *gin.Context) {
 		results["gorm"].(map[string]any)["error"] = gormErr.Error()
 	}
 
+	// Encrypt any plaintext or rotated email provider credentials
+	secretsCount, secretsErr := services.MigrateEmailProviderSecrets(ctx, ctrl.db)
+	results["secrets"] = map[string]any{
+		"error":   nil,
+		"updated": secretsCount,
+	}
+	if secretsErr != nil {
+		results["secrets"].(map[string]any)["error"] = secretsErr.Error()
+	}
+
 	// Determine overall status
-	hasErrors := riverErr != nil || gormErr != nil
+	hasErrors := riverErr != nil || gormErr != nil || secretsErr != nil
 	status := "success"
 	if hasErrors {
 		status = "partial_failure"
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
+	"github.com/patali/yantra/src/middleware"
+	"github.com/patali/yantra/src/services"
+)
+
+type NodeTypeController struct {
+	registry *executors.Registry
+}
+
+func NewNodeTypeController(registry *executors.Registry) *NodeTypeController {
+	return &NodeTypeController{registry: registry}
+}
+
+// RegisterRoutes registers node type routes
+func (ctrl *NodeTypeController) RegisterRoutes(rg *gin.RouterGroup, authService *services.AuthService) {
+	nodeTypes := rg.Group("/node-types")
+	nodeTypes.Use(middleware.AuthMiddleware(authService))
+	{
+		nodeTypes.GET("", ctrl.ListNodeTypes)
+	}
+}
+
+// ListNodeTypes lists built-in and custom node types with their config schemas
+// GET /api/node-types
+func (ctrl *NodeTypeController) ListNodeTypes(c *gin.Context) {
+	nodeTypes := ctrl.registry.List()
+	response := make([]dto.NodeTypeResponse, 0, len(nodeTypes))
+	for _, t := range nodeTypes {
+		fields := make([]dto.NodeTypeConfigField, 0, len(t.Config))
+		for _, f := range t.Config {
+			fields = append(fields, dto.NodeTypeConfigField{
+				Name:        f.Name,
+				Type:        f.Type,
+				Required:    f.Required,
+				Description: f.Description,
+				Enum:        f.Enum,
+			})
+		}
+		response = append(response, dto.NodeTypeResponse{
+			Type:        t.Type,
+			Name:        t.Name,
+			Description: t.Description,
+			Category:    t.Category,
+			Async:       t.Async,
+			AsyncOptIn:  t.AsyncOptIn,
+			EventType:   t.EventType,
+			Builtin:     t.Builtin,
+			Executable:  t.New != nil,
+			Config:      fields,
+		})
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, response)
+}
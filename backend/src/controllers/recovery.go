@@ -2,10 +2,13 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/patali/yantra/src/breaker"
 	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
 	"github.com/patali/yantra/src/middleware"
 	"github.com/patali/yantra/src/services"
 )
@@ -14,17 +17,23 @@ type RecoveryController struct {
 	outboxService   *services.OutboxService
 	workflowService *services.WorkflowService
 	workflowEngine  *services.WorkflowEngineService
+	auditService    *services.AuditService
+	breakers        *breaker.Registry
 }
 
 func NewRecoveryController(
 	outboxService *services.OutboxService,
 	workflowService *services.WorkflowService,
 	workflowEngine *services.WorkflowEngineService,
+	auditService *services.AuditService,
+	breakers *breaker.Registry,
 ) *RecoveryController {
 	return &RecoveryController{
 		outboxService:   outboxService,
 		workflowService: workflowService,
 		workflowEngine:  workflowEngine,
+		auditService:    auditService,
+		breakers:        breakers,
 	}
 }
 
@@ -41,7 +50,19 @@ func (ctrl *RecoveryController) RegisterRoutes(rg *gin.RouterGroup, authService
 
 		// Dead letter queue operations (for async node failures)
 		recovery.GET("/dead-letter", ctrl.GetDeadLetterMessages)
+		recovery.POST("/dead-letter/bulk-retry", ctrl.BulkRetryDeadLetterMessages)
+		recovery.POST("/dead-letter/bulk-discard", ctrl.BulkDiscardDeadLetterMessages)
+		recovery.GET("/dead-letter/:messageId", ctrl.GetDeadLetterMessage)
+		recovery.PUT("/dead-letter/:messageId/payload", ctrl.UpdateDeadLetterPayload)
 		recovery.POST("/dead-letter/:messageId/retry", ctrl.RetryDeadLetterMessage)
+		recovery.POST("/dead-letter/:messageId/discard", ctrl.DiscardDeadLetterMessage)
+
+		// Audit log of recovery operations
+		recovery.GET("/audit-log", ctrl.GetAuditLog)
+
+		// Circuit breakers of external destinations
+		recovery.GET("/circuit-breakers", ctrl.GetCircuitBreakers)
+		recovery.POST("/circuit-breakers/reset", ctrl.ResetCircuitBreaker)
 
 		// Workflow restart operations
 		recovery.POST("/workflows/:executionId/restart", ctrl.RestartWorkflow)
@@ -96,8 +117,8 @@ func (ctrl *RecoveryController) GetFailedExecutions(c *gin.Context) {
 	middleware.RespondSuccess(c, http.StatusOK, executions)
 }
 
-// GetDeadLetterMessages returns all dead letter messages for the current account
-// GET /api/recovery/dead-letter
+// GetDeadLetterMessages returns a page of the account's dead letter messages
+// GET /api/recovery/dead-letter?workflowId=&eventType=&error=&from=&to=&limit=50&offset=0
 func (ctrl *RecoveryController) GetDeadLetterMessages(c *gin.Context) {
 	// SECURITY: Get account ID from auth middleware
 	accountID, err := middleware.RequireAccountID(c)
@@ -105,14 +126,39 @@ func (ctrl *RecoveryController) GetDeadLetterMessages(c *gin.Context) {
 		return
 	}
 
+	var query dto.DeadLetterListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+
 	// SECURITY: Filter by account ID
-	messages, err := ctrl.outboxService.GetDeadLetterMessagesByAccount(accountID, 100) // Limit to 100 messages
+	page, err := ctrl.outboxService.GetDeadLetterMessagesByAccount(c.Request.Context(), accountID, query.DeadLetterFilter, query.Limit, query.Offset)
 	if err != nil {
 		middleware.RespondInternalError(c, err.Error())
 		return
 	}
 
-	middleware.RespondSuccess(c, http.StatusOK, messages)
+	middleware.RespondSuccess(c, http.StatusOK, page)
+}
+
+// GetDeadLetterMessage returns a dead letter message with its payload and audit history
+// GET /api/recovery/dead-letter/:messageId
+func (ctrl *RecoveryController) GetDeadLetterMessage(c *gin.Context) {
+	// SECURITY: Get account ID from auth middleware
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	// SECURITY: Verify message belongs to user's account
+	detail, err := ctrl.outboxService.GetDeadLetterMessageByAccount(c.Request.Context(), c.Param("messageId"), accountID)
+	if err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, detail)
 }
 
 // RetryDeadLetterMessage retries a specific dead letter message
@@ -123,18 +169,223 @@ func (ctrl *RecoveryController) RetryDeadLetterMessage(c *gin.Context) {
 	if err != nil {
 		return
 	}
+	userID, _ := middleware.GetUserID(c)
 	messageId := c.Param("messageId")
 
 	// SECURITY: Verify message belongs to user's account
-	err = ctrl.outboxService.RetryDeadLetterMessageByAccount(messageId, accountID)
+	err = ctrl.outboxService.RetryDeadLetterMessageByAccount(c.Request.Context(), messageId, accountID, userID)
 	if err != nil {
-		middleware.RespondNotFound(c, "Message not found or access denied")
+		respondDeadLetterError(c, err)
 		return
 	}
 
 	middleware.RespondSuccess(c, http.StatusOK, gin.H{"message": "Dead letter message retry initiated"})
 }
 
+// DiscardDeadLetterMessage removes a specific message from the dead letter queue
+// POST /api/recovery/dead-letter/:messageId/discard
+func (ctrl *RecoveryController) DiscardDeadLetterMessage(c *gin.Context) {
+	// SECURITY: Get account ID from auth middleware
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 && !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	// SECURITY: Scoped to the account; an unknown message matches nothing
+	result, err := ctrl.outboxService.DiscardDeadLetterMessagesByAccount(c.Request.Context(), accountID, userID, dto.DeadLetterBulkRequest{
+		DeadLetterFilter: dto.DeadLetterFilter{MessageIDs: []string{c.Param("messageId")}},
+		Reason:           req.Reason,
+	})
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+	if result.Succeeded == 0 {
+		middleware.RespondNotFound(c, services.ErrDeadLetterNotFound.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{"message": "Dead letter message discarded"})
+}
+
+// UpdateDeadLetterPayload edits a dead letter message's payload, optionally retrying it
+// PUT /api/recovery/dead-letter/:messageId/payload
+func (ctrl *RecoveryController) UpdateDeadLetterPayload(c *gin.Context) {
+	// SECURITY: Get account ID from auth middleware
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	var req dto.UpdateDeadLetterPayloadRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	if req.NodeConfig == nil && len(req.Input) == 0 {
+		middleware.RespondBadRequest(c, "nodeConfig or input is required")
+		return
+	}
+
+	// SECURITY: Verify message belongs to user's account
+	message, err := ctrl.outboxService.UpdateDeadLetterPayloadByAccount(c.Request.Context(), c.Param("messageId"), accountID, userID, req)
+	if err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, message)
+}
+
+// BulkRetryDeadLetterMessages requeues the dead letter messages matching a filter
+// POST /api/recovery/dead-letter/bulk-retry
+func (ctrl *RecoveryController) BulkRetryDeadLetterMessages(c *gin.Context) {
+	// SECURITY: Get account ID from auth middleware
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	var req dto.DeadLetterBulkRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	if req.IsEmpty() {
+		middleware.RespondBadRequest(c, "a filter or messageIds is required")
+		return
+	}
+
+	// SECURITY: Filter by account ID
+	result, err := ctrl.outboxService.RetryDeadLetterMessagesByAccount(c.Request.Context(), accountID, userID, req)
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, result)
+}
+
+// BulkDiscardDeadLetterMessages removes the dead letter messages matching a filter from the queue
+// POST /api/recovery/dead-letter/bulk-discard
+func (ctrl *RecoveryController) BulkDiscardDeadLetterMessages(c *gin.Context) {
+	// SECURITY: Get account ID from auth middleware
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	var req dto.DeadLetterBulkRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+	if req.IsEmpty() {
+		middleware.RespondBadRequest(c, "a filter or messageIds is required")
+		return
+	}
+
+	// SECURITY: Filter by account ID
+	result, err := ctrl.outboxService.DiscardDeadLetterMessagesByAccount(c.Request.Context(), accountID, userID, req)
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, result)
+}
+
+// GetAuditLog returns the account's audit log, newest first
+// GET /api/recovery/audit-log?resourceType=&resourceId=&limit=100&offset=0
+func (ctrl *RecoveryController) GetAuditLog(c *gin.Context) {
+	// SECURITY: Get account ID from auth middleware
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var query struct {
+		ResourceType string `form:"resourceType"`
+		ResourceID   string `form:"resourceId"`
+		Limit        int    `form:"limit"`
+		Offset       int    `form:"offset"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+	if query.Limit <= 0 || query.Limit > 500 {
+		query.Limit = 100
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	// SECURITY: Filter by account ID
+	logs, err := ctrl.auditService.GetAuditLogs(c.Request.Context(), accountID, query.ResourceType, query.ResourceID, query.Limit, query.Offset)
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, logs)
+}
+
+// GetCircuitBreakers returns the circuit breakers of the account's destinations on this server
+// GET /api/recovery/circuit-breakers
+func (ctrl *RecoveryController) GetCircuitBreakers(c *gin.Context) {
+	// SECURITY: Get account ID from auth middleware
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	// SECURITY: Filter by account ID
+	middleware.RespondSuccess(c, http.StatusOK, ctrl.breakers.Statuses(accountID))
+}
+
+// ResetCircuitBreaker closes the account's breaker for a destination, e.g. once an outage is known to be over
+// POST /api/recovery/circuit-breakers/reset
+func (ctrl *RecoveryController) ResetCircuitBreaker(c *gin.Context) {
+	// SECURITY: Get account ID from auth middleware
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req struct {
+		Destination string `json:"destination" binding:"required"`
+	}
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	// SECURITY: Only the account's own breaker can be reset
+	if !ctrl.breakers.Reset(breaker.Key{AccountID: accountID, Destination: req.Destination}) {
+		middleware.RespondNotFound(c, "Circuit breaker not found")
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{"message": "Circuit breaker reset"})
+}
+
+// respondDeadLetterError maps a dead letter operation error to a response
+func respondDeadLetterError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrDeadLetterNotFound) {
+		middleware.RespondNotFound(c, err.Error())
+		return
+	}
+	middleware.RespondInternalError(c, err.Error())
+}
+
 // RestartWorkflow restarts a failed workflow execution
 // POST /api/recovery/workflows/:executionId/restart
 func (ctrl *RecoveryController) RestartWorkflow(c *gin.Context) {
@@ -226,7 +477,8 @@ func (ctrl *RecoveryController) CancelWorkflow(c *gin.Context) {
 	})
 }
 
-// ReExecuteNode retries a specific node execution
+// ReExecuteNode retries a failed node with its config from the workflow version the execution ran.
+// With "resume": true the execution continues downstream from the node once the retry succeeds
 // POST /api/recovery/executions/:executionId/nodes/:nodeId/retry
 func (ctrl *RecoveryController) ReExecuteNode(c *gin.Context) {
 	// SECURITY: Get account ID from auth middleware
@@ -237,6 +489,11 @@ func (ctrl *RecoveryController) ReExecuteNode(c *gin.Context) {
 	executionId := c.Param("executionId")
 	nodeId := c.Param("nodeId")
 
+	var req dto.RetryNodeRequest
+	if c.Request.ContentLength > 0 && !middleware.BindJSON(c, &req) {
+		return
+	}
+
 	// Get the execution
 	execution, err := ctrl.workflowService.GetWorkflowExecutionById(executionId)
 	if err != nil {
@@ -251,12 +508,15 @@ func (ctrl *RecoveryController) ReExecuteNode(c *gin.Context) {
 		return
 	}
 
-	// Find the specific node execution
+	// Find the most recent execution of the node (earlier retries leave older rows behind)
 	var nodeExecution *dto.NodeExecutionResponse
-	for _, nodeExec := range execution.NodeExecutions {
-		if nodeExec.NodeID == nodeId {
-			nodeExecution = &nodeExec
-			break
+	for i := range execution.NodeExecutions {
+		nodeExec := &execution.NodeExecutions[i]
+		if nodeExec.NodeID != nodeId {
+			continue
+		}
+		if nodeExecution == nil || isLaterNodeExecution(nodeExec, nodeExecution) {
+			nodeExecution = nodeExec
 		}
 	}
 
@@ -271,16 +531,8 @@ func (ctrl *RecoveryController) ReExecuteNode(c *gin.Context) {
 		return
 	}
 
-	// Check if this is an asynchronous node that can be retried
-	canRetry := false
-	switch nodeExecution.NodeType {
-	case "email", "http", "slack":
-		canRetry = true
-	default:
-		canRetry = false
-	}
-
-	if !canRetry {
+	// Retries are delivered through the outbox, so only node types it can run are retried individually
+	if !executors.SupportsAsync(nodeExecution.NodeType) {
 		middleware.RespondBadRequest(c, "This node type cannot be retried individually")
 		return
 	}
@@ -296,38 +548,28 @@ func (ctrl *RecoveryController) ReExecuteNode(c *gin.Context) {
 		nodeInput = make(map[string]interface{})
 	}
 
-	// Create a new outbox message for retry
-	// This will trigger the outbox worker to process the node again
 	// Note: accountID already verified via GetWorkflowByIdAndAccount above
-	_, _, err = ctrl.outboxService.ExecuteNodeWithOutbox(
-		c.Request.Context(),
-		executionId,
-		&accountID, // Pass as pointer
-		nodeId,
-		nodeExecution.NodeType,
-		make(map[string]interface{}), // Node config - would need to be retrieved from workflow definition
-		nodeInput,
-		getEventTypeForNodeType(nodeExecution.NodeType),
-	)
-
+	message, err := ctrl.workflowEngine.RetryNode(c.Request.Context(), executionId, accountID, nodeId, nodeInput, req.Resume)
+	if errors.Is(err, services.ErrRetryNodeNotFound) {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
 	if err != nil {
 		middleware.RespondInternalError(c, err.Error())
 		return
 	}
 
-	middleware.RespondSuccess(c, http.StatusOK, gin.H{"message": "Node retry initiated"})
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{
+		"message":   "Node retry initiated",
+		"messageId": message.ID,
+		"resume":    req.Resume,
+	})
 }
 
-// Helper function to get event type for node type
-func getEventTypeForNodeType(nodeType string) string {
-	switch nodeType {
-	case "email":
-		return "email.send"
-	case "http":
-		return "http.request"
-	case "slack":
-		return "slack.send"
-	default:
-		return "unknown"
+// isLaterNodeExecution reports whether a started after b; rows without a start time sort first
+func isLaterNodeExecution(a, b *dto.NodeExecutionResponse) bool {
+	if a.StartedAt == nil {
+		return false
 	}
+	return b.StartedAt == nil || a.StartedAt.After(*b.StartedAt)
 }
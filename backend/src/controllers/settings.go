@@ -5,7 +5,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/executors"
 	"github.com/patali/yantra/src/middleware"
+	"github.com/patali/yantra/src/retry"
+	"github.com/patali/yantra/src/secrets"
 	"github.com/patali/yantra/src/services"
 	"gorm.io/gorm"
 )
@@ -32,6 +35,15 @@ func (ctrl *SettingsController) RegisterRoutes(rg *gin.RouterGroup, authService
 		settings.POST("/email-providers/test", ctrl.TestEmailProvider)
 		settings.PUT("/email-providers/activate", ctrl.SetActiveEmailProvider)
 
+		// Outbound request policy for HTTP and Slack nodes
+		settings.GET("/egress-policy", ctrl.GetEgressPolicy)
+		settings.PUT("/egress-policy", ctrl.UpdateEgressPolicy)
+
+		// Account default retry policies for outbox deliveries
+		settings.GET("/retry-policies", ctrl.GetRetryPolicies)
+		settings.PUT("/retry-policies/:nodeType", ctrl.UpdateRetryPolicy)
+		settings.DELETE("/retry-policies/:nodeType", ctrl.DeleteRetryPolicy)
+
 		// Alternative endpoints (singular)
 		settings.GET("/email", ctrl.GetEmailProviders)
 		settings.POST("/email", ctrl.CreateEmailProvider)
@@ -72,11 +84,12 @@ func (ctrl *SettingsController) GetEmailProviders(c *gin.Context) {
 	}
 
 	// Return empty array instead of null if no providers
-	if providers == nil {
-		providers = []models.EmailProviderSettings{}
+	masked := make([]models.EmailProviderSettings, 0, len(providers))
+	for _, provider := range providers {
+		masked = append(masked, services.MaskEmailProviderSecrets(provider))
 	}
 
-	middleware.RespondSuccess(c, http.StatusOK, providers)
+	middleware.RespondSuccess(c, http.StatusOK, masked)
 }
 
 // CreateEmailProvider creates or updates an email provider configuration
@@ -98,20 +111,10 @@ func (ctrl *SettingsController) CreateEmailProvider(c *gin.Context) {
 
 	if result.Error == nil {
 		// Provider exists, update it
-		updates := map[string]interface{}{
-			"api_key":           ptrString(req.APIKey),
-			"domain":            ptrString(req.Domain),
-			"from_email":        ptrString(req.FromEmail),
-			"from_name":         ptrString(req.FromName),
-			"region":            ptrString(req.Region),
-			"access_key_id":     ptrString(req.AccessKeyID),
-			"secret_access_key": ptrString(req.SecretAccessKey),
-			"is_active":         req.IsActive,
-			"smtp_host":         ptrString(req.SMTPHost),
-			"smtp_port":         ptrInt(req.SMTPPort),
-			"smtp_user":         ptrString(req.SMTPUser),
-			"smtp_password":     ptrString(req.SMTPPassword),
-			"smtp_secure":       req.SMTPSecure,
+		updates, err := buildEmailProviderUpdates(req)
+		if err != nil {
+			middleware.RespondInternalError(c, err.Error())
+			return
 		}
 
 		if err := ctrl.db.Model(&existing).Updates(updates).Error; err != nil {
@@ -121,7 +124,7 @@ func (ctrl *SettingsController) CreateEmailProvider(c *gin.Context) {
 
 		// Reload the updated provider
 		ctrl.db.First(&existing, "id = ?", existing.ID)
-		middleware.RespondSuccess(c, http.StatusOK, existing)
+		middleware.RespondSuccess(c, http.StatusOK, services.MaskEmailProviderSecrets(existing))
 		return
 	}
 
@@ -129,27 +132,33 @@ func (ctrl *SettingsController) CreateEmailProvider(c *gin.Context) {
 	provider := models.EmailProviderSettings{
 		AccountID:       accountID,
 		Provider:        req.Provider,
-		APIKey:          ptrString(req.APIKey),
+		APIKey:          ptrSecret(req.APIKey),
 		Domain:          ptrString(req.Domain),
 		FromEmail:       ptrString(req.FromEmail),
 		FromName:        ptrString(req.FromName),
 		Region:          ptrString(req.Region),
 		AccessKeyID:     ptrString(req.AccessKeyID),
-		SecretAccessKey: ptrString(req.SecretAccessKey),
+		SecretAccessKey: ptrSecret(req.SecretAccessKey),
 		IsActive:        req.IsActive,
 		SMTPHost:        ptrString(req.SMTPHost),
 		SMTPPort:        ptrInt(req.SMTPPort),
 		SMTPUser:        ptrString(req.SMTPUser),
-		SMTPPassword:    ptrString(req.SMTPPassword),
+		SMTPPassword:    ptrSecret(req.SMTPPassword),
 		SMTPSecure:      req.SMTPSecure,
 	}
 
+	// SECURITY: Credentials are encrypted at rest and never echoed back
+	if err := services.EncryptEmailProviderSecrets(&provider); err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
 	if err := ctrl.db.Create(&provider).Error; err != nil {
 		middleware.RespondInternalError(c, err.Error())
 		return
 	}
 
-	middleware.RespondSuccess(c, http.StatusCreated, provider)
+	middleware.RespondSuccess(c, http.StatusCreated, services.MaskEmailProviderSecrets(provider))
 }
 
 // UpdateEmailProvider updates an email provider configuration
@@ -174,20 +183,10 @@ func (ctrl *SettingsController) UpdateEmailProvider(c *gin.Context) {
 	}
 
 	// Update fields
-	updates := map[string]interface{}{
-		"api_key":           ptrString(req.APIKey),
-		"domain":            ptrString(req.Domain),
-		"from_email":        ptrString(req.FromEmail),
-		"from_name":         ptrString(req.FromName),
-		"region":            ptrString(req.Region),
-		"access_key_id":     ptrString(req.AccessKeyID),
-		"secret_access_key": ptrString(req.SecretAccessKey),
-		"is_active":         req.IsActive,
-		"smtp_host":         ptrString(req.SMTPHost),
-		"smtp_port":         ptrInt(req.SMTPPort),
-		"smtp_user":         ptrString(req.SMTPUser),
-		"smtp_password":     ptrString(req.SMTPPassword),
-		"smtp_secure":       req.SMTPSecure,
+	updates, err := buildEmailProviderUpdates(req)
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
 	}
 
 	if err := ctrl.db.Model(&provider).Updates(updates).Error; err != nil {
@@ -198,7 +197,7 @@ func (ctrl *SettingsController) UpdateEmailProvider(c *gin.Context) {
 	// Reload
 	ctrl.db.First(&provider, "id = ?", id)
 
-	middleware.RespondSuccess(c, http.StatusOK, provider)
+	middleware.RespondSuccess(c, http.StatusOK, services.MaskEmailProviderSecrets(provider))
 }
 
 // DeleteEmailProvider deletes an email provider configuration
@@ -239,7 +238,7 @@ func (ctrl *SettingsController) GetEmailProviderByName(c *gin.Context) {
 		return
 	}
 
-	middleware.RespondSuccess(c, http.StatusOK, emailProvider)
+	middleware.RespondSuccess(c, http.StatusOK, services.MaskEmailProviderSecrets(emailProvider))
 }
 
 // TestEmailProvider tests an email provider configuration
@@ -283,6 +282,28 @@ func (ctrl *SettingsController) TestEmailProvider(c *gin.Context) {
 		SMTPSecure:      req.SMTPSecure,
 	}
 
+	// Masked placeholders mean "use the stored credential" (e.g. testing an existing provider)
+	if secrets.IsMasked(req.APIKey) || secrets.IsMasked(req.SecretAccessKey) || secrets.IsMasked(req.SMTPPassword) {
+		var stored models.EmailProviderSettings
+		if err := ctrl.db.Where("account_id = ? AND provider = ?", accountID, req.Provider).First(&stored).Error; err != nil {
+			middleware.RespondNotFound(c, "Provider not found")
+			return
+		}
+		if err := services.DecryptEmailProviderSecrets(&stored); err != nil {
+			middleware.RespondInternalError(c, err.Error())
+			return
+		}
+		if secrets.IsMasked(req.APIKey) {
+			config.APIKey = stored.APIKey
+		}
+		if secrets.IsMasked(req.SecretAccessKey) {
+			config.SecretAccessKey = stored.SecretAccessKey
+		}
+		if secrets.IsMasked(req.SMTPPassword) {
+			config.SMTPPassword = stored.SMTPPassword
+		}
+	}
+
 	// Initialize email service and test the provider
 	emailService := services.NewEmailService(ctrl.db)
 	result, err := emailService.TestProviderToEmail(c.Request.Context(), accountID, services.EmailProvider(req.Provider), config, user.Email)
@@ -342,10 +363,149 @@ func (ctrl *SettingsController) SetActiveEmailProvider(c *gin.Context) {
 		return
 	}
 
-	middleware.RespondSuccess(c, http.StatusOK, provider)
+	middleware.RespondSuccess(c, http.StatusOK, services.MaskEmailProviderSecrets(provider))
+}
+
+// GetEgressPolicy returns the account's outbound host rules
+// GET /api/settings/egress-policy
+func (ctrl *SettingsController) GetEgressPolicy(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	rules, err := services.NewEgressPolicyService(ctrl.db).GetRules(c.Request.Context(), accountID)
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, rules)
+}
+
+// UpdateEgressPolicy replaces the account's outbound host rules
+// PUT /api/settings/egress-policy
+func (ctrl *SettingsController) UpdateEgressPolicy(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req executors.EgressRules
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	rules, err := services.NewEgressPolicyService(ctrl.db).UpdateRules(c.Request.Context(), accountID, req)
+	if err != nil {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, rules)
+}
+
+// GetRetryPolicies returns the built-in retry policy and the account's defaults by node type
+// GET /api/settings/retry-policies
+func (ctrl *SettingsController) GetRetryPolicies(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	policies, err := services.NewRetryPolicyService(ctrl.db).GetPolicies(c.Request.Context(), accountID)
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{
+		"defaults": retry.Default(),
+		"policies": policies,
+	})
+}
+
+// UpdateRetryPolicy sets the account's default retry policy for an async node type
+// PUT /api/settings/retry-policies/:nodeType
+func (ctrl *SettingsController) UpdateRetryPolicy(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req retry.Policy
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	policy, err := services.NewRetryPolicyService(ctrl.db).UpdatePolicy(c.Request.Context(), accountID, c.Param("nodeType"), req)
+	if err != nil {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, policy)
+}
+
+// DeleteRetryPolicy removes the account's default retry policy for a node type
+// DELETE /api/settings/retry-policies/:nodeType
+func (ctrl *SettingsController) DeleteRetryPolicy(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	if err := services.NewRetryPolicyService(ctrl.db).DeletePolicy(c.Request.Context(), accountID, c.Param("nodeType")); err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{"message": "Retry policy removed"})
+}
+
+// buildEmailProviderUpdates builds the column updates for a provider request
+// Secrets are encrypted; masked placeholders leave the stored value unchanged
+func buildEmailProviderUpdates(req EmailProviderRequest) (map[string]interface{}, error) {
+	updates := map[string]interface{}{
+		"domain":        ptrString(req.Domain),
+		"from_email":    ptrString(req.FromEmail),
+		"from_name":     ptrString(req.FromName),
+		"region":        ptrString(req.Region),
+		"access_key_id": ptrString(req.AccessKeyID),
+		"is_active":     req.IsActive,
+		"smtp_host":     ptrString(req.SMTPHost),
+		"smtp_port":     ptrInt(req.SMTPPort),
+		"smtp_user":     ptrString(req.SMTPUser),
+		"smtp_secure":   req.SMTPSecure,
+	}
+
+	secretValues := map[string]string{
+		"api_key":           req.APIKey,
+		"secret_access_key": req.SecretAccessKey,
+		"smtp_password":     req.SMTPPassword,
+	}
+	for column, value := range secretValues {
+		if secrets.IsMasked(value) {
+			continue
+		}
+		encrypted, err := services.EncryptSecret(ptrString(value))
+		if err != nil {
+			return nil, err
+		}
+		updates[column] = encrypted
+	}
+
+	return updates, nil
 }
 
 // Helper functions
+func ptrSecret(s string) *string {
+	if secrets.IsMasked(s) {
+		return nil
+	}
+	return ptrString(s)
+}
+
 func ptrString(s string) *string {
 	if s == "" {
 		return nil
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/middleware"
+	"github.com/patali/yantra/src/services"
+	"gorm.io/gorm"
+)
+
+type WasmPluginController struct {
+	pluginService *services.WasmPluginService
+}
+
+func NewWasmPluginController(pluginService *services.WasmPluginService) *WasmPluginController {
+	return &WasmPluginController{pluginService: pluginService}
+}
+
+// RegisterRoutes registers WASM plugin routes
+func (ctrl *WasmPluginController) RegisterRoutes(rg *gin.RouterGroup, authService *services.AuthService) {
+	plugins := rg.Group("/wasm-plugins")
+	plugins.Use(middleware.AuthMiddleware(authService))
+	{
+		plugins.GET("", ctrl.ListPlugins)
+		plugins.POST("", ctrl.CreatePlugin)
+		plugins.GET("/:id", ctrl.GetPlugin)
+		plugins.PUT("/:id", ctrl.UpdatePlugin)
+		plugins.DELETE("/:id", ctrl.DeletePlugin)
+	}
+}
+
+// ListPlugins lists the account's plugins
+// GET /api/wasm-plugins
+func (ctrl *WasmPluginController) ListPlugins(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	plugins, err := ctrl.pluginService.ListPlugins(c.Request.Context(), accountID)
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, plugins)
+}
+
+// CreatePlugin uploads a plugin (base64 module in JSON)
+// POST /api/wasm-plugins
+func (ctrl *WasmPluginController) CreatePlugin(c *gin.Context) {
+	userID, err := middleware.RequireUserID(c)
+	if err != nil {
+		return
+	}
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req dto.CreateWasmPluginRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	plugin, err := ctrl.pluginService.CreatePlugin(c.Request.Context(), accountID, userID, req)
+	if err != nil {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusCreated, plugin)
+}
+
+// GetPlugin returns a plugin's metadata
+// GET /api/wasm-plugins/:id
+func (ctrl *WasmPluginController) GetPlugin(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	plugin, err := ctrl.pluginService.GetPluginByID(c.Request.Context(), accountID, c.Param("id"))
+	if err != nil {
+		middleware.RespondNotFound(c, "Plugin not found")
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, plugin)
+}
+
+// UpdatePlugin replaces a plugin's module or settings
+// PUT /api/wasm-plugins/:id
+func (ctrl *WasmPluginController) UpdatePlugin(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req dto.UpdateWasmPluginRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	plugin, err := ctrl.pluginService.UpdatePlugin(c.Request.Context(), accountID, c.Param("id"), req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		middleware.RespondNotFound(c, "Plugin not found")
+		return
+	}
+	if err != nil {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, plugin)
+}
+
+// DeletePlugin deletes a plugin
+// DELETE /api/wasm-plugins/:id
+func (ctrl *WasmPluginController) DeletePlugin(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	err = ctrl.pluginService.DeletePlugin(c.Request.Context(), accountID, c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		middleware.RespondNotFound(c, "Plugin not found")
+		return
+	}
+	if err != nil {
+		middleware.RespondInternalError(c, err.Error())
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{"message": "Plugin deleted successfully"})
+}
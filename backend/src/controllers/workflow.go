@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/patali/yantra/src/db/models"
 	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
 	"github.com/patali/yantra/src/middleware"
 	"github.com/patali/yantra/src/services"
 )
@@ -33,11 +35,17 @@ func (ctrl *WorkflowController) RegisterRoutes(rg *gin.RouterGroup, authService
 		workflows.GET("/", ctrl.GetAllWorkflows) // Alternative with slash
 		workflows.POST("", ctrl.CreateWorkflow)  // Frontend compatible (no trailing slash)
 		workflows.POST("/", ctrl.CreateWorkflow) // Alternative with slash
+		workflows.POST("/validate", ctrl.ValidateWorkflow)
 		workflows.GET("/:id", ctrl.GetWorkflowById)
 		workflows.PUT("/:id", ctrl.UpdateWorkflow)
 		workflows.DELETE("/:id", ctrl.DeleteWorkflow)
 		workflows.POST("/:id/execute", ctrl.ExecuteWorkflow)
 		workflows.PUT("/:id/schedule", ctrl.UpdateSchedule)
+		workflows.GET("/:id/schedules", ctrl.ListWorkflowSchedules)
+		workflows.POST("/:id/schedules", ctrl.CreateWorkflowSchedule)
+		workflows.GET("/:id/schedules/preview", ctrl.PreviewWorkflowSchedules)
+		workflows.PUT("/:id/schedules/:scheduleId", ctrl.UpdateWorkflowSchedule)
+		workflows.DELETE("/:id/schedules/:scheduleId", ctrl.DeleteWorkflowSchedule)
 		workflows.GET("/:id/versions", ctrl.GetVersionHistory)
 		workflows.GET("/:id/executions", ctrl.GetWorkflowExecutions)                       // Frontend endpoint
 		workflows.GET("/:id/executions/:executionId", ctrl.GetWorkflowExecutionById)       // Frontend endpoint
@@ -119,6 +127,9 @@ func (ctrl *WorkflowController) CreateWorkflow(c *gin.Context) {
 
 	workflow, err := ctrl.workflowService.CreateWorkflow(c.Request.Context(), req, userID, accountID)
 	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
 		middleware.RespondInternalError(c, err.Error())
 		return
 	}
@@ -126,6 +137,30 @@ func (ctrl *WorkflowController) CreateWorkflow(c *gin.Context) {
 	middleware.RespondSuccess(c, http.StatusCreated, workflow)
 }
 
+// ValidateWorkflow checks a workflow definition without saving it
+// POST /api/workflows/validate
+func (ctrl *WorkflowController) ValidateWorkflow(c *gin.Context) {
+	var req dto.ValidateWorkflowRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, ctrl.workflowService.ValidateWorkflow(req))
+}
+
+// respondValidationError sends a 400 listing every validation issue; returns false if err is not a validation error
+func respondValidationError(c *gin.Context, err error) bool {
+	validationErr, ok := services.AsWorkflowValidationError(err)
+	if !ok {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "invalid workflow definition",
+		"issues": validationErr.Issues,
+	})
+	return true
+}
+
 // UpdateWorkflow updates a workflow
 // PUT /api/workflows/:id
 func (ctrl *WorkflowController) UpdateWorkflow(c *gin.Context) {
@@ -142,6 +177,9 @@ func (ctrl *WorkflowController) UpdateWorkflow(c *gin.Context) {
 
 	workflow, err := ctrl.workflowService.UpdateWorkflowByAccount(id, accountID, req)
 	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
 		middleware.RespondNotFound(c, "Workflow not found")
 		return
 	}
@@ -176,7 +214,11 @@ func (ctrl *WorkflowController) ExecuteWorkflow(c *gin.Context) {
 		return
 	}
 
-	jobID, executionID, err := ctrl.workflowService.ExecuteWorkflow(c.Request.Context(), id, req.Input)
+	jobID, executionID, err := ctrl.workflowService.ExecuteWorkflowWithTrigger(c.Request.Context(), id, req.Input, models.TriggerTypeManual, req.Environment)
+	if errors.Is(err, services.ErrEnvironmentNotFound) {
+		middleware.RespondBadRequest(c, err.Error())
+		return
+	}
 	if err != nil {
 		middleware.RespondInternalError(c, err.Error())
 		return
@@ -200,6 +242,9 @@ func (ctrl *WorkflowController) UpdateSchedule(c *gin.Context) {
 	}
 
 	if err := ctrl.workflowService.UpdateSchedule(c.Request.Context(), id, req); err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
 		middleware.RespondInternalError(c, err.Error())
 		return
 	}
@@ -491,6 +536,9 @@ func (ctrl *WorkflowController) RestoreVersion(c *gin.Context) {
 	}
 
 	if err := ctrl.workflowService.RestoreWorkflowVersion(id, req.Version); err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
 		middleware.RespondBadRequest(c, err.Error())
 		return
 	}
@@ -567,12 +615,9 @@ func getRetryableNodes(nodeExecutions []dto.NodeExecutionResponse) []string {
 	var retryableNodes []string
 
 	for _, nodeExec := range nodeExecutions {
-		if nodeExec.Status == "error" {
-			// Only asynchronous nodes can be retried individually
-			switch nodeExec.NodeType {
-			case "email", "http", "slack":
-				retryableNodes = append(retryableNodes, nodeExec.NodeID)
-			}
+		// Retries run through the outbox, so only node types it can deliver are retried individually
+		if nodeExec.Status == "error" && executors.SupportsAsync(nodeExec.NodeType) {
+			retryableNodes = append(retryableNodes, nodeExec.NodeID)
 		}
 	}
 
@@ -708,7 +753,12 @@ func (ctrl *WorkflowController) TriggerWebhook(c *gin.Context) {
 	}
 
 	// Trigger workflow execution with "webhook" trigger type
-	jobID, executionID, err := ctrl.workflowService.ExecuteWorkflowWithTrigger(c.Request.Context(), workflowID, input, models.TriggerTypeWebhook)
+	// ?environment=staging selects the account environment (default environment if omitted)
+	jobID, executionID, err := ctrl.workflowService.ExecuteWorkflowWithTrigger(c.Request.Context(), workflowID, input, models.TriggerTypeWebhook, c.Query("environment"))
+	if errors.Is(err, services.ErrEnvironmentNotFound) {
+		middleware.RespondBadRequest(c, "Unknown environment")
+		return
+	}
 	if err != nil {
 		// SECURITY: Don't expose internal errors - use generic message
 		middleware.RespondInternalError(c, "Failed to trigger workflow")
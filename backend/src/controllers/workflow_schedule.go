@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/middleware"
+	"github.com/patali/yantra/src/services"
+)
+
+// ListWorkflowSchedules lists a workflow's schedules
+// GET /api/workflows/:id/schedules
+func (ctrl *WorkflowController) ListWorkflowSchedules(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	schedules, err := ctrl.workflowService.ListWorkflowSchedules(c.Request.Context(), c.Param("id"), accountID)
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, schedules)
+}
+
+// CreateWorkflowSchedule adds a schedule to a workflow
+// POST /api/workflows/:id/schedules
+func (ctrl *WorkflowController) CreateWorkflowSchedule(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req dto.CreateWorkflowScheduleRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	schedule, err := ctrl.workflowService.CreateWorkflowSchedule(c.Request.Context(), c.Param("id"), accountID, req)
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusCreated, schedule)
+}
+
+// UpdateWorkflowSchedule updates a schedule; {"enabled": false} pauses it
+// PUT /api/workflows/:id/schedules/:scheduleId
+func (ctrl *WorkflowController) UpdateWorkflowSchedule(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	var req dto.UpdateWorkflowScheduleRequest
+	if !middleware.BindJSON(c, &req) {
+		return
+	}
+
+	schedule, err := ctrl.workflowService.UpdateWorkflowSchedule(c.Request.Context(), c.Param("id"), c.Param("scheduleId"), accountID, req)
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, schedule)
+}
+
+// DeleteWorkflowSchedule deletes a schedule
+// DELETE /api/workflows/:id/schedules/:scheduleId
+func (ctrl *WorkflowController) DeleteWorkflowSchedule(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	if err := ctrl.workflowService.DeleteWorkflowSchedule(c.Request.Context(), c.Param("id"), c.Param("scheduleId"), accountID); err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
+
+// PreviewWorkflowSchedules returns a workflow's next fire times
+// GET /api/workflows/:id/schedules/preview?count=10[&cron=0 9 * * *&timezone=Europe/Berlin]
+func (ctrl *WorkflowController) PreviewWorkflowSchedules(c *gin.Context) {
+	accountID, err := middleware.RequireAccountID(c)
+	if err != nil {
+		return
+	}
+
+	count := services.DefaultSchedulePreviewCount
+	if raw := c.Query("count"); raw != "" {
+		count, err = strconv.Atoi(raw)
+		if err != nil || count < 1 || count > services.MaxSchedulePreviewCount {
+			middleware.RespondBadRequest(c, "count must be between 1 and "+strconv.Itoa(services.MaxSchedulePreviewCount))
+			return
+		}
+	}
+
+	runs, err := ctrl.workflowService.PreviewWorkflowSchedules(c.Request.Context(), c.Param("id"), accountID, count, c.Query("cron"), c.Query("timezone"))
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, gin.H{"runs": runs})
+}
+
+// respondScheduleError maps schedule errors: unknown workflow or schedule is 404, validation issues are listed
+func respondScheduleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWorkflowNotFound):
+		middleware.RespondNotFound(c, "Workflow not found")
+	case errors.Is(err, services.ErrWorkflowScheduleNotFound):
+		middleware.RespondNotFound(c, "Schedule not found")
+	case respondValidationError(c, err):
+	default:
+		middleware.RespondBadRequest(c, err.Error())
+	}
+}
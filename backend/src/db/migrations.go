@@ -18,11 +18,18 @@ func (d *Database) AutoMigrate() error {
 		&models.AccountMember{},
 		&models.Workflow{},
 		&models.WorkflowVersion{},
+		&models.WorkflowSchedule{},
 		&models.WorkflowExecution{},
 		&models.WorkflowNodeExecution{},
+		&models.LoopIteration{},
 		&models.OutboxMessage{},
 		&models.EmailProviderSettings{},
 		&models.SleepSchedule{},
+		&models.AccountEgressPolicy{},
+		&models.AccountRetryPolicy{},
+		&models.AuditLog{},
+		&models.WasmPlugin{},
+		&models.Environment{},
 	)
 
 	if err != nil {
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit log actions
+const (
+	AuditDeadLetterRetry       = "dead_letter.retry"
+	AuditDeadLetterDiscard     = "dead_letter.discard"
+	AuditDeadLetterEditPayload = "dead_letter.edit_payload"
+)
+
+// AuditLog records an operator action on one of an account's resources
+type AuditLog struct {
+	ID           string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AccountID    string    `gorm:"type:uuid;not null;index:idx_audit_account_created" json:"accountId"`
+	UserID       *string   `gorm:"type:uuid" json:"userId,omitempty"`
+	Action       string    `gorm:"not null" json:"action"`
+	ResourceType string    `gorm:"not null;index:idx_audit_resource" json:"resourceType"` // outbox_message
+	ResourceID   string    `gorm:"not null;index:idx_audit_resource" json:"resourceId"`
+	Details      *string   `gorm:"type:text" json:"details,omitempty"` // JSON object describing the change
+	CreatedAt    time.Time `gorm:"autoCreateTime;index:idx_audit_account_created" json:"createdAt"`
+
+	// Relationships
+	Account Account `gorm:"foreignKey:AccountID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
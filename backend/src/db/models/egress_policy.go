@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// AccountEgressPolicy stores per-account outbound host rules for HTTP and Slack nodes
+// Host lists are JSON arrays of hostnames or "*.example.com" wildcards
+type AccountEgressPolicy struct {
+	AccountID    string    `gorm:"type:uuid;primaryKey" json:"accountId"`
+	AllowedHosts string    `gorm:"type:text;not null;default:'[]'" json:"-"`
+	DeniedHosts  string    `gorm:"type:text;not null;default:'[]'" json:"-"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	// Relationships
+	Account Account `gorm:"foreignKey:AccountID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (AccountEgressPolicy) TableName() string {
+	return "account_egress_policies"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Environment is an account-level set of variable overrides (e.g. dev, staging, prod)
+// Variables is a JSON object applied on top of each workflow's own variables
+type Environment struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AccountID string    `gorm:"type:uuid;not null;uniqueIndex:idx_environments_account_name" json:"accountId"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_environments_account_name" json:"name"`
+	IsDefault bool      `gorm:"not null;default:false" json:"isDefault"`
+	Variables string    `gorm:"type:text;not null;default:'{}'" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	// Relationships
+	Account Account `gorm:"foreignKey:AccountID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (Environment) TableName() string {
+	return "account_environments"
+}
+
+func (e *Environment) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -13,9 +13,12 @@ type WorkflowExecution struct {
 	Version     int        `gorm:"not null" json:"version"`
 	Status      string     `gorm:"not null" json:"status"`            // running, success, error, interrupted, sleeping, queued
 	TriggerType string     `gorm:"not null" json:"triggerType"`       // manual, scheduled, webhook, resume
+	Environment *string    `json:"environment,omitempty"`             // Environment whose variables apply (nil = account default)
 	Input       *string    `gorm:"type:text" json:"input,omitempty"`  // JSON string
 	Output      *string    `gorm:"type:text" json:"output,omitempty"` // JSON string
 	Error       *string    `gorm:"type:text" json:"error,omitempty"`
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty"`                 // Fire time of a scheduled run
+	ScheduleID  *string    `gorm:"type:uuid" json:"scheduleId,omitempty"` // Workflow schedule that started the run (nil = the workflow's own schedule)
 	StartedAt   time.Time  `gorm:"autoCreateTime" json:"startedAt"`
 	CompletedAt *time.Time `json:"completedAt,omitempty"`
 }
@@ -56,3 +59,31 @@ func (wne *WorkflowNodeExecution) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// LoopIteration checkpoints a finished iteration of a top-level loop so a resumed
+// execution continues at the first unfinished iteration instead of starting over.
+// Output is the value the iteration added to a loop accumulator (JSON, nil when nothing was added)
+type LoopIteration struct {
+	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ExecutionID string    `gorm:"type:uuid;not null;uniqueIndex:idx_loop_iterations_execution_loop_index" json:"executionId"`
+	LoopNodeID  string    `gorm:"not null;uniqueIndex:idx_loop_iterations_execution_loop_index" json:"loopNodeId"`
+	Iteration   int       `gorm:"not null;uniqueIndex:idx_loop_iterations_execution_loop_index" json:"iteration"`
+	Status      string    `gorm:"not null" json:"status"`            // success, skipped, error
+	Output      *string   `gorm:"type:text" json:"output,omitempty"` // JSON string
+	Error       *string   `gorm:"type:text" json:"error,omitempty"`
+	CompletedAt time.Time `gorm:"autoCreateTime" json:"completedAt"`
+
+	// Relationships
+	Execution WorkflowExecution `gorm:"foreignKey:ExecutionID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (LoopIteration) TableName() string {
+	return "loop_iterations"
+}
+
+func (li *LoopIteration) BeforeCreate(tx *gorm.DB) error {
+	if li.ID == "" {
+		li.ID = uuid.New().String()
+	}
+	return nil
+}
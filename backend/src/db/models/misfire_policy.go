@@ -0,0 +1,36 @@
+package models
+
+// Misfire policy constants - what the scheduler does with scheduled runs it missed while it was down
+const (
+	// MisfireSkip drops missed runs; the schedule continues with its next fire time
+	MisfireSkip = "skip"
+
+	// MisfireRunOnce starts a single run for the most recent missed fire time
+	MisfireRunOnce = "run_once"
+
+	// MisfireRunAll starts a run for every missed fire time, up to the workflow's MaxMissedRuns
+	MisfireRunAll = "run_all"
+)
+
+// DefaultMaxMissedRuns is how many missed runs run_all starts when a workflow does not set a cap
+const DefaultMaxMissedRuns = 10
+
+// MaxMissedRunsLimit is the highest cap a workflow may set for run_all
+const MaxMissedRunsLimit = 100
+
+// AllMisfirePolicies contains all valid misfire policies for validation
+var AllMisfirePolicies = []string{
+	MisfireSkip,
+	MisfireRunOnce,
+	MisfireRunAll,
+}
+
+// IsValidMisfirePolicy returns true if the misfire policy is valid
+func IsValidMisfirePolicy(policy string) bool {
+	for _, p := range AllMisfirePolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
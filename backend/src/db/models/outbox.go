@@ -12,13 +12,16 @@ type OutboxMessage struct {
 	NodeExecutionID string     `gorm:"type:uuid;not null" json:"nodeExecutionId"`
 	EventType       string     `gorm:"not null" json:"eventType"`         // email.send, http.request, slack.send, etc.
 	Payload         string     `gorm:"type:text;not null" json:"payload"` // JSON payload with all data needed
-	Status          string     `gorm:"default:pending" json:"status"`     // pending, processing, completed, dead_letter
+	Status          string     `gorm:"default:pending" json:"status"`     // pending, processing, completed, dead_letter, cancelled, discarded
 	IdempotencyKey  string     `gorm:"uniqueIndex;not null" json:"idempotencyKey"`
 	Attempts        int        `gorm:"default:0" json:"attempts"`
 	MaxAttempts     int        `gorm:"default:3" json:"maxAttempts"`
 	LastError       *string    `gorm:"type:text" json:"lastError,omitempty"`
 	LastAttemptAt   *time.Time `json:"lastAttemptAt,omitempty"`
 	NextRetryAt     *time.Time `gorm:"index:idx_outbox_status_retry" json:"nextRetryAt,omitempty"`
+	RiverJobID      *int64     `gorm:"index" json:"riverJobId,omitempty"`      // River job delivering the message; River owns its retries
+	RetryPolicy     *string    `gorm:"type:text" json:"retryPolicy,omitempty"` // JSON retry.Policy resolved when the message was queued
+	ResumeExecution bool       `gorm:"default:false" json:"resumeExecution"`   // Continue the workflow execution downstream once delivered (node retries)
 	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"createdAt"`
 	ProcessedAt     *time.Time `json:"processedAt,omitempty"`
 
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// AccountRetryPolicy stores an account's default outbox retry policy for one async node type
+// Zero fields are unset; node configs override the policy field by field
+type AccountRetryPolicy struct {
+	AccountID      string    `gorm:"type:uuid;primaryKey" json:"accountId"`
+	NodeType       string    `gorm:"primaryKey" json:"nodeType"`
+	MaxAttempts    int       `gorm:"not null;default:0" json:"maxAttempts,omitempty"`
+	Backoff        string    `gorm:"not null;default:''" json:"backoff,omitempty"`
+	InitialDelayMs int64     `gorm:"not null;default:0" json:"initialDelayMs,omitempty"`
+	MaxDelayMs     int64     `gorm:"not null;default:0" json:"maxDelayMs,omitempty"`
+	DeadlineMs     int64     `gorm:"not null;default:0" json:"deadlineMs,omitempty"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	// Relationships
+	Account Account `gorm:"foreignKey:AccountID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (AccountRetryPolicy) TableName() string {
+	return "account_retry_policies"
+}
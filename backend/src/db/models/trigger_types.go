@@ -13,6 +13,9 @@ const (
 
 	// TriggerTypeResume indicates the workflow was resumed from a checkpoint
 	TriggerTypeResume = "resume"
+
+	// TriggerTypeNodeRetry indicates the workflow continued from a checkpoint after a failed node was retried
+	TriggerTypeNodeRetry = "node_retry"
 )
 
 // AllTriggerTypes contains all valid trigger types for validation
@@ -21,6 +24,7 @@ var AllTriggerTypes = []string{
 	TriggerTypeScheduled,
 	TriggerTypeWebhook,
 	TriggerTypeResume,
+	TriggerTypeNodeRetry,
 }
 
 // IsValidTriggerType returns true if the trigger type is valid
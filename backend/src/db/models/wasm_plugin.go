@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WasmPlugin is an uploaded WebAssembly module that wasm nodes run by name
+type WasmPlugin struct {
+	ID            string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AccountID     string    `gorm:"type:uuid;not null;uniqueIndex:idx_wasm_plugins_account_name" json:"accountId"`
+	Name          string    `gorm:"not null;uniqueIndex:idx_wasm_plugins_account_name" json:"name"`
+	Description   *string   `json:"description"`
+	Module        []byte    `gorm:"type:bytea;not null" json:"-"`
+	SHA256        string    `gorm:"column:sha256;not null" json:"sha256"`
+	SizeBytes     int       `gorm:"not null" json:"sizeBytes"`
+	MemoryLimitMB int       `gorm:"not null;default:0" json:"memoryLimitMb"` // 0 = server maximum
+	FuelLimit     int64     `gorm:"not null;default:0" json:"fuelLimit"`     // 0 = server maximum
+	TimeoutMs     int       `gorm:"not null;default:0" json:"timeoutMs"`     // 0 = server maximum
+	AllowHTTP     bool      `gorm:"column:allow_http;not null;default:false" json:"allowHttp"`
+	CreatedBy     string    `gorm:"type:uuid" json:"createdBy"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	// Relationships
+	Account Account `gorm:"foreignKey:AccountID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (WasmPlugin) TableName() string {
+	return "wasm_plugins"
+}
+
+func (p *WasmPlugin) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
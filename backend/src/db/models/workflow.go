@@ -14,6 +14,9 @@ type Workflow struct {
 	IsActive           bool              `gorm:"default:true" json:"isActive"`
 	Schedule           *string           `json:"schedule,omitempty"` // Cron expression
 	Timezone           string            `gorm:"default:UTC" json:"timezone"`
+	MisfirePolicy      string            `gorm:"default:skip" json:"misfirePolicy"`       // skip, run_once, run_all
+	MaxMissedRuns      int               `gorm:"default:10" json:"maxMissedRuns"`         // Cap on runs started by run_all
+	LastScheduledAt    *time.Time        `json:"lastScheduledAt,omitempty"`               // Scheduled time of the last started run
 	WebhookPath        *string           `json:"webhookPath,omitempty"`                   // Custom webhook path segment
 	WebhookRequireAuth bool              `gorm:"default:false" json:"webhookRequireAuth"` // Whether webhook requires auth
 	WebhookSecretHash  *string           `gorm:"index" json:"-"`                          // Hashed webhook secret (never expose in JSON)
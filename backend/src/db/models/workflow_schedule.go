@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkflowSchedule is one of a workflow's cron entries, in addition to the workflow's own Schedule.
+// Each entry has its own timezone, static input, active window and jitter, and can be paused on its own
+type WorkflowSchedule struct {
+	ID              string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	WorkflowID      string     `gorm:"type:uuid;not null;index" json:"workflowId"`
+	Name            string     `gorm:"not null;default:''" json:"name"`
+	CronExpression  string     `gorm:"not null" json:"cronExpression"`
+	Timezone        string     `gorm:"not null;default:UTC" json:"timezone"`
+	Input           string     `gorm:"type:text;not null;default:'{}'" json:"-"` // JSON object merged into each run's input
+	Enabled         bool       `gorm:"not null" json:"enabled"`                  // No default: gorm would replace false with it on create
+	StartAt         *time.Time `json:"startAt,omitempty"`                        // No runs before this time
+	EndAt           *time.Time `json:"endAt,omitempty"`                          // No runs after this time
+	JitterSeconds   int        `gorm:"not null;default:0" json:"jitterSeconds"`  // Runs start up to this many seconds late
+	MisfirePolicy   string     `gorm:"not null;default:skip" json:"misfirePolicy"`
+	MaxMissedRuns   int        `gorm:"not null;default:10" json:"maxMissedRuns"`
+	LastScheduledAt *time.Time `json:"lastScheduledAt,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	// Relationships
+	Workflow Workflow `gorm:"foreignKey:WorkflowID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (WorkflowSchedule) TableName() string {
+	return "workflow_schedules"
+}
+
+func (ws *WorkflowSchedule) BeforeCreate(tx *gorm.DB) error {
+	if ws.ID == "" {
+		ws.ID = uuid.New().String()
+	}
+	return nil
+}
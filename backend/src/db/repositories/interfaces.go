@@ -83,10 +83,8 @@ type NodeExecutionRepository interface {
 
 // OutboxRepository defines operations for outbox messages
 type OutboxRepository interface {
-	CountOrphanedMessages(ctx context.Context) (int64, error)
 	Create(ctx context.Context, message *models.OutboxMessage) error
 	Update(ctx context.Context, id string, updates map[string]interface{}) error
-	UpdateOrphanedMessages(ctx context.Context, updates map[string]interface{}) error
 }
 
 // EmailProviderRepository defines operations for email provider settings
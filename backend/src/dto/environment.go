@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// CreateEnvironmentRequest creates an account environment with variable overrides
+type CreateEnvironmentRequest struct {
+	Name      string                 `json:"name" binding:"required"`
+	IsDefault bool                   `json:"isDefault"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// UpdateEnvironmentRequest updates an environment; omitted fields are unchanged and variables are replaced as a whole
+type UpdateEnvironmentRequest struct {
+	Name      *string                `json:"name"`
+	IsDefault *bool                  `json:"isDefault"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// EnvironmentResponse represents an account environment
+type EnvironmentResponse struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	IsDefault bool                   `json:"isDefault"`
+	Variables map[string]interface{} `json:"variables"`
+	CreatedAt time.Time              `json:"createdAt"`
+	UpdatedAt time.Time              `json:"updatedAt"`
+}
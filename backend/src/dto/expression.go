@@ -0,0 +1,36 @@
+package dto
+
+// EvaluateExpressionRequest is the request to evaluate an expression or template for authoring
+// The context comes from Context, or from a past execution (ExecutionID + NodeID); fields set
+// in Context override the ones loaded from the execution
+type EvaluateExpressionRequest struct {
+	Source      string             `json:"source" binding:"required"`
+	Dialect     string             `json:"dialect"` // expression (default), simple, go, auto
+	Escape      string             `json:"escape"`  // none (default), url, header, html, json
+	Context     *ExpressionContext `json:"context"`
+	ExecutionID string             `json:"executionId"`
+	NodeID      string             `json:"nodeId"`
+}
+
+// ExpressionContext is a sample context for evaluating an expression
+type ExpressionContext struct {
+	Input         interface{}            `json:"input"`
+	NodeOutputs   map[string]interface{} `json:"nodeOutputs"`
+	Vars          map[string]interface{} `json:"vars"`
+	WorkflowInput interface{}            `json:"workflowInput"`
+}
+
+// ExpressionPosition is a 1-based position in the source
+type ExpressionPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// EvaluateExpressionResponse is the result of evaluating an expression or template
+type EvaluateExpressionResponse struct {
+	Valid    bool                `json:"valid"`
+	Result   interface{}         `json:"result"`
+	Type     string              `json:"type,omitempty"` // string, number, boolean, object, array, null
+	Error    string              `json:"error,omitempty"`
+	Position *ExpressionPosition `json:"position,omitempty"` // Set for parse errors
+}
@@ -0,0 +1,24 @@
+package dto
+
+// NodeTypeConfigField describes one field of a node type's data.config
+type NodeTypeConfigField struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// NodeTypeResponse represents a node type available in workflow definitions
+type NodeTypeResponse struct {
+	Type        string                `json:"type"`
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	Category    string                `json:"category,omitempty"`
+	Async       bool                  `json:"async"`
+	AsyncOptIn  bool                  `json:"asyncOptIn"` // Runs through the outbox when config sets "async": true
+	EventType   string                `json:"eventType,omitempty"`
+	Builtin     bool                  `json:"builtin"`
+	Executable  bool                  `json:"executable"` // false for structural nodes (start, end)
+	Config      []NodeTypeConfigField `json:"config"`
+}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeadLetterFilter selects dead letter messages; empty fields match every message
+type DeadLetterFilter struct {
+	WorkflowID string     `form:"workflowId" json:"workflowId"`
+	EventType  string     `form:"eventType" json:"eventType"`
+	Error      string     `form:"error" json:"error"` // Case-insensitive substring of the last error
+	From       *time.Time `form:"from" json:"from"`   // Failed at or after (RFC 3339)
+	To         *time.Time `form:"to" json:"to"`       // Failed before (RFC 3339)
+	MessageIDs []string   `form:"-" json:"messageIds"`
+}
+
+// IsEmpty reports whether the filter matches every message
+func (f DeadLetterFilter) IsEmpty() bool {
+	return f.WorkflowID == "" && f.EventType == "" && f.Error == "" &&
+		f.From == nil && f.To == nil && len(f.MessageIDs) == 0
+}
+
+// DeadLetterListQuery represents the query of a dead letter listing
+type DeadLetterListQuery struct {
+	DeadLetterFilter
+	Limit  int `form:"limit"`
+	Offset int `form:"offset"`
+}
+
+// DeadLetterBulkRequest selects the dead letter messages of a bulk retry or discard
+type DeadLetterBulkRequest struct {
+	DeadLetterFilter
+	Reason string `json:"reason"` // Recorded in the audit log
+}
+
+// DeadLetterBulkResponse reports the outcome of a bulk retry or discard
+type DeadLetterBulkResponse struct {
+	Matched   int               `json:"matched"`
+	Succeeded int               `json:"succeeded"`
+	Failed    map[string]string `json:"failed,omitempty"` // Message ID -> error
+	HasMore   bool              `json:"hasMore"`          // More messages match than one request processes
+}
+
+// UpdateDeadLetterPayloadRequest edits a dead letter message's payload before it is retried.
+// NodeConfig keys are merged into the stored config (null removes a key); Input replaces the input
+type UpdateDeadLetterPayloadRequest struct {
+	NodeConfig map[string]interface{} `json:"nodeConfig"`
+	Input      json.RawMessage        `json:"input"`
+	Reason     string                 `json:"reason"` // Recorded in the audit log
+	Retry      bool                   `json:"retry"`  // Requeue the message once the payload is saved
+}
+
+// RetryNodeRequest is the optional body of a node retry
+type RetryNodeRequest struct {
+	Resume bool `json:"resume"` // Continue the execution downstream from the node once the retry succeeds
+}
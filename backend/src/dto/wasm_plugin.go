@@ -0,0 +1,22 @@
+package dto
+
+// CreateWasmPluginRequest uploads a WASM plugin; module is the base64-encoded .wasm binary
+type CreateWasmPluginRequest struct {
+	Name          string  `json:"name" binding:"required"`
+	Description   *string `json:"description"`
+	Module        []byte  `json:"module" binding:"required"`
+	MemoryLimitMB int     `json:"memoryLimitMb"`
+	FuelLimit     int64   `json:"fuelLimit"`
+	TimeoutMs     int     `json:"timeoutMs"`
+	AllowHTTP     bool    `json:"allowHttp"`
+}
+
+// UpdateWasmPluginRequest replaces a plugin's module or settings; omitted fields are unchanged
+type UpdateWasmPluginRequest struct {
+	Description   *string `json:"description"`
+	Module        []byte  `json:"module"`
+	MemoryLimitMB *int    `json:"memoryLimitMb"`
+	FuelLimit     *int64  `json:"fuelLimit"`
+	TimeoutMs     *int    `json:"timeoutMs"`
+	AllowHTTP     *bool   `json:"allowHttp"`
+}
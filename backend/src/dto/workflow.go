@@ -9,6 +9,8 @@ type CreateWorkflowRequest struct {
 	Definition         map[string]interface{} `json:"definition" binding:"required"`
 	Schedule           *string                `json:"schedule"`
 	Timezone           *string                `json:"timezone"`
+	MisfirePolicy      *string                `json:"misfirePolicy"` // skip, run_once, run_all
+	MaxMissedRuns      *int                   `json:"maxMissedRuns"`
 	WebhookPath        *string                `json:"webhookPath"`
 	WebhookRequireAuth *bool                  `json:"webhookRequireAuth"`
 	// Note: IsActive removed - workflows are always active
@@ -22,6 +24,8 @@ type UpdateWorkflowRequest struct {
 	ChangeLog          *string                `json:"change_log"`
 	Schedule           *string                `json:"schedule"`
 	Timezone           *string                `json:"timezone"`
+	MisfirePolicy      *string                `json:"misfirePolicy"` // skip, run_once, run_all
+	MaxMissedRuns      *int                   `json:"maxMissedRuns"`
 	WebhookPath        *string                `json:"webhookPath"`
 	WebhookRequireAuth *bool                  `json:"webhookRequireAuth"`
 	// Note: IsActive removed - workflows are always active
@@ -29,14 +33,37 @@ type UpdateWorkflowRequest struct {
 
 // UpdateScheduleRequest represents the request to update workflow schedule
 type UpdateScheduleRequest struct {
-	Schedule *string `json:"schedule"` // Can be null to clear, omitted to keep existing
-	Timezone *string `json:"timezone"`
-	IsActive *bool   `json:"isActive"` // Use camelCase to match frontend
+	Schedule      *string `json:"schedule"` // Can be null to clear, omitted to keep existing
+	Timezone      *string `json:"timezone"`
+	IsActive      *bool   `json:"isActive"` // Use camelCase to match frontend
+	MisfirePolicy *string `json:"misfirePolicy"`
+	MaxMissedRuns *int    `json:"maxMissedRuns"`
+}
+
+// ValidateWorkflowRequest represents the request to validate a workflow definition without saving it
+type ValidateWorkflowRequest struct {
+	Definition map[string]interface{} `json:"definition" binding:"required"`
+	Schedule   *string                `json:"schedule"`
+}
+
+// WorkflowValidationIssue is a single problem found in a workflow definition
+type WorkflowValidationIssue struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	NodeID  string `json:"nodeId,omitempty"`
+	EdgeID  string `json:"edgeId,omitempty"`
+}
+
+// ValidateWorkflowResponse lists every problem found in a workflow definition
+type ValidateWorkflowResponse struct {
+	Valid  bool                      `json:"valid"`
+	Issues []WorkflowValidationIssue `json:"issues"`
 }
 
 // ExecuteWorkflowRequest represents the request to execute a workflow
 type ExecuteWorkflowRequest struct {
-	Input map[string]interface{} `json:"input"`
+	Input       map[string]interface{} `json:"input"`
+	Environment string                 `json:"environment"` // Account environment name (default environment if empty)
 }
 
 // WorkflowCreator represents the workflow creator information
@@ -59,6 +86,9 @@ type WorkflowResponse struct {
 	IsActive           bool             `json:"isActive"`
 	Schedule           *string          `json:"schedule,omitempty"`
 	Timezone           string           `json:"timezone"`
+	MisfirePolicy      string           `json:"misfirePolicy"`
+	MaxMissedRuns      int              `json:"maxMissedRuns"`
+	LastScheduledAt    *time.Time       `json:"lastScheduledAt,omitempty"`
 	WebhookPath        *string          `json:"webhookPath,omitempty"`
 	WebhookRequireAuth bool             `json:"webhookRequireAuth"`
 	CurrentVersion     int              `json:"currentVersion"`
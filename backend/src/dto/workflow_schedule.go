@@ -0,0 +1,62 @@
+package dto
+
+import "time"
+
+// CreateWorkflowScheduleRequest adds a cron entry to a workflow
+type CreateWorkflowScheduleRequest struct {
+	Name           string                 `json:"name"`
+	CronExpression string                 `json:"cronExpression" binding:"required"`
+	Timezone       string                 `json:"timezone"` // Defaults to UTC
+	Input          map[string]interface{} `json:"input"`    // Merged into each run's input
+	Enabled        *bool                  `json:"enabled"`  // Defaults to true
+	StartAt        *time.Time             `json:"startAt"`
+	EndAt          *time.Time             `json:"endAt"`
+	JitterSeconds  int                    `json:"jitterSeconds"`
+	MisfirePolicy  *string                `json:"misfirePolicy"`
+	MaxMissedRuns  *int                   `json:"maxMissedRuns"`
+}
+
+// UpdateWorkflowScheduleRequest updates a schedule; omitted fields are unchanged.
+// ClearStartAt and ClearEndAt remove a bound of the active window
+type UpdateWorkflowScheduleRequest struct {
+	Name           *string                `json:"name"`
+	CronExpression *string                `json:"cronExpression"`
+	Timezone       *string                `json:"timezone"`
+	Input          map[string]interface{} `json:"input"` // Replaced as a whole
+	Enabled        *bool                  `json:"enabled"`
+	StartAt        *time.Time             `json:"startAt"`
+	EndAt          *time.Time             `json:"endAt"`
+	ClearStartAt   bool                   `json:"clearStartAt"`
+	ClearEndAt     bool                   `json:"clearEndAt"`
+	JitterSeconds  *int                   `json:"jitterSeconds"`
+	MisfirePolicy  *string                `json:"misfirePolicy"`
+	MaxMissedRuns  *int                   `json:"maxMissedRuns"`
+}
+
+// WorkflowScheduleResponse represents one of a workflow's schedules
+type WorkflowScheduleResponse struct {
+	ID              string                 `json:"id"`
+	WorkflowID      string                 `json:"workflowId"`
+	Name            string                 `json:"name"`
+	CronExpression  string                 `json:"cronExpression"`
+	Timezone        string                 `json:"timezone"`
+	Input           map[string]interface{} `json:"input"`
+	Enabled         bool                   `json:"enabled"`
+	StartAt         *time.Time             `json:"startAt,omitempty"`
+	EndAt           *time.Time             `json:"endAt,omitempty"`
+	JitterSeconds   int                    `json:"jitterSeconds"`
+	MisfirePolicy   string                 `json:"misfirePolicy"`
+	MaxMissedRuns   int                    `json:"maxMissedRuns"`
+	LastScheduledAt *time.Time             `json:"lastScheduledAt,omitempty"`
+	NextRunAt       *time.Time             `json:"nextRunAt,omitempty"` // Nil when disabled, past its end or the workflow is inactive
+	CreatedAt       time.Time              `json:"createdAt"`
+	UpdatedAt       time.Time              `json:"updatedAt"`
+}
+
+// ScheduledRunPreview is an upcoming fire time of a workflow
+type ScheduledRunPreview struct {
+	ScheduleID *string   `json:"scheduleId,omitempty"` // Nil for the workflow's own schedule
+	Name       string    `json:"name,omitempty"`
+	Time       time.Time `json:"time"`
+	Timezone   string    `json:"timezone"`
+}
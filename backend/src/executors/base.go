@@ -2,6 +2,8 @@ package executors
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"time"
 )
 
@@ -13,6 +15,9 @@ type ExecutionContext struct {
 	WorkflowData map[string]interface{} `json:"workflow_data"`
 	ExecutionID  string                 `json:"execution_id"`
 	AccountID    string                 `json:"account_id"`
+
+	// IdempotencyKey is the outbox message's key; set by the outbox worker, empty for synchronous runs
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 // ExecutionResult holds the result of node execution
@@ -22,6 +27,7 @@ type ExecutionResult struct {
 	Error      string                 `json:"error,omitempty"`
 	NeedsSleep bool                   `json:"needs_sleep,omitempty"` // If true, workflow should enter sleeping state
 	WakeUpAt   *time.Time             `json:"wake_up_at,omitempty"`  // When to resume execution (UTC)
+	Permanent  bool                   `json:"permanent,omitempty"`   // Failure that retrying cannot fix (bad config, rejected request)
 }
 
 // Executor interface that all node executors must implement
@@ -29,9 +35,46 @@ type Executor interface {
 	Execute(ctx context.Context, execCtx ExecutionContext) (*ExecutionResult, error)
 }
 
-// NodeRequiresOutbox returns true if the node type requires outbox pattern
+// IsPermanentStatus reports whether an HTTP status means the request was rejected and retrying it won't help:
+// 4xx except timeouts and rate limiting
+func IsPermanentStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return false
+	}
+	return statusCode >= 400 && statusCode < 500
+}
+
+// PermanentError marks an executor error that retrying cannot fix, such as invalid config
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent wraps err as a PermanentError
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanentError reports whether err or an error it wraps is a PermanentError
+func IsPermanentError(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
+
+// NodeRequiresOutbox returns true if the node runs through the outbox pattern
 // Outbox pattern should only be used for side effects that need retry logic
-// HTTP nodes should be synchronous so their output can be used by downstream nodes
-func NodeRequiresOutbox(nodeType string) bool {
-	return IsAsyncNode(nodeType)
+// HTTP nodes are synchronous so their output can be used by downstream nodes, unless config sets "async": true
+func NodeRequiresOutbox(nodeType string, config map[string]interface{}) bool {
+	if IsAsyncNode(nodeType) {
+		return true
+	}
+	async, _ := config["async"].(bool)
+	return async && SupportsAsync(nodeType)
 }
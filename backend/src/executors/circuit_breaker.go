@@ -0,0 +1,47 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/patali/yantra/src/breaker"
+)
+
+// breakerTransport guards outbound requests with per-account, per-host circuit breakers.
+// Transport errors and 5xx/429 responses count as failures; any other response reaches the host
+type breakerTransport struct {
+	base     http.RoundTripper
+	breakers *breaker.Registry
+}
+
+// NewBreakerTransport wraps base with the breakers of registry
+func NewBreakerTransport(base http.RoundTripper, registry *breaker.Registry) http.RoundTripper {
+	return &breakerTransport{base: base, breakers: registry}
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := breaker.Key{
+		AccountID:   egressAccountFromContext(req.Context()),
+		Destination: breaker.HostDestination(strings.ToLower(req.URL.Host)),
+	}
+	if err := t.breakers.Allow(key); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err != nil:
+		// Cancelled callers and egress denials say nothing about the host's health
+		if _, denied := AsEgressDenied(err); !denied && !errors.Is(err, context.Canceled) {
+			t.breakers.Failure(key, err)
+		}
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		t.breakers.Failure(key, fmt.Errorf("status %d", resp.StatusCode))
+	default:
+		t.breakers.Success(key)
+	}
+	return resp, err
+}
@@ -0,0 +1,48 @@
+package executors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/patali/yantra/src/breaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakerTransport(t *testing.T) {
+	var hits, status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	registry := breaker.NewRegistry(breaker.Config{FailureThreshold: 2, OpenDuration: time.Hour})
+	client := &http.Client{Transport: NewBreakerTransport(http.DefaultTransport, registry)}
+	get := func(accountID string) error {
+		req, _ := http.NewRequestWithContext(WithEgressAccount(context.Background(), accountID), "GET", server.URL, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	assert.NoError(t, get("acc-1"))
+	assert.NoError(t, get("acc-1"))
+
+	err := get("acc-1")
+	_, open := breaker.AsOpen(err)
+	assert.True(t, open, "two 503s open the breaker")
+	assert.Equal(t, int32(2), hits.Load(), "rejected requests are not sent")
+
+	status.Store(http.StatusNotFound)
+	assert.NoError(t, get("acc-2"), "other accounts are unaffected")
+	assert.NoError(t, get("acc-2"))
+	assert.NoError(t, get("acc-2"), "4xx responses are not destination failures")
+	assert.Equal(t, int32(5), hits.Load())
+}
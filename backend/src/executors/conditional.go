@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/PaesslerAG/gval"
+	"github.com/patali/yantra/src/templating"
 )
 
 type ConditionalExecutor struct{}
@@ -134,36 +134,8 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execCtx ExecutionCont
 		}, nil
 	}
 
-	// Prepare the evaluation context with input data and workflow data
-	evalContext := make(map[string]interface{})
-
-	// Add input data
-	if execCtx.Input != nil {
-		evalContext["input"] = execCtx.Input
-		// Also flatten input.data to inputData for easier access to nested data
-		if inputMap, ok := execCtx.Input.(map[string]interface{}); ok {
-			if data, ok := inputMap["data"].(map[string]interface{}); ok {
-				// Add the data object itself for access via data.field
-				evalContext["data"] = data
-				// Also add nested data at root level for easier access (field without data. prefix)
-				for k, v := range data {
-					evalContext[k] = v
-				}
-			}
-		}
-	}
-
-	// Add workflow data (contains previous node outputs)
-	if execCtx.WorkflowData != nil {
-		evalContext["workflow"] = execCtx.WorkflowData
-		// Also add at root level for easier access
-		for k, v := range execCtx.WorkflowData {
-			evalContext[k] = v
-		}
-	}
-
-	// Evaluate the condition using gval
-	result, err := gval.Evaluate(condition, evalContext)
+	// Evaluate the condition with the shared expression language
+	result, err := templating.Evaluate(condition, ConditionContext(execCtx))
 	if err != nil {
 		return &ExecutionResult{
 			Success: false,
@@ -192,3 +164,38 @@ func (e *ConditionalExecutor) Execute(ctx context.Context, execCtx ExecutionCont
 		Output:  output,
 	}, nil
 }
+
+// ConditionContext is the expression context of a conditional node: the template context
+// plus the legacy root names (data, workflow and the data/workflow fields), which take precedence
+func ConditionContext(execCtx ExecutionContext) templating.Context {
+	evalContext := make(map[string]interface{})
+
+	// Add input data
+	if execCtx.Input != nil {
+		evalContext["input"] = execCtx.Input
+		// Also flatten input.data to inputData for easier access to nested data
+		if inputMap, ok := execCtx.Input.(map[string]interface{}); ok {
+			if data, ok := inputMap["data"].(map[string]interface{}); ok {
+				// Add the data object itself for access via data.field
+				evalContext["data"] = data
+				// Also add nested data at root level for easier access (field without data. prefix)
+				for k, v := range data {
+					evalContext[k] = v
+				}
+			}
+		}
+	}
+
+	// Add workflow data (contains previous node outputs)
+	if execCtx.WorkflowData != nil {
+		evalContext["workflow"] = execCtx.WorkflowData
+		// Also add at root level for easier access
+		for k, v := range execCtx.WorkflowData {
+			evalContext[k] = v
+		}
+	}
+
+	ctx := TemplateContext(execCtx)
+	ctx.Locals = evalContext
+	return ctx
+}
@@ -0,0 +1,309 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// EgressDeniedError is returned when the egress policy blocks an outbound request
+type EgressDeniedError struct {
+	Host   string
+	Reason string
+}
+
+func (e *EgressDeniedError) Error() string {
+	return fmt.Sprintf("egress policy denied request to %s: %s", e.Host, e.Reason)
+}
+
+// AsEgressDenied unwraps an egress policy error from a transport error chain
+func AsEgressDenied(err error) (*EgressDeniedError, bool) {
+	var egressErr *EgressDeniedError
+	if errors.As(err, &egressErr) {
+		return egressErr, true
+	}
+	return nil, false
+}
+
+// EgressRules are per-account host lists
+// A non-empty AllowedHosts restricts the account to those hosts; DeniedHosts are always blocked
+type EgressRules struct {
+	AllowedHosts []string `json:"allowedHosts"`
+	DeniedHosts  []string `json:"deniedHosts"`
+}
+
+// EgressRulesLoader loads the egress rules for an account
+type EgressRulesLoader func(ctx context.Context, accountID string) (*EgressRules, error)
+
+// blockedNetworks are denied unless private networks are explicitly allowed
+// Covers loopback, RFC1918, link-local (incl. cloud metadata), CGNAT, and IPv6 equivalents
+var blockedNetworks = []struct {
+	cidr  string
+	label string
+}{
+	{"0.0.0.0/8", "unspecified"},
+	{"10.0.0.0/8", "private"},
+	{"100.64.0.0/10", "carrier-grade NAT"},
+	{"127.0.0.0/8", "loopback"},
+	{"169.254.0.0/16", "link-local"},
+	{"172.16.0.0/12", "private"},
+	{"192.0.0.0/24", "IETF protocol assignments"},
+	{"192.168.0.0/16", "private"},
+	{"198.18.0.0/15", "benchmarking"},
+	{"224.0.0.0/4", "multicast"},
+	{"240.0.0.0/4", "reserved"},
+	{"::/128", "unspecified"},
+	{"::1/128", "loopback"},
+	{"fc00::/7", "unique local"},
+	{"fe80::/10", "link-local"},
+	{"ff00::/8", "multicast"},
+}
+
+const egressRulesCacheTTL = 30 * time.Second
+
+type cachedEgressRules struct {
+	rules    *EgressRules
+	loadedAt time.Time
+}
+
+// EgressPolicy decides which destinations outbound executor requests may reach
+// IP checks run on the resolved address at dial time, so DNS rebinding cannot bypass them
+type EgressPolicy struct {
+	allowPrivateNetworks bool
+	allowedHosts         []string // Operator allowlist: exempt from IP range checks
+	deniedHosts          []string
+	blocked              []*net.IPNet
+	blockedLabels        []string
+
+	rulesLoader EgressRulesLoader
+	rulesMu     sync.Mutex
+	rulesCache  map[string]cachedEgressRules
+}
+
+// NewEgressPolicy creates an egress policy
+// allowedHosts bypass the private range check (for trusted internal services); deniedHosts are always blocked
+func NewEgressPolicy(allowPrivateNetworks bool, allowedHosts, deniedHosts []string) *EgressPolicy {
+	p := &EgressPolicy{
+		allowPrivateNetworks: allowPrivateNetworks,
+		allowedHosts:         normalizeHostPatterns(allowedHosts),
+		deniedHosts:          normalizeHostPatterns(deniedHosts),
+		rulesCache:           make(map[string]cachedEgressRules),
+	}
+	for _, b := range blockedNetworks {
+		_, network, err := net.ParseCIDR(b.cidr)
+		if err != nil {
+			panic(fmt.Sprintf("invalid blocked network %s: %v", b.cidr, err))
+		}
+		p.blocked = append(p.blocked, network)
+		p.blockedLabels = append(p.blockedLabels, b.label)
+	}
+	return p
+}
+
+// SetAccountRulesLoader enables per-account allow/deny host lists
+func (p *EgressPolicy) SetAccountRulesLoader(loader EgressRulesLoader) {
+	p.rulesLoader = loader
+}
+
+// InvalidateAccount drops cached rules after an account's policy changes
+func (p *EgressPolicy) InvalidateAccount(accountID string) {
+	p.rulesMu.Lock()
+	defer p.rulesMu.Unlock()
+	delete(p.rulesCache, accountID)
+}
+
+// CheckHost validates a hostname against operator and account host lists
+func (p *EgressPolicy) CheckHost(ctx context.Context, host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if matchesAnyHost(host, p.deniedHosts) {
+		return &EgressDeniedError{Host: host, Reason: "host is on the denied list"}
+	}
+
+	accountID := egressAccountFromContext(ctx)
+	if accountID == "" || p.rulesLoader == nil {
+		return nil
+	}
+
+	rules, err := p.accountRules(ctx, accountID)
+	if err != nil {
+		// SECURITY: Fail closed if account rules cannot be loaded
+		return &EgressDeniedError{Host: host, Reason: fmt.Sprintf("failed to load account egress rules: %v", err)}
+	}
+	if rules == nil {
+		return nil
+	}
+
+	if matchesAnyHost(host, normalizeHostPatterns(rules.DeniedHosts)) {
+		return &EgressDeniedError{Host: host, Reason: "host is on the account's denied list"}
+	}
+	allowed := normalizeHostPatterns(rules.AllowedHosts)
+	if len(allowed) > 0 && !matchesAnyHost(host, allowed) {
+		return &EgressDeniedError{Host: host, Reason: "host is not on the account's allowed list"}
+	}
+
+	return nil
+}
+
+// CheckIP validates a resolved address against the blocked ranges
+func (p *EgressPolicy) CheckIP(host string, ip net.IP) error {
+	if p.allowPrivateNetworks || matchesAnyHost(strings.ToLower(host), p.allowedHosts) {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4 // Normalize IPv4-mapped IPv6 (::ffff:10.0.0.1)
+	}
+	for i, network := range p.blocked {
+		if network.Contains(ip) {
+			return &EgressDeniedError{
+				Host:   host,
+				Reason: fmt.Sprintf("address %s is in a blocked range (%s)", ip, p.blockedLabels[i]),
+			}
+		}
+	}
+	return nil
+}
+
+// DialContext wraps a dialer so every connection is checked against the blocked IP ranges, on the address
+// actually dialed. Host lists are checked per request by Transport, since pooled connections are shared
+// across accounts
+func (p *EgressPolicy) DialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		d := *dialer
+		d.ControlContext = func(_ context.Context, _, address string, _ syscall.RawConn) error {
+			ipStr, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(ipStr)
+			if ip == nil {
+				return &EgressDeniedError{Host: host, Reason: fmt.Sprintf("could not parse resolved address %s", ipStr)}
+			}
+			return p.CheckIP(host, ip)
+		}
+
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// Transport wraps a round tripper so every request, including redirect hops and requests reusing an idle
+// connection, is checked against the operator and account host lists
+func (p *EgressPolicy) Transport(base http.RoundTripper) http.RoundTripper {
+	return &egressTransport{base: base, policy: p}
+}
+
+type egressTransport struct {
+	base   http.RoundTripper
+	policy *EgressPolicy
+}
+
+func (t *egressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.CheckHost(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// CheckRedirect re-validates each redirect hop and caps the chain length
+func (p *EgressPolicy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return &EgressDeniedError{Host: req.URL.Host, Reason: fmt.Sprintf("redirect to unsupported scheme %q", req.URL.Scheme)}
+	}
+	// IP ranges are re-checked when the redirect target is dialed
+	return p.CheckHost(req.Context(), req.URL.Hostname())
+}
+
+func (p *EgressPolicy) accountRules(ctx context.Context, accountID string) (*EgressRules, error) {
+	p.rulesMu.Lock()
+	cached, ok := p.rulesCache[accountID]
+	p.rulesMu.Unlock()
+	if ok && time.Since(cached.loadedAt) < egressRulesCacheTTL {
+		return cached.rules, nil
+	}
+
+	rules, err := p.rulesLoader(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.rulesMu.Lock()
+	p.rulesCache[accountID] = cachedEgressRules{rules: rules, loadedAt: time.Now()}
+	p.rulesMu.Unlock()
+	return rules, nil
+}
+
+type egressAccountKey struct{}
+
+// WithEgressAccount tags a request context with the account whose egress rules apply
+func WithEgressAccount(ctx context.Context, accountID string) context.Context {
+	if accountID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, egressAccountKey{}, accountID)
+}
+
+func egressAccountFromContext(ctx context.Context) string {
+	accountID, _ := ctx.Value(egressAccountKey{}).(string)
+	return accountID
+}
+
+// normalizeHostPatterns lowercases and trims host patterns, dropping empties
+func normalizeHostPatterns(patterns []string) []string {
+	result := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern != "" {
+			result = append(result, pattern)
+		}
+	}
+	return result
+}
+
+// matchesAnyHost supports exact hosts and "*.example.com" wildcards (which also match subdomains at any depth)
+func matchesAnyHost(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "*.") {
+			if strings.HasSuffix(host, pattern[1:]) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	defaultEgressPolicy   = NewEgressPolicy(false, nil, nil)
+	defaultEgressPolicyMu sync.RWMutex
+)
+
+// SetDefaultEgressPolicy installs the policy used by executor factories created afterwards
+func SetDefaultEgressPolicy(p *EgressPolicy) {
+	defaultEgressPolicyMu.Lock()
+	defer defaultEgressPolicyMu.Unlock()
+	defaultEgressPolicy = p
+}
+
+// DefaultEgressPolicy returns the process-wide egress policy
+func DefaultEgressPolicy() *EgressPolicy {
+	defaultEgressPolicyMu.RLock()
+	defer defaultEgressPolicyMu.RUnlock()
+	return defaultEgressPolicy
+}
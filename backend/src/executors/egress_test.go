@@ -0,0 +1,144 @@
+package executors
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newEgressTestClient(policy *EgressPolicy) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	return &http.Client{
+		Timeout:       5 * time.Second,
+		Transport:     policy.Transport(&http.Transport{DialContext: policy.DialContext(dialer)}),
+		CheckRedirect: policy.CheckRedirect,
+	}
+}
+
+func TestEgressPolicy_BlocksPrivateRanges(t *testing.T) {
+	policy := NewEgressPolicy(false, nil, nil)
+
+	blocked := []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "::1", "fe80::1", "::ffff:10.0.0.1"}
+	for _, ip := range blocked {
+		err := policy.CheckIP("example.com", net.ParseIP(ip))
+		assert.Error(t, err, ip)
+	}
+
+	assert.NoError(t, policy.CheckIP("example.com", net.ParseIP("93.184.216.34")))
+	assert.NoError(t, policy.CheckIP("example.com", net.ParseIP("2606:2800:220:1:248:1893:25c8:1946")))
+}
+
+func TestEgressPolicy_HTTPExecutorBlocksLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	executor := NewHTTPExecutor(newEgressTestClient(NewEgressPolicy(false, nil, nil)))
+	result, err := executor.Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{"url": server.URL},
+	})
+
+	assert.Nil(t, result)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "egress policy denied request")
+	assert.Contains(t, err.Error(), "loopback")
+
+	// Operator allowlist exempts trusted hosts from the range check
+	executor = NewHTTPExecutor(newEgressTestClient(NewEgressPolicy(false, []string{"127.0.0.1"}, nil)))
+	result, err = executor.Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{"url": server.URL},
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestEgressPolicy_RedirectRevalidation(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer target.Close()
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://internal.example"+r.URL.Path, http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	// The first hop is allowed, the redirect target is on the denied list
+	policy := NewEgressPolicy(true, nil, []string{"*.example", "internal.example"})
+	executor := NewHTTPExecutor(newEgressTestClient(policy))
+	_, err := executor.Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{"url": redirector.URL},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "internal.example")
+	assert.Contains(t, err.Error(), "denied list")
+}
+
+func TestEgressPolicy_AccountRules(t *testing.T) {
+	policy := NewEgressPolicy(true, nil, nil)
+	policy.SetAccountRulesLoader(func(ctx context.Context, accountID string) (*EgressRules, error) {
+		if accountID == "restricted" {
+			return &EgressRules{AllowedHosts: []string{"*.partner.com"}, DeniedHosts: []string{"blocked.partner.com"}}, nil
+		}
+		return nil, nil
+	})
+
+	restricted := WithEgressAccount(context.Background(), "restricted")
+	assert.NoError(t, policy.CheckHost(restricted, "api.partner.com"))
+	assert.Error(t, policy.CheckHost(restricted, "blocked.partner.com"))
+	assert.Error(t, policy.CheckHost(restricted, "evil.com"))
+
+	open := WithEgressAccount(context.Background(), "open")
+	assert.NoError(t, policy.CheckHost(open, "evil.com"))
+}
+
+func TestEgressPolicy_AccountRulesOnPooledConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	denied := map[string]bool{"restricted": true}
+	policy := NewEgressPolicy(true, nil, nil)
+	policy.SetAccountRulesLoader(func(ctx context.Context, accountID string) (*EgressRules, error) {
+		if denied[accountID] {
+			return &EgressRules{DeniedHosts: []string{"127.0.0.1"}}, nil
+		}
+		return nil, nil
+	})
+	executor := NewHTTPExecutor(newEgressTestClient(policy))
+	config := map[string]interface{}{"url": server.URL}
+
+	// Account "open" leaves an idle keep-alive connection to the host in the shared pool
+	result, err := executor.Execute(WithEgressAccount(context.Background(), "open"), ExecutionContext{NodeConfig: config})
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+
+	// Account "restricted" must not reuse it
+	_, err = executor.Execute(WithEgressAccount(context.Background(), "restricted"), ExecutionContext{NodeConfig: config})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "account's denied list")
+
+	// A policy change applies to the next request, not only to new connections
+	denied["open"] = true
+	policy.InvalidateAccount("open")
+	_, err = executor.Execute(WithEgressAccount(context.Background(), "open"), ExecutionContext{NodeConfig: config})
+	assert.Error(t, err)
+}
+
+func TestHTTPExecutor_RejectsNonHTTPScheme(t *testing.T) {
+	executor := NewHTTPExecutor(&http.Client{Timeout: time.Second})
+	_, err := executor.Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{"url": "file:///etc/passwd"},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported url scheme")
+}
@@ -2,24 +2,32 @@ package executors
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/patali/yantra/src/breaker"
 	"gorm.io/gorm"
 )
 
 // ExecutorFactory provides a stateless factory for creating executors
 type ExecutorFactory struct {
-	db           *gorm.DB
-	emailService EmailServiceInterface
-	httpClient   *http.Client
+	deps     Dependencies
+	registry *Registry
 }
 
 // NewExecutorFactory creates a new executor factory with required dependencies
 func NewExecutorFactory(db *gorm.DB, emailService EmailServiceInterface) *ExecutorFactory {
 	// Create a shared HTTP client with connection pooling
 	// This client is reused across all executor instances to prevent resource leaks
+	// SECURITY: Every request, dial and redirect goes through the egress policy (SSRF protection)
+	egressPolicy := DefaultEgressPolicy()
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
 	transport := &http.Transport{
+		DialContext:         egressPolicy.DialContext(dialer),
 		MaxIdleConns:        100,              // Maximum idle connections across all hosts
 		MaxIdleConnsPerHost: 10,               // Maximum idle connections per host
 		MaxConnsPerHost:     100,              // Maximum connections per host
@@ -28,48 +36,36 @@ func NewExecutorFactory(db *gorm.DB, emailService EmailServiceInterface) *Execut
 		DisableCompression:  false,            // Enable compression
 	}
 
+	// Requests to a host that keeps failing are rejected until its circuit breaker lets a probe through
 	httpClient := &http.Client{
-		Timeout:   30 * time.Second,
-		Transport: transport,
+		Timeout:       30 * time.Second,
+		Transport:     NewBreakerTransport(egressPolicy.Transport(transport), breaker.Default()),
+		CheckRedirect: egressPolicy.CheckRedirect,
 	}
 
 	return &ExecutorFactory{
-		db:           db,
-		emailService: emailService,
-		httpClient:   httpClient,
+		deps: Dependencies{
+			DB:           db,
+			EmailService: emailService,
+			HTTPClient:   httpClient,
+			Wasm:         DefaultWasmRuntime(),
+		},
+		registry: DefaultRegistry(),
 	}
 }
 
+// Registry returns the node type registry the factory builds executors from
+func (f *ExecutorFactory) Registry() *Registry {
+	return f.registry
+}
+
 // GetExecutor creates a new executor instance for a node type
 // Each call returns a new instance, making the factory stateless
 // Shared resources like HTTP clients are passed to executors to prevent leaks
 func (f *ExecutorFactory) GetExecutor(nodeType string) (Executor, error) {
-	switch nodeType {
-	case NodeTypeJSONArray:
-		return NewJsonArrayTriggerExecutor(), nil
-	case NodeTypeConditional:
-		return NewConditionalExecutor(), nil
-	case NodeTypeTransform:
-		return NewTransformExecutor(), nil
-	case NodeTypeDelay:
-		return NewDelayExecutor(), nil
-	case NodeTypeSleep:
-		return NewSleepExecutor(), nil
-	case NodeTypeEmail:
-		return NewEmailExecutor(f.db, f.emailService), nil
-	case NodeTypeHTTP:
-		return NewHTTPExecutor(f.httpClient), nil
-	case NodeTypeSlack:
-		return NewSlackExecutor(f.httpClient), nil
-	case NodeTypeLoop:
-		return NewLoopExecutor(), nil
-	case NodeTypeLoopAccumulator:
-		return NewLoopAccumulatorExecutor(), nil
-	case NodeTypeJSONToCSV:
-		return NewJSONToCSVExecutor(), nil
-	case NodeTypeJSON:
-		return NewJSONExecutor(), nil
-	default:
+	t, ok := f.registry.Lookup(nodeType)
+	if !ok || t.New == nil {
 		return nil, fmt.Errorf("no executor found for node type: %s", nodeType)
 	}
+	return t.New(f.deps), nil
 }
@@ -8,17 +8,24 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strings"
+
+	"github.com/patali/yantra/src/breaker"
+	"github.com/patali/yantra/src/templating"
 )
 
+// defaultIdempotencyHeader carries the outbox idempotency key on async calls unless idempotencyHeader is set
+const defaultIdempotencyHeader = "Idempotency-Key"
+
 type HTTPExecutor struct {
-	client *http.Client
+	client     *http.Client
+	tokenCache *OAuth2TokenCache
 }
 
 func NewHTTPExecutor(client *http.Client) *HTTPExecutor {
 	return &HTTPExecutor{
-		client: client,
+		client:     client,
+		tokenCache: sharedOAuth2TokenCache,
 	}
 }
 
@@ -26,11 +33,21 @@ func (e *HTTPExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*
 	// Get URL and method from config
 	urlStr, ok := execCtx.NodeConfig["url"].(string)
 	if !ok || urlStr == "" {
-		return nil, fmt.Errorf("url is required")
+		return nil, Permanent(fmt.Errorf("url is required"))
 	}
 
 	// Replace template variables in URL with URL-encoded values
-	urlStr = e.replaceTemplateVariablesWithEncoding(urlStr, execCtx.Input, true)
+	urlStr = renderTemplate(urlStr, execCtx, templating.EscapeURL)
+
+	// SECURITY: Only http(s) is allowed; destinations are checked by the client's egress policy
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("invalid url: %w", err))
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, Permanent(fmt.Errorf("unsupported url scheme %q (must be http or https)", parsedURL.Scheme))
+	}
+	ctx = WithEgressAccount(ctx, execCtx.AccountID)
 
 	method, ok := execCtx.NodeConfig["method"].(string)
 	if !ok || method == "" {
@@ -43,28 +60,32 @@ func (e *HTTPExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*
 	if h, ok := execCtx.NodeConfig["headers"].(map[string]interface{}); ok {
 		for k, v := range h {
 			if strVal, ok := v.(string); ok {
-				// Replace template variables in header values without encoding
-				headers[k] = e.replaceTemplateVariablesWithEncoding(strVal, execCtx.Input, false)
+				// Replace template variables in header values (CR/LF stripped)
+				headers[k] = renderTemplate(strVal, execCtx, templating.EscapeHeader)
 			}
 		}
 	}
 
 	// Get body (for POST, PUT, PATCH)
-	var bodyReader io.Reader
+	// Buffered so the request can be signed and re-sent after an OAuth2 token refresh
+	var bodyBytes []byte
 	if method == "POST" || method == "PUT" || method == "PATCH" {
 		if body, ok := execCtx.NodeConfig["body"]; ok {
 			// If body is already a string, use it directly (after replacing variables)
 			if bodyStr, ok := body.(string); ok {
-				// Replace template variables in body without URL encoding
-				replacedBody := e.replaceTemplateVariablesWithEncoding(bodyStr, execCtx.Input, false)
-				bodyReader = strings.NewReader(replacedBody)
+				// Replace template variables in body; JSON bodies get JSON-escaped values
+				escape := templating.EscapeNone
+				if isJSONBody(bodyStr, headers) {
+					escape = templating.EscapeJSON
+				}
+				bodyBytes = []byte(renderTemplate(bodyStr, execCtx, escape))
 			} else {
 				// Otherwise, marshal to JSON
-				bodyBytes, err := json.Marshal(body)
+				var err error
+				bodyBytes, err = json.Marshal(body)
 				if err != nil {
-					return nil, fmt.Errorf("failed to marshal body: %w", err)
+					return nil, Permanent(fmt.Errorf("failed to marshal body: %w", err))
 				}
-				bodyReader = bytes.NewReader(bodyBytes)
 				// Set Content-Type to application/json if not already set
 				if _, exists := headers["Content-Type"]; !exists {
 					headers["Content-Type"] = "application/json"
@@ -73,35 +94,30 @@ func (e *HTTPExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*
 		}
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, method, urlStr, bodyReader)
+	respOpts, err := parseHTTPResponseOptions(execCtx.NodeConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, Permanent(err)
 	}
 
-	// Set headers
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	// Async calls carry the outbox idempotency key so the API can drop duplicates of a retried delivery
+	if execCtx.IdempotencyKey != "" {
+		headerName, _ := execCtx.NodeConfig["idempotencyHeader"].(string)
+		if headerName == "" {
+			headerName = defaultIdempotencyHeader
+		}
+		if !hasHeader(headers, headerName) {
+			headers[headerName] = execCtx.IdempotencyKey
+		}
 	}
 
-	// Execute request using shared HTTP client
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+	// Follow pagination when configured
+	if pagination, ok := execCtx.NodeConfig["pagination"].(map[string]interface{}); ok && len(pagination) > 0 {
+		return e.executePaginated(ctx, method, urlStr, headers, bodyBytes, execCtx, pagination, respOpts)
 	}
-	defer resp.Body.Close()
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
+	resp, respBody, bodySize, err := e.send(ctx, method, urlStr, headers, bodyBytes, execCtx, respOpts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Try to parse as JSON, otherwise return as string
-	var data interface{}
-	if err := json.Unmarshal(respBody, &data); err != nil {
-		// Not JSON, return as string
-		data = string(respBody)
+		return nil, err
 	}
 
 	// Build output
@@ -109,17 +125,33 @@ func (e *HTTPExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*
 		"status_code": resp.StatusCode,
 		"url":         urlStr,
 		"method":      method,
-		"data":        data,
 		"headers":     resp.Header,
 	}
+	respOpts.extract(resp, output)
+
+	data, decodeErr := respOpts.decodeBody(respBody)
+	output["data"] = data
+	if respOpts.ResponseType == ResponseTypeBase64 || respOpts.ResponseType == ResponseTypeDiscard {
+		output["content_type"] = resp.Header.Get("Content-Type")
+		output["size"] = bodySize // Bytes read, since discard mode keeps no body
+	}
 
-	// Check if request was successful (2xx status code)
-	success := resp.StatusCode >= 200 && resp.StatusCode < 300
-	if !success {
+	// Check if the status code is accepted (2xx unless acceptedStatusCodes is set)
+	if !respOpts.accepts(resp.StatusCode) {
 		return &ExecutionResult{
-			Success: false,
-			Output:  output,
-			Error:   fmt.Sprintf("HTTP request failed with status %d", resp.StatusCode),
+			Success:   false,
+			Output:    output,
+			Error:     fmt.Sprintf("HTTP request failed with status %d", resp.StatusCode),
+			Permanent: IsPermanentStatus(resp.StatusCode),
+		}, nil
+	}
+
+	if decodeErr != nil {
+		return &ExecutionResult{
+			Success:   false,
+			Output:    output,
+			Error:     decodeErr.Error(),
+			Permanent: true,
 		}, nil
 	}
 
@@ -129,71 +161,88 @@ func (e *HTTPExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*
 	}, nil
 }
 
-// replaceTemplateVariablesWithEncoding replaces {{variable}} patterns with values from input
-// If urlEncode is true, values are URL-encoded to handle spaces and special characters
-func (e *HTTPExecutor) replaceTemplateVariablesWithEncoding(text string, input interface{}, urlEncode bool) string {
-	// Match patterns like {{input.field}} or {{variable}}
-	re := regexp.MustCompile(`\{\{([^}]+)\}\}`)
-
-	result := re.ReplaceAllStringFunc(text, func(match string) string {
-		// Extract the variable name (remove {{ and }})
-		varName := strings.TrimSpace(match[2 : len(match)-2])
-
-		// Get the value from input
-		value := e.getValueFromPath(input, varName)
-
-		// Convert to string
-		if value != nil {
-			strValue := fmt.Sprintf("%v", value)
-			if urlEncode {
-				// URL-encode the value to handle spaces and special characters
-				return url.QueryEscape(strValue)
-			}
-			return strValue
+// send performs a request and reads the response body, returning the number of bytes read
+// A 401 with OAuth2 auth refreshes the token once and retries, since cached tokens can be revoked before expiry
+func (e *HTTPExecutor) send(ctx context.Context, method, urlStr string, headers map[string]string, body []byte, execCtx ExecutionContext, respOpts *httpResponseOptions) (*http.Response, []byte, int64, error) {
+	resp, err := e.doRequest(ctx, method, urlStr, headers, body, execCtx, false)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && usesOAuth2(execCtx.NodeConfig) {
+		resp.Body.Close()
+		resp, err = e.doRequest(ctx, method, urlStr, headers, body, execCtx, true)
+		if err != nil {
+			return nil, nil, 0, err
 		}
+	}
+	defer resp.Body.Close()
 
-		// If not found, keep the original placeholder
-		return match
-	})
+	// Read response body (capped at maxResponseSize)
+	respBody, size, err := respOpts.readBody(resp.Body)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 
-	return result
+	return resp, respBody, size, nil
 }
 
-// replaceTemplateVariables replaces {{variable}} patterns with values from input (no encoding)
-// Kept for backward compatibility with tests
-func (e *HTTPExecutor) replaceTemplateVariables(text string, input interface{}) string {
-	return e.replaceTemplateVariablesWithEncoding(text, input, false)
-}
+// doRequest builds, authenticates, signs and sends a single request
+func (e *HTTPExecutor) doRequest(ctx context.Context, method, urlStr string, headers map[string]string, body []byte, execCtx ExecutionContext, forceTokenRefresh bool) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if err := e.applyAuth(ctx, req, execCtx, forceTokenRefresh); err != nil {
+		return nil, err
+	}
+	if err := e.applySigning(ctx, req, body, execCtx); err != nil {
+		return nil, err
+	}
 
-// getValueFromPath navigates through nested objects to get a value
-// Supports paths like "input.field", "field.nested", "field", etc.
-// Special handling: if path starts with "input." and input is a map, try without the "input." prefix
-func (e *HTTPExecutor) getValueFromPath(data interface{}, path string) interface{} {
-	parts := strings.Split(path, ".")
-	current := data
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-
-		switch v := current.(type) {
-		case map[string]interface{}:
-			current = v[part]
-		case map[interface{}]interface{}:
-			current = v[part]
-		default:
-			return nil
+	// Execute request using shared HTTP client
+	resp, err := e.client.Do(req)
+	if err != nil {
+		if egressErr, ok := AsEgressDenied(err); ok {
+			return nil, Permanent(egressErr)
 		}
+		if openErr, ok := breaker.AsOpen(err); ok {
+			return nil, openErr
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
 
-		if current == nil {
-			// Special case: if path started with "input." and we couldn't find it,
-			// try without the "input." prefix (for backward compatibility)
-			if len(parts) > 1 && parts[0] == "input" {
-				// Retry without "input." prefix
-				return e.getValueFromPath(data, strings.Join(parts[1:], "."))
-			}
-			return nil
+// hasHeader reports whether headers has a header, compared case-insensitively
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
 		}
 	}
+	return false
+}
 
-	return current
+// isJSONBody reports whether a string body is JSON, by Content-Type or by shape
+func isJSONBody(body string, headers map[string]string) bool {
+	for k, v := range headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return strings.Contains(strings.ToLower(v), "json")
+		}
+	}
+	trimmed := strings.TrimSpace(body)
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
 }
@@ -0,0 +1,360 @@
+package executors
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/patali/yantra/src/templating"
+)
+
+// HTTP node auth modes (config: auth.type)
+const (
+	HTTPAuthBasic  = "basic"
+	HTTPAuthBearer = "bearer"
+	HTTPAuthAPIKey = "api-key"
+	HTTPAuthOAuth2 = "oauth2"
+)
+
+// HTTP node request signing modes (config: signing.type)
+const (
+	HTTPSigningHMAC    = "hmac"
+	HTTPSigningSigV4   = "aws-sigv4"
+	oauth2ExpirySkew   = 30 * time.Second
+	oauth2DefaultTTL   = 5 * time.Minute
+	maxOAuth2TokenSize = 1 << 20 // 1MB
+)
+
+// applyAuth applies the node's auth block to the request
+// forceRefresh bypasses the OAuth2 token cache (used after a 401)
+func (e *HTTPExecutor) applyAuth(ctx context.Context, req *http.Request, execCtx ExecutionContext, forceRefresh bool) error {
+	auth, ok := execCtx.NodeConfig["auth"].(map[string]interface{})
+	if !ok || len(auth) == 0 {
+		return nil
+	}
+
+	field := func(name string) string {
+		value, _ := auth[name].(string)
+		return renderTemplate(value, execCtx, templating.EscapeHeader)
+	}
+
+	authType, _ := auth["type"].(string)
+	switch strings.ToLower(authType) {
+	case "", "none":
+		return nil
+
+	case HTTPAuthBasic:
+		username := field("username")
+		if username == "" {
+			return fmt.Errorf("auth.username is required for basic auth")
+		}
+		req.SetBasicAuth(username, field("password"))
+
+	case HTTPAuthBearer:
+		token := field("token")
+		if token == "" {
+			return fmt.Errorf("auth.token is required for bearer auth")
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+	case HTTPAuthAPIKey:
+		key := field("key")
+		value := field("value")
+		if key == "" || value == "" {
+			return fmt.Errorf("auth.key and auth.value are required for api-key auth")
+		}
+		switch strings.ToLower(field("in")) {
+		case "", "header":
+			req.Header.Set(key, value)
+		case "query":
+			query := req.URL.Query()
+			query.Set(key, value)
+			req.URL.RawQuery = query.Encode()
+		default:
+			return fmt.Errorf("auth.in must be 'header' or 'query'")
+		}
+
+	case HTTPAuthOAuth2:
+		creds := oauth2ClientCredentials{
+			TokenURL:     field("tokenUrl"),
+			ClientID:     field("clientId"),
+			ClientSecret: field("clientSecret"),
+			Audience:     field("audience"),
+			AuthStyle:    strings.ToLower(field("authStyle")),
+			Scopes:       oauth2Scopes(auth["scopes"]),
+		}
+		if creds.TokenURL == "" || creds.ClientID == "" || creds.ClientSecret == "" {
+			return fmt.Errorf("auth.tokenUrl, auth.clientId and auth.clientSecret are required for oauth2 auth")
+		}
+		token, err := e.tokenCache.Token(ctx, e.client, execCtx.AccountID, creds, forceRefresh)
+		if err != nil {
+			return fmt.Errorf("oauth2 token request failed: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+	default:
+		return fmt.Errorf("unsupported auth type: %s (must be basic, bearer, api-key or oauth2)", authType)
+	}
+
+	return nil
+}
+
+// usesOAuth2 reports whether the node authenticates with OAuth2 client credentials
+func usesOAuth2(nodeConfig map[string]interface{}) bool {
+	auth, ok := nodeConfig["auth"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	authType, _ := auth["type"].(string)
+	return strings.ToLower(authType) == HTTPAuthOAuth2
+}
+
+// applySigning signs the request according to the node's signing block
+// Must run after all headers and the body are final
+func (e *HTTPExecutor) applySigning(ctx context.Context, req *http.Request, body []byte, execCtx ExecutionContext) error {
+	signing, ok := execCtx.NodeConfig["signing"].(map[string]interface{})
+	if !ok || len(signing) == 0 {
+		return nil
+	}
+
+	field := func(name string) string {
+		value, _ := signing[name].(string)
+		return renderTemplate(value, execCtx, templating.EscapeHeader)
+	}
+
+	signingType, _ := signing["type"].(string)
+	switch strings.ToLower(signingType) {
+	case "", "none":
+		return nil
+
+	case HTTPSigningHMAC:
+		secret := field("secret")
+		if secret == "" {
+			return fmt.Errorf("signing.secret is required for hmac signing")
+		}
+
+		var newHash func() hash.Hash
+		switch strings.ToLower(field("algorithm")) {
+		case "", "sha256":
+			newHash = sha256.New
+		case "sha512":
+			newHash = sha512.New
+		case "sha1":
+			newHash = sha1.New
+		default:
+			return fmt.Errorf("signing.algorithm must be sha256, sha512 or sha1")
+		}
+
+		// Optional timestamp is signed as "<timestamp>.<body>" to prevent replay
+		payload := body
+		if timestampHeader := field("timestampHeader"); timestampHeader != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set(timestampHeader, timestamp)
+			payload = append([]byte(timestamp+"."), body...)
+		}
+
+		mac := hmac.New(newHash, []byte(secret))
+		mac.Write(payload)
+		sum := mac.Sum(nil)
+
+		var signature string
+		switch strings.ToLower(field("encoding")) {
+		case "", "hex":
+			signature = hex.EncodeToString(sum)
+		case "base64":
+			signature = base64.StdEncoding.EncodeToString(sum)
+		default:
+			return fmt.Errorf("signing.encoding must be hex or base64")
+		}
+
+		header := field("header")
+		if header == "" {
+			header = "X-Signature"
+		}
+		req.Header.Set(header, field("prefix")+signature)
+
+	case HTTPSigningSigV4:
+		creds := aws.Credentials{
+			AccessKeyID:     field("accessKeyId"),
+			SecretAccessKey: field("secretAccessKey"),
+			SessionToken:    field("sessionToken"),
+		}
+		region := field("region")
+		service := field("service")
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" || region == "" || service == "" {
+			return fmt.Errorf("signing.accessKeyId, signing.secretAccessKey, signing.region and signing.service are required for aws-sigv4")
+		}
+
+		payloadHash := sha256.Sum256(body)
+		signer := v4.NewSigner()
+		if err := signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), service, region, time.Now()); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unsupported signing type: %s (must be hmac or aws-sigv4)", signingType)
+	}
+
+	return nil
+}
+
+// oauth2Scopes accepts scopes as a space/comma-separated string or an array
+func oauth2Scopes(raw interface{}) []string {
+	var scopes []string
+	switch v := raw.(type) {
+	case string:
+		scopes = strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	case []interface{}:
+		for _, s := range v {
+			if str, ok := s.(string); ok && str != "" {
+				scopes = append(scopes, str)
+			}
+		}
+	}
+	return scopes
+}
+
+// oauth2ClientCredentials identifies an OAuth2 client-credentials grant
+type oauth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Audience     string
+	AuthStyle    string // "header" (default, HTTP basic) or "body"
+	Scopes       []string
+}
+
+// cacheKey scopes tokens per account and credential; the secret is hashed so it never sits in map keys
+func (c oauth2ClientCredentials) cacheKey(accountID string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		accountID, c.TokenURL, c.ClientID, c.ClientSecret, c.Audience, strings.Join(c.Scopes, " "),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+type oauth2CacheEntry struct {
+	mu          sync.Mutex // Serializes refreshes so concurrent nodes share one token request
+	accessToken string
+	expiresAt   time.Time
+}
+
+// OAuth2TokenCache caches client-credentials access tokens until shortly before expiry
+// Safe for concurrent use; shared across executor instances
+type OAuth2TokenCache struct {
+	mu      sync.Mutex
+	entries map[string]*oauth2CacheEntry
+}
+
+// NewOAuth2TokenCache creates an empty token cache
+func NewOAuth2TokenCache() *OAuth2TokenCache {
+	return &OAuth2TokenCache{entries: make(map[string]*oauth2CacheEntry)}
+}
+
+// sharedOAuth2TokenCache is used by all HTTP executors so tokens survive across executions
+var sharedOAuth2TokenCache = NewOAuth2TokenCache()
+
+// Token returns a cached access token or fetches a new one
+func (c *OAuth2TokenCache) Token(ctx context.Context, client *http.Client, accountID string, creds oauth2ClientCredentials, forceRefresh bool) (string, error) {
+	key := creds.cacheKey(accountID)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &oauth2CacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if !forceRefresh && entry.accessToken != "" && time.Now().Before(entry.expiresAt) {
+		return entry.accessToken, nil
+	}
+
+	token, expiresIn, err := fetchOAuth2Token(ctx, client, creds)
+	if err != nil {
+		entry.accessToken = ""
+		return "", err
+	}
+
+	entry.accessToken = token
+	entry.expiresAt = time.Now().Add(expiresIn - oauth2ExpirySkew)
+	return token, nil
+}
+
+// fetchOAuth2Token performs the client-credentials grant
+func fetchOAuth2Token(ctx context.Context, client *http.Client, creds oauth2ClientCredentials) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(creds.Scopes) > 0 {
+		form.Set("scope", strings.Join(creds.Scopes, " "))
+	}
+	if creds.Audience != "" {
+		form.Set("audience", creds.Audience)
+	}
+	if creds.AuthStyle == "body" {
+		form.Set("client_id", creds.ClientID)
+		form.Set("client_secret", creds.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, creds.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if creds.AuthStyle != "body" {
+		req.SetBasicAuth(url.QueryEscape(creds.ClientID), url.QueryEscape(creds.ClientSecret))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxOAuth2TokenSize))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", 0, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		AccessToken string      `json:"access_token"`
+		TokenType   string      `json:"token_type"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.Unmarshal(respBody, &tokenResp); err != nil {
+		return "", 0, fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response did not include access_token")
+	}
+
+	expiresIn := oauth2DefaultTTL
+	if seconds, err := tokenResp.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		expiresIn = time.Duration(seconds) * time.Second
+	}
+
+	return tokenResp.AccessToken, expiresIn, nil
+}
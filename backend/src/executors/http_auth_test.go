@@ -0,0 +1,237 @@
+package executors
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newAuthTestExecutor() *HTTPExecutor {
+	executor := NewHTTPExecutor(&http.Client{Timeout: 5 * time.Second})
+	executor.tokenCache = NewOAuth2TokenCache()
+	return executor
+}
+
+func TestHTTPExecutor_Auth(t *testing.T) {
+	var lastRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	executor := newAuthTestExecutor()
+
+	t.Run("Basic auth with templated password", func(t *testing.T) {
+		result, err := executor.Execute(context.Background(), ExecutionContext{
+			NodeConfig: map[string]interface{}{
+				"url": server.URL,
+				"auth": map[string]interface{}{
+					"type":     "basic",
+					"username": "alice",
+					"password": "{{input.password}}",
+				},
+			},
+			Input: map[string]interface{}{"password": "s3cret"},
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		username, password, ok := lastRequest.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "alice", username)
+		assert.Equal(t, "s3cret", password)
+	})
+
+	t.Run("Bearer token", func(t *testing.T) {
+		_, err := executor.Execute(context.Background(), ExecutionContext{
+			NodeConfig: map[string]interface{}{
+				"url":  server.URL,
+				"auth": map[string]interface{}{"type": "bearer", "token": "abc123"},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer abc123", lastRequest.Header.Get("Authorization"))
+	})
+
+	t.Run("API key in query", func(t *testing.T) {
+		_, err := executor.Execute(context.Background(), ExecutionContext{
+			NodeConfig: map[string]interface{}{
+				"url": server.URL + "?page=2",
+				"auth": map[string]interface{}{
+					"type":  "api-key",
+					"key":   "api_key",
+					"value": "k-1",
+					"in":    "query",
+				},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "k-1", lastRequest.URL.Query().Get("api_key"))
+		assert.Equal(t, "2", lastRequest.URL.Query().Get("page"))
+	})
+
+	t.Run("API key in header", func(t *testing.T) {
+		_, err := executor.Execute(context.Background(), ExecutionContext{
+			NodeConfig: map[string]interface{}{
+				"url":  server.URL,
+				"auth": map[string]interface{}{"type": "api-key", "key": "X-API-Key", "value": "k-2"},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "k-2", lastRequest.Header.Get("X-API-Key"))
+	})
+
+	t.Run("Unsupported auth type", func(t *testing.T) {
+		result, err := executor.Execute(context.Background(), ExecutionContext{
+			NodeConfig: map[string]interface{}{
+				"url":  server.URL,
+				"auth": map[string]interface{}{"type": "kerberos"},
+			},
+		})
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "unsupported auth type")
+	})
+}
+
+func TestHTTPExecutor_OAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+		r.ParseForm()
+		clientID, clientSecret, _ := r.BasicAuth()
+		if r.Form.Get("grant_type") != "client_credentials" || clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	var revoked atomic.Bool
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if revoked.Load() && r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer apiServer.Close()
+
+	executor := newAuthTestExecutor()
+	execCtx := ExecutionContext{
+		AccountID: "account-1",
+		NodeConfig: map[string]interface{}{
+			"url": apiServer.URL,
+			"auth": map[string]interface{}{
+				"type":         "oauth2",
+				"tokenUrl":     tokenServer.URL,
+				"clientId":     "client",
+				"clientSecret": "secret",
+				"scopes":       []interface{}{"read", "write"},
+			},
+		},
+	}
+
+	// First two calls share a cached token
+	for i := 0; i < 2; i++ {
+		result, err := executor.Execute(context.Background(), execCtx)
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, "Bearer token-1", result.Output["data"])
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+
+	// A different account gets its own token
+	otherAccount := execCtx
+	otherAccount.AccountID = "account-2"
+	result, err := executor.Execute(context.Background(), otherAccount)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token-2", result.Output["data"])
+
+	// A 401 with a cached token triggers one refresh and retry
+	revoked.Store(true)
+	result, err = executor.Execute(context.Background(), execCtx)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "Bearer token-3", result.Output["data"])
+	assert.Equal(t, int32(3), atomic.LoadInt32(&tokenRequests))
+}
+
+func TestHTTPExecutor_HMACSigning(t *testing.T) {
+	var signature, timestamp, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Hub-Signature-256")
+		timestamp = r.Header.Get("X-Timestamp")
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	executor := newAuthTestExecutor()
+	_, err := executor.Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url":    server.URL,
+			"method": "POST",
+			"body":   map[string]interface{}{"event": "created"},
+			"signing": map[string]interface{}{
+				"type":            "hmac",
+				"secret":          "webhook-secret",
+				"header":          "X-Hub-Signature-256",
+				"prefix":          "sha256=",
+				"timestampHeader": "X-Timestamp",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte("webhook-secret"))
+	mac.Write([]byte(timestamp + "." + body))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+}
+
+func TestHTTPExecutor_SigV4Signing(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	executor := newAuthTestExecutor()
+	_, err := executor.Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url": server.URL,
+			"signing": map[string]interface{}{
+				"type":            "aws-sigv4",
+				"accessKeyId":     "AKIDEXAMPLE",
+				"secretAccessKey": "secret",
+				"region":          "us-east-1",
+				"service":         "execute-api",
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+	assert.Contains(t, authorization, "/us-east-1/execute-api/aws4_request")
+}
@@ -0,0 +1,410 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PaesslerAG/jsonpath"
+)
+
+// HTTP node pagination strategies (config: pagination.type)
+const (
+	PaginationPage   = "page"
+	PaginationOffset = "offset"
+	PaginationCursor = "cursor"
+	PaginationLink   = "link"
+)
+
+const (
+	defaultMaxPages = 10
+	globalMaxPages  = 1000
+	// maxPaginatedDataSize matches MaxDataSize in workflow_engine.go (10MB)
+	maxPaginatedDataSize = 10 * 1024 * 1024
+)
+
+// paginationConfig is the parsed pagination block of an http node
+type paginationConfig struct {
+	Type      string
+	MaxPages  int
+	ItemsPath string
+
+	// page
+	PageParam     string
+	StartPage     int
+	PageSizeParam string
+	PageSize      int
+
+	// offset
+	OffsetParam string
+	LimitParam  string
+	Limit       int
+	StartOffset int
+
+	// cursor
+	CursorPath  string
+	CursorParam string
+}
+
+func parsePaginationConfig(raw map[string]interface{}) (*paginationConfig, error) {
+	str := func(key, def string) string {
+		if v, ok := raw[key].(string); ok && v != "" {
+			return v
+		}
+		return def
+	}
+	num := func(key string, def int) int {
+		if v, ok := raw[key].(float64); ok {
+			return int(v)
+		}
+		if v, ok := raw[key].(int); ok {
+			return v
+		}
+		return def
+	}
+
+	cfg := &paginationConfig{
+		Type:          strings.ToLower(str("type", "")),
+		MaxPages:      num("maxPages", defaultMaxPages),
+		ItemsPath:     str("itemsPath", ""),
+		PageParam:     str("pageParam", "page"),
+		StartPage:     num("startPage", 1),
+		PageSizeParam: str("pageSizeParam", ""),
+		PageSize:      num("pageSize", 0),
+		OffsetParam:   str("offsetParam", "offset"),
+		LimitParam:    str("limitParam", "limit"),
+		Limit:         num("limit", 100),
+		StartOffset:   num("startOffset", 0),
+		CursorPath:    str("cursorPath", ""),
+		CursorParam:   str("cursorParam", "cursor"),
+	}
+
+	if cfg.MaxPages <= 0 {
+		cfg.MaxPages = defaultMaxPages
+	}
+	if cfg.MaxPages > globalMaxPages {
+		cfg.MaxPages = globalMaxPages
+	}
+
+	switch cfg.Type {
+	case PaginationPage, PaginationLink:
+	case PaginationOffset:
+		if cfg.Limit <= 0 {
+			return nil, fmt.Errorf("pagination.limit must be positive")
+		}
+	case PaginationCursor:
+		if cfg.CursorPath == "" {
+			return nil, fmt.Errorf("pagination.cursorPath is required for cursor pagination")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported pagination type: %s (must be page, offset, cursor or link)", cfg.Type)
+	}
+
+	return cfg, nil
+}
+
+// executePaginated follows pages until the API runs out, maxPages is hit, or data grows past maxPaginatedDataSize
+// Items from every page are concatenated into output.data
+func (e *HTTPExecutor) executePaginated(ctx context.Context, method, baseURL string, headers map[string]string, body []byte, execCtx ExecutionContext, rawConfig map[string]interface{}, respOpts *httpResponseOptions) (*ExecutionResult, error) {
+	cfg, err := parsePaginationConfig(rawConfig)
+	if err != nil {
+		return nil, Permanent(err)
+	}
+	// Items are read from each page's JSON, so only the JSON response types can paginate
+	if respOpts.ResponseType != ResponseTypeAuto && respOpts.ResponseType != ResponseTypeJSON {
+		return nil, Permanent(fmt.Errorf("responseType %s cannot be used with pagination (must be auto or json)", respOpts.ResponseType))
+	}
+
+	items := make([]interface{}, 0)
+	totalBytes := 0
+	pages := 0
+	hasMore := false
+	page := cfg.StartPage
+	offset := cfg.StartOffset
+	cursor := ""
+	pageURL, err := applyPaginationParams(baseURL, cfg, page, offset, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastResp *http.Response
+	for {
+		resp, respBody, size, err := e.send(ctx, method, pageURL, headers, body, execCtx, respOpts)
+		if err != nil {
+			return nil, err
+		}
+		lastResp = resp
+		pages++
+
+		data, decodeErr := respOpts.decodeBody(respBody)
+
+		// failedPage is the output of a failed page: its response and how far pagination got
+		failedPage := func(data interface{}) map[string]interface{} {
+			output := map[string]interface{}{
+				"status_code": resp.StatusCode,
+				"url":         pageURL,
+				"method":      method,
+				"data":        data,
+				"headers":     resp.Header,
+				"pages":       pages,
+				"item_count":  len(items), // Items collected from the pages before this one
+			}
+			respOpts.extract(resp, output)
+			return output
+		}
+
+		if !respOpts.accepts(resp.StatusCode) || decodeErr != nil {
+			result := &ExecutionResult{
+				Success:   false,
+				Output:    failedPage(data),
+				Error:     fmt.Sprintf("HTTP request for page %d failed with status %d", pages, resp.StatusCode),
+				Permanent: IsPermanentStatus(resp.StatusCode),
+			}
+			if respOpts.accepts(resp.StatusCode) {
+				result.Error = fmt.Sprintf("page %d: %v", pages, decodeErr)
+				result.Permanent = true
+			}
+			return result, nil
+		}
+
+		totalBytes += int(size)
+		if totalBytes > maxPaginatedDataSize {
+			// The oversized page's body is left out of the output
+			return &ExecutionResult{
+				Success:   false,
+				Output:    failedPage(nil),
+				Error:     fmt.Sprintf("paginated response size exceeds maximum allowed (%d bytes) after %d pages", maxPaginatedDataSize, pages),
+				Permanent: true,
+			}, nil
+		}
+
+		pageItems, err := extractPageItems(data, cfg.ItemsPath)
+		if err != nil {
+			return &ExecutionResult{
+				Success:   false,
+				Output:    failedPage(data),
+				Error:     fmt.Sprintf("page %d: %v", pages, err),
+				Permanent: true,
+			}, nil
+		}
+		items = append(items, pageItems...)
+
+		// Work out the next page, if any
+		nextURL := ""
+		switch cfg.Type {
+		case PaginationPage:
+			pageSize := cfg.PageSize
+			if len(pageItems) > 0 && (pageSize <= 0 || len(pageItems) >= pageSize) {
+				page++
+				nextURL, err = applyPaginationParams(baseURL, cfg, page, offset, cursor)
+			}
+		case PaginationOffset:
+			if len(pageItems) >= cfg.Limit {
+				offset += len(pageItems)
+				nextURL, err = applyPaginationParams(baseURL, cfg, page, offset, cursor)
+			}
+		case PaginationCursor:
+			next := extractCursor(data, cfg.CursorPath)
+			if next != "" && next != cursor {
+				cursor = next
+				nextURL, err = applyPaginationParams(baseURL, cfg, page, offset, cursor)
+			}
+		case PaginationLink:
+			nextURL, err = nextLinkURL(resp.Header, pageURL)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if nextURL == "" {
+			break
+		}
+		if pages >= cfg.MaxPages {
+			hasMore = true
+			break
+		}
+		pageURL = nextURL
+	}
+
+	output := map[string]interface{}{
+		"status_code": lastResp.StatusCode,
+		"url":         baseURL,
+		"method":      method,
+		"data":        items, // Primary output: items from all pages
+		"headers":     lastResp.Header,
+		"pages":       pages,
+		"item_count":  len(items),
+		"has_more":    hasMore, // True when maxPages stopped pagination early
+	}
+	respOpts.extract(lastResp, output) // Headers and cookies of the last page
+
+	return &ExecutionResult{
+		Success: true,
+		Output:  output,
+	}, nil
+}
+
+// applyPaginationParams sets the query parameters for the requested page
+func applyPaginationParams(baseURL string, cfg *paginationConfig, page, offset int, cursor string) (string, error) {
+	if cfg.Type == PaginationLink {
+		return baseURL, nil
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+
+	query := u.Query()
+	switch cfg.Type {
+	case PaginationPage:
+		query.Set(cfg.PageParam, strconv.Itoa(page))
+		if cfg.PageSizeParam != "" && cfg.PageSize > 0 {
+			query.Set(cfg.PageSizeParam, strconv.Itoa(cfg.PageSize))
+		}
+	case PaginationOffset:
+		query.Set(cfg.OffsetParam, strconv.Itoa(offset))
+		query.Set(cfg.LimitParam, strconv.Itoa(cfg.Limit))
+	case PaginationCursor:
+		if cursor == "" {
+			return baseURL, nil // First request goes out without a cursor
+		}
+		query.Set(cfg.CursorParam, cursor)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// extractPageItems finds the items array in a page body
+// Without itemsPath, a top-level array or a data/items/results array is used
+func extractPageItems(data interface{}, itemsPath string) ([]interface{}, error) {
+	if itemsPath != "" {
+		value, err := jsonpath.Get(normalizeJSONPath(itemsPath), data)
+		if err != nil {
+			return nil, fmt.Errorf("itemsPath %s not found: %w", itemsPath, err)
+		}
+		if value == nil {
+			return []interface{}{}, nil
+		}
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("itemsPath %s is not an array", itemsPath)
+		}
+		return arr, nil
+	}
+
+	if arr, ok := data.([]interface{}); ok {
+		return arr, nil
+	}
+	if obj, ok := data.(map[string]interface{}); ok {
+		for _, key := range []string{"data", "items", "results"} {
+			if arr, ok := obj[key].([]interface{}); ok {
+				return arr, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("response has no items array (set pagination.itemsPath)")
+}
+
+// extractCursor reads the next cursor; missing, null or empty means no more pages
+func extractCursor(data interface{}, cursorPath string) string {
+	value, err := jsonpath.Get(normalizeJSONPath(cursorPath), data)
+	if err != nil || value == nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return "" // Some APIs return has_more=false here
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// normalizeJSONPath accepts "meta.next" as shorthand for "$.meta.next"
+func normalizeJSONPath(path string) string {
+	if strings.HasPrefix(path, "$") {
+		return path
+	}
+	return "$." + path
+}
+
+// nextLinkURL returns the rel="next" target of an RFC 5988 Link header, resolved against the current URL
+// SECURITY: Only same-origin links are followed, since every page is sent with the node's auth and signing
+func nextLinkURL(header http.Header, currentURL string) (string, error) {
+	for _, value := range header.Values("Link") {
+		for _, link := range parseLinkHeader(value) {
+			for _, param := range strings.Split(link.params, ";") {
+				param = strings.TrimSpace(param)
+				if !strings.HasPrefix(strings.ToLower(param), "rel=") {
+					continue
+				}
+				rels := strings.Fields(strings.Trim(param[len("rel="):], `"`))
+				for _, rel := range rels {
+					if strings.EqualFold(rel, "next") {
+						base, err := url.Parse(currentURL)
+						if err != nil {
+							return "", fmt.Errorf("invalid url: %w", err)
+						}
+						ref, err := url.Parse(link.target)
+						if err != nil {
+							return "", fmt.Errorf("invalid Link header url: %w", err)
+						}
+						next := base.ResolveReference(ref)
+						if !strings.EqualFold(next.Scheme, base.Scheme) || !strings.EqualFold(next.Host, base.Host) {
+							return "", Permanent(fmt.Errorf("Link header next page %s is not on the same origin as %s://%s", next.Redacted(), base.Scheme, base.Host))
+						}
+						return next.String(), nil
+					}
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// linkValue is one link of a Link header: the target between < > and the parameters after it
+type linkValue struct {
+	target string
+	params string
+}
+
+// parseLinkHeader splits a Link header value into its links. Targets are read up to the closing >, so commas
+// inside a URL (?fields=a,b) don't split a link; parameters run to the next comma outside a quoted string
+func parseLinkHeader(value string) []linkValue {
+	var links []linkValue
+	for {
+		start := strings.Index(value, "<")
+		if start < 0 {
+			return links
+		}
+		end := strings.Index(value[start:], ">")
+		if end < 0 {
+			return links
+		}
+		target := value[start+1 : start+end]
+		rest := value[start+end+1:]
+
+		paramsEnd := len(rest)
+		quoted := false
+		for i, c := range rest {
+			if c == '"' {
+				quoted = !quoted
+			} else if c == ',' && !quoted {
+				paramsEnd = i
+				break
+			}
+		}
+
+		links = append(links, linkValue{target: strings.TrimSpace(target), params: rest[:paramsEnd]})
+		value = rest[paramsEnd:]
+	}
+}
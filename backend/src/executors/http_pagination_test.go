@@ -0,0 +1,285 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPaginationTestExecutor() *HTTPExecutor {
+	return NewHTTPExecutor(&http.Client{Timeout: 5 * time.Second})
+}
+
+func TestHTTPExecutor_PagePagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page > 3 {
+			fmt.Fprint(w, `{"items":[]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"items":[{"page":%d},{"page":%d}]}`, page, page)
+	}))
+	defer server.Close()
+
+	result, err := newPaginationTestExecutor().Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url": server.URL,
+			"pagination": map[string]interface{}{
+				"type":      "page",
+				"itemsPath": "$.items",
+				"pageSize":  float64(2),
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Len(t, result.Output["data"], 6)
+	assert.Equal(t, 4, result.Output["pages"])
+	assert.Equal(t, false, result.Output["has_more"])
+}
+
+func TestHTTPExecutor_OffsetPagination(t *testing.T) {
+	const total = 5
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		fmt.Fprint(w, "[")
+		for i := offset; i < offset+limit && i < total; i++ {
+			if i > offset {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, "%d", i)
+		}
+		fmt.Fprint(w, "]")
+	}))
+	defer server.Close()
+
+	result, err := newPaginationTestExecutor().Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url": server.URL,
+			"pagination": map[string]interface{}{
+				"type":  "offset",
+				"limit": float64(2),
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []interface{}{float64(0), float64(1), float64(2), float64(3), float64(4)}, result.Output["data"])
+	assert.Equal(t, 3, result.Output["pages"])
+}
+
+func TestHTTPExecutor_CursorPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("after") {
+		case "":
+			fmt.Fprint(w, `{"data":["a","b"],"meta":{"next":"c2"}}`)
+		case "c2":
+			fmt.Fprint(w, `{"data":["c"],"meta":{"next":null}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	result, err := newPaginationTestExecutor().Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url": server.URL,
+			"pagination": map[string]interface{}{
+				"type":        "cursor",
+				"cursorPath":  "meta.next",
+				"cursorParam": "after",
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, result.Output["data"])
+}
+
+func TestHTTPExecutor_LinkHeaderPaginationWithMaxPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		w.Header().Set("Link", fmt.Sprintf(`</items?p=%d>; rel="next", </items?p=99>; rel="last"`, page+1))
+		fmt.Fprintf(w, `[%d]`, page)
+	}))
+	defer server.Close()
+
+	result, err := newPaginationTestExecutor().Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url": server.URL + "/items?p=1",
+			"pagination": map[string]interface{}{
+				"type":     "link",
+				"maxPages": float64(3),
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []interface{}{float64(1), float64(2), float64(3)}, result.Output["data"])
+	assert.Equal(t, true, result.Output["has_more"])
+}
+
+func TestHTTPExecutor_LinkHeaderPaginationCrossOrigin(t *testing.T) {
+	var leakedAuth string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leakedAuth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `[2]`)
+	}))
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s/items?p=2>; rel="next"`, other.URL))
+		fmt.Fprint(w, `[1]`)
+	}))
+	defer server.Close()
+
+	_, err := newPaginationTestExecutor().Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url":        server.URL + "/items?p=1",
+			"auth":       map[string]interface{}{"type": "bearer", "token": "abc123"},
+			"pagination": map[string]interface{}{"type": "link"},
+		},
+	})
+
+	assert.Error(t, err)
+	assert.True(t, IsPermanentError(err))
+	assert.Contains(t, err.Error(), "not on the same origin")
+	assert.Equal(t, "", leakedAuth)
+}
+
+func TestHTTPExecutor_PaginationPageFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `[1]`)
+	}))
+	defer server.Close()
+
+	result, err := newPaginationTestExecutor().Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url":        server.URL,
+			"pagination": map[string]interface{}{"type": "page"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "page 2 failed with status 500")
+}
+
+func TestHTTPExecutor_PaginationItemsPathFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"error": "no items"}`)
+			return
+		}
+		fmt.Fprint(w, `{"items": [1]}`)
+	}))
+	defer server.Close()
+
+	result, err := newPaginationTestExecutor().Execute(context.Background(), ExecutionContext{
+		NodeConfig: map[string]interface{}{
+			"url":        server.URL,
+			"pagination": map[string]interface{}{"type": "page", "itemsPath": "$.items"},
+		},
+	})
+
+	// A page without the items array won't change on retry
+	assert.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.True(t, result.Permanent)
+	assert.Contains(t, result.Error, "page 2")
+	assert.Equal(t, 2, result.Output["pages"])
+	assert.Equal(t, 1, result.Output["item_count"])
+	assert.Equal(t, map[string]interface{}{"error": "no items"}, result.Output["data"])
+}
+
+func TestHTTPExecutor_PaginationResponseOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		w.Header().Set("X-Page", page)
+		if page == "2" && r.URL.Path == "/broken" {
+			fmt.Fprint(w, `not json`)
+			return
+		}
+		if page == "3" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprintf(w, `["item-%s"]`, page)
+	}))
+	defer server.Close()
+
+	run := func(path string, extra map[string]interface{}) (*ExecutionResult, error) {
+		config := map[string]interface{}{
+			"url":        server.URL + path,
+			"pagination": map[string]interface{}{"type": "page"},
+		}
+		for key, value := range extra {
+			config[key] = value
+		}
+		return newPaginationTestExecutor().Execute(context.Background(), ExecutionContext{NodeConfig: config})
+	}
+
+	t.Run("Extracts headers from the last page", func(t *testing.T) {
+		result, err := run("/items", map[string]interface{}{"extractHeaders": []interface{}{"X-Page"}})
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, []interface{}{"item-1", "item-2"}, result.Output["data"])
+		assert.Equal(t, map[string]interface{}{"X-Page": "3"}, result.Output["extracted_headers"])
+	})
+
+	t.Run("JSON response type fails on a non-JSON page", func(t *testing.T) {
+		result, err := run("/broken", map[string]interface{}{"responseType": "json"})
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.True(t, result.Permanent)
+		assert.Contains(t, result.Error, "page 2: response is not valid JSON")
+	})
+
+	t.Run("Non-JSON response types are rejected", func(t *testing.T) {
+		for _, responseType := range []string{"text", "base64", "discard"} {
+			_, err := run("/items", map[string]interface{}{"responseType": responseType})
+			assert.Error(t, err, responseType)
+			assert.True(t, IsPermanentError(err), responseType)
+		}
+	})
+}
+
+func TestNextLinkURL(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://api.example.com/items?page=3>; rel="next", <https://api.example.com/items?page=1>; rel="prev"`)
+
+	next, err := nextLinkURL(header, "https://api.example.com/items?page=2")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/items?page=3", next)
+
+	// Commas inside the URL or a quoted parameter don't split a link
+	header = http.Header{}
+	header.Add("Link", `<https://api.example.com/items?fields=a,b&page=1>; rel="prev"; title="a, b", <https://api.example.com/items?fields=a,b&page=3>; rel="next"`)
+	next, err = nextLinkURL(header, "https://api.example.com/items?fields=a,b&page=2")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/items?fields=a,b&page=3", next)
+
+	header = http.Header{}
+	header.Add("Link", `<http://api.example.com/items?page=3>; rel="next"`)
+	_, err = nextLinkURL(header, "https://api.example.com/items?page=2")
+	assert.Error(t, err)
+
+	next, err = nextLinkURL(http.Header{}, "https://api.example.com/items")
+	assert.NoError(t, err)
+	assert.Equal(t, "", next)
+}
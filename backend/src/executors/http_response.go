@@ -0,0 +1,222 @@
+package executors
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HTTP node response types (config: responseType)
+const (
+	ResponseTypeAuto    = "auto" // JSON if it parses, otherwise text (default)
+	ResponseTypeJSON    = "json"
+	ResponseTypeText    = "text"
+	ResponseTypeBase64  = "base64"
+	ResponseTypeDiscard = "discard"
+)
+
+// maxHTTPResponseSize matches MaxDataSize in workflow_engine.go (10MB)
+const maxHTTPResponseSize = 10 * 1024 * 1024
+
+// statusRange is an inclusive range of accepted status codes
+type statusRange struct {
+	min, max int
+}
+
+// httpResponseOptions controls how the http node reads and judges a response
+type httpResponseOptions struct {
+	MaxSize        int64
+	ResponseType   string
+	Accepted       []statusRange
+	ExtractHeaders []string
+	ExtractCookies []string
+	AllCookies     bool
+}
+
+// parseHTTPResponseOptions reads maxResponseSize, responseType, acceptedStatusCodes,
+// extractHeaders and extractCookies from the node config
+func parseHTTPResponseOptions(config map[string]interface{}) (*httpResponseOptions, error) {
+	opts := &httpResponseOptions{
+		MaxSize:      maxHTTPResponseSize,
+		ResponseType: ResponseTypeAuto,
+		Accepted:     []statusRange{{200, 299}},
+	}
+
+	if size, ok := config["maxResponseSize"].(float64); ok && size > 0 {
+		if int64(size) < opts.MaxSize {
+			opts.MaxSize = int64(size)
+		}
+	}
+
+	if rt, ok := config["responseType"].(string); ok && rt != "" {
+		switch strings.ToLower(rt) {
+		case ResponseTypeAuto, ResponseTypeJSON, ResponseTypeText, ResponseTypeBase64, ResponseTypeDiscard:
+			opts.ResponseType = strings.ToLower(rt)
+		default:
+			return nil, fmt.Errorf("unsupported responseType: %s (must be auto, json, text, base64 or discard)", rt)
+		}
+	}
+
+	if raw, ok := config["acceptedStatusCodes"].([]interface{}); ok && len(raw) > 0 {
+		accepted, err := parseStatusRanges(raw)
+		if err != nil {
+			return nil, err
+		}
+		opts.Accepted = accepted
+	}
+
+	if raw, ok := config["extractHeaders"].([]interface{}); ok {
+		for _, h := range raw {
+			if name, ok := h.(string); ok && name != "" {
+				opts.ExtractHeaders = append(opts.ExtractHeaders, name)
+			}
+		}
+	}
+
+	switch raw := config["extractCookies"].(type) {
+	case bool:
+		opts.AllCookies = raw
+	case []interface{}:
+		for _, c := range raw {
+			if name, ok := c.(string); ok && name != "" {
+				opts.ExtractCookies = append(opts.ExtractCookies, name)
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+// parseStatusRanges accepts codes (404), class patterns ("2xx") and ranges ("400-499")
+func parseStatusRanges(raw []interface{}) ([]statusRange, error) {
+	ranges := make([]statusRange, 0, len(raw))
+	for _, entry := range raw {
+		switch v := entry.(type) {
+		case float64:
+			ranges = append(ranges, statusRange{int(v), int(v)})
+		case int:
+			ranges = append(ranges, statusRange{v, v})
+		case string:
+			v = strings.ToLower(strings.TrimSpace(v))
+			if len(v) == 3 && strings.HasSuffix(v, "xx") && v[0] >= '1' && v[0] <= '5' {
+				base := int(v[0]-'0') * 100
+				ranges = append(ranges, statusRange{base, base + 99})
+				continue
+			}
+			if lo, hi, found := strings.Cut(v, "-"); found {
+				min, err1 := strconv.Atoi(strings.TrimSpace(lo))
+				max, err2 := strconv.Atoi(strings.TrimSpace(hi))
+				if err1 != nil || err2 != nil || min > max {
+					return nil, fmt.Errorf("invalid status code range: %s", v)
+				}
+				ranges = append(ranges, statusRange{min, max})
+				continue
+			}
+			code, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code: %s", v)
+			}
+			ranges = append(ranges, statusRange{code, code})
+		default:
+			return nil, fmt.Errorf("invalid status code entry: %v", entry)
+		}
+	}
+	return ranges, nil
+}
+
+// accepts reports whether a status code counts as success
+func (o *httpResponseOptions) accepts(code int) bool {
+	for _, r := range o.Accepted {
+		if code >= r.min && code <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody reads at most MaxSize bytes; discard mode drains without keeping the body
+func (o *httpResponseOptions) readBody(body io.Reader) ([]byte, int64, error) {
+	limited := io.LimitReader(body, o.MaxSize+1)
+
+	if o.ResponseType == ResponseTypeDiscard {
+		n, err := io.Copy(io.Discard, limited)
+		if err != nil {
+			return nil, n, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, n, nil
+	}
+
+	data, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, int64(len(data)), fmt.Errorf("failed to read response: %w", err)
+	}
+	if int64(len(data)) > o.MaxSize {
+		return nil, int64(len(data)), fmt.Errorf("response body exceeds maximum size of %d bytes", o.MaxSize)
+	}
+	return data, int64(len(data)), nil
+}
+
+// decodeBody converts the raw body into the node's data output
+func (o *httpResponseOptions) decodeBody(body []byte) (interface{}, error) {
+	switch o.ResponseType {
+	case ResponseTypeDiscard:
+		return nil, nil
+	case ResponseTypeText:
+		return string(body), nil
+	case ResponseTypeBase64:
+		return base64.StdEncoding.EncodeToString(body), nil
+	case ResponseTypeJSON:
+		var data interface{}
+		if len(body) == 0 {
+			return nil, nil
+		}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, fmt.Errorf("response is not valid JSON: %w", err)
+		}
+		return data, nil
+	default:
+		// Try to parse as JSON, otherwise return as string
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return string(body), nil
+		}
+		return data, nil
+	}
+}
+
+// extract adds requested headers and cookies to the output
+func (o *httpResponseOptions) extract(resp *http.Response, output map[string]interface{}) {
+	if len(o.ExtractHeaders) > 0 {
+		extracted := make(map[string]interface{}, len(o.ExtractHeaders))
+		for _, name := range o.ExtractHeaders {
+			values := resp.Header.Values(name)
+			switch len(values) {
+			case 0:
+				extracted[name] = nil
+			case 1:
+				extracted[name] = values[0]
+			default:
+				extracted[name] = values
+			}
+		}
+		output["extracted_headers"] = extracted
+	}
+
+	if o.AllCookies || len(o.ExtractCookies) > 0 {
+		wanted := make(map[string]bool, len(o.ExtractCookies))
+		for _, name := range o.ExtractCookies {
+			wanted[name] = true
+		}
+		cookies := make(map[string]interface{})
+		for _, cookie := range resp.Cookies() {
+			if o.AllCookies || wanted[cookie.Name] {
+				cookies[cookie.Name] = cookie.Value
+			}
+		}
+		output["cookies"] = cookies
+	}
+}
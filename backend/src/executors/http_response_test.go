@@ -0,0 +1,138 @@
+package executors
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPExecutor_ResponseOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0x00, 0x01, 0xff})
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 2048)))
+		case "/session":
+			w.Header().Set("X-Request-Id", "req-1")
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+			http.SetCookie(w, &http.Cookie{Name: "tracking", Value: "t"})
+			w.Write([]byte(`ok`))
+		default:
+			w.Write([]byte(`not json`))
+		}
+	}))
+	defer server.Close()
+
+	executor := NewHTTPExecutor(&http.Client{Timeout: 5 * time.Second})
+	run := func(config map[string]interface{}) (*ExecutionResult, error) {
+		return executor.Execute(context.Background(), ExecutionContext{NodeConfig: config})
+	}
+
+	t.Run("404 fails by default", func(t *testing.T) {
+		result, err := run(map[string]interface{}{"url": server.URL + "/missing"})
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.True(t, result.Permanent, "4xx responses are not retried")
+	})
+
+	t.Run("Accepted status codes", func(t *testing.T) {
+		result, err := run(map[string]interface{}{
+			"url":                 server.URL + "/missing",
+			"acceptedStatusCodes": []interface{}{"2xx", float64(404)},
+		})
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, 404, result.Output["status_code"])
+		assert.Equal(t, "not found", result.Output["data"].(map[string]interface{})["error"])
+	})
+
+	t.Run("Base64 binary body", func(t *testing.T) {
+		result, err := run(map[string]interface{}{
+			"url":          server.URL + "/binary",
+			"responseType": "base64",
+		})
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0x00, 0x01, 0xff}), result.Output["data"])
+		assert.Equal(t, "application/octet-stream", result.Output["content_type"])
+		assert.Equal(t, int64(3), result.Output["size"])
+	})
+
+	t.Run("JSON response type rejects non-JSON", func(t *testing.T) {
+		result, err := run(map[string]interface{}{
+			"url":          server.URL,
+			"responseType": "json",
+		})
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "not valid JSON")
+	})
+
+	t.Run("Discard keeps no body", func(t *testing.T) {
+		result, err := run(map[string]interface{}{
+			"url":          server.URL + "/large",
+			"responseType": "discard",
+		})
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Nil(t, result.Output["data"])
+		assert.Equal(t, int64(2048), result.Output["size"])
+	})
+
+	t.Run("Max response size", func(t *testing.T) {
+		result, err := run(map[string]interface{}{
+			"url":             server.URL + "/large",
+			"maxResponseSize": float64(1024),
+		})
+		assert.Nil(t, result)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds maximum size of 1024 bytes")
+	})
+
+	t.Run("Extract headers and cookies", func(t *testing.T) {
+		result, err := run(map[string]interface{}{
+			"url":            server.URL + "/session",
+			"extractHeaders": []interface{}{"X-Request-Id", "X-Missing"},
+			"extractCookies": []interface{}{"session"},
+		})
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, map[string]interface{}{"X-Request-Id": "req-1", "X-Missing": nil}, result.Output["extracted_headers"])
+		assert.Equal(t, map[string]interface{}{"session": "abc"}, result.Output["cookies"])
+	})
+
+	t.Run("Invalid response type", func(t *testing.T) {
+		_, err := run(map[string]interface{}{
+			"url":          server.URL,
+			"responseType": "xml",
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseStatusRanges(t *testing.T) {
+	ranges, err := parseStatusRanges([]interface{}{"2xx", "400-404", float64(409), "410"})
+	assert.NoError(t, err)
+
+	opts := &httpResponseOptions{Accepted: ranges}
+	for _, code := range []int{200, 299, 400, 404, 409, 410} {
+		assert.True(t, opts.accepts(code), code)
+	}
+	for _, code := range []int{301, 405, 500} {
+		assert.False(t, opts.accepts(code), code)
+	}
+
+	_, err = parseStatusRanges([]interface{}{"500-400"})
+	assert.Error(t, err)
+}
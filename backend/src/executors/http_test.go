@@ -3,9 +3,11 @@ package executors
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/patali/yantra/src/templating"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,6 +31,7 @@ func TestHTTPExecutor(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "url is required")
+		assert.True(t, IsPermanentError(err))
 	})
 
 	t.Run("Default method is GET", func(t *testing.T) {
@@ -112,10 +115,53 @@ func TestHTTPExecutor(t *testing.T) {
 	})
 }
 
+func TestHTTPExecutor_IdempotencyKey(t *testing.T) {
+	received := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	executor := NewHTTPExecutor(&http.Client{Timeout: 5 * time.Second})
+	run := func(config map[string]interface{}, key string) (*ExecutionResult, http.Header) {
+		result, err := executor.Execute(context.Background(), ExecutionContext{NodeConfig: config, IdempotencyKey: key})
+		assert.NoError(t, err)
+		return result, <-received
+	}
+
+	t.Run("Async calls send the outbox key", func(t *testing.T) {
+		result, headers := run(map[string]interface{}{"url": server.URL, "method": "POST"}, "exec-1-node-1")
+		assert.Equal(t, "exec-1-node-1", headers.Get("Idempotency-Key"))
+		assert.False(t, result.Success)
+		assert.False(t, result.Permanent, "5xx responses are retried")
+	})
+
+	t.Run("Custom header name", func(t *testing.T) {
+		_, headers := run(map[string]interface{}{"url": server.URL, "idempotencyHeader": "X-Request-Id"}, "exec-1-node-1")
+		assert.Equal(t, "exec-1-node-1", headers.Get("X-Request-Id"))
+		assert.Empty(t, headers.Get("Idempotency-Key"))
+	})
+
+	t.Run("Configured header wins", func(t *testing.T) {
+		_, headers := run(map[string]interface{}{
+			"url":     server.URL,
+			"headers": map[string]interface{}{"idempotency-key": "order-42"},
+		}, "exec-1-node-1")
+		assert.Equal(t, "order-42", headers.Get("Idempotency-Key"))
+	})
+
+	t.Run("Synchronous calls send no key", func(t *testing.T) {
+		_, headers := run(map[string]interface{}{"url": server.URL}, "")
+		assert.Empty(t, headers.Get("Idempotency-Key"))
+	})
+}
+
 // TestHTTPTemplateVariables tests the template variable replacement
 func TestHTTPTemplateVariables(t *testing.T) {
-	client := &http.Client{Timeout: 5 * time.Second}
-	executor := NewHTTPExecutor(client)
+	render := func(text string, input interface{}, escape templating.Escape) string {
+		return renderTemplate(text, ExecutionContext{Input: input}, escape)
+	}
 
 	t.Run("Simple variable replacement", func(t *testing.T) {
 		input := map[string]interface{}{
@@ -123,7 +169,7 @@ func TestHTTPTemplateVariables(t *testing.T) {
 			"age":  30,
 		}
 
-		result := executor.replaceTemplateVariables("Hello {{name}}, you are {{age}} years old", input)
+		result := render("Hello {{name}}, you are {{age}} years old", input, templating.EscapeNone)
 		assert.Equal(t, "Hello John, you are 30 years old", result)
 	})
 
@@ -132,7 +178,7 @@ func TestHTTPTemplateVariables(t *testing.T) {
 			"datetime": "2025-11-29 15:08:32",
 		}
 
-		result := executor.replaceTemplateVariablesWithEncoding("http://api.example.com?time={{datetime}}", input, true)
+		result := render("http://api.example.com?time={{datetime}}", input, templating.EscapeURL)
 		// Spaces should be URL-encoded
 		assert.Contains(t, result, "2025-11-29")
 		assert.NotContains(t, result, " ") // Should not contain raw spaces
@@ -148,7 +194,7 @@ func TestHTTPTemplateVariables(t *testing.T) {
 			},
 		}
 
-		result := executor.replaceTemplateVariables("User: {{user.name}}, Email: {{user.email}}", input)
+		result := render("User: {{user.name}}, Email: {{user.email}}", input, templating.EscapeNone)
 		assert.Equal(t, "User: Jane, Email: jane@example.com", result)
 	})
 
@@ -158,7 +204,7 @@ func TestHTTPTemplateVariables(t *testing.T) {
 			"token":  "abc123",
 		}
 
-		result := executor.replaceTemplateVariables("https://api.example.com/user/{{userId}}?token={{token}}", input)
+		result := render("https://api.example.com/user/{{userId}}?token={{token}}", input, templating.EscapeNone)
 		assert.Equal(t, "https://api.example.com/user/123?token=abc123", result)
 	})
 
@@ -167,7 +213,7 @@ func TestHTTPTemplateVariables(t *testing.T) {
 			"name": "John",
 		}
 
-		result := executor.replaceTemplateVariables("Hello {{name}}, your age is {{age}}", input)
+		result := render("Hello {{name}}, your age is {{age}}", input, templating.EscapeNone)
 		assert.Equal(t, "Hello John, your age is {{age}}", result)
 	})
 
@@ -176,7 +222,7 @@ func TestHTTPTemplateVariables(t *testing.T) {
 			"name": "John",
 		}
 
-		result := executor.replaceTemplateVariables("{{name}} said hello. {{name}} is happy.", input)
+		result := render("{{name}} said hello. {{name}} is happy.", input, templating.EscapeNone)
 		assert.Equal(t, "John said hello. John is happy.", result)
 	})
 
@@ -189,11 +235,11 @@ func TestHTTPTemplateVariables(t *testing.T) {
 		}
 
 		// Should work with "input." prefix even though data is at top level
-		result := executor.replaceTemplateVariables("User: {{input.userId}}, Email: {{input.email}}", input)
+		result := render("User: {{input.userId}}, Email: {{input.email}}", input, templating.EscapeNone)
 		assert.Equal(t, "User: 123, Email: test@example.com", result)
 
 		// Should also work without "input." prefix
-		result2 := executor.replaceTemplateVariables("User: {{userId}}, Email: {{email}}", input)
+		result2 := render("User: {{userId}}, Email: {{email}}", input, templating.EscapeNone)
 		assert.Equal(t, "User: 123, Email: test@example.com", result2)
 	})
 }
@@ -56,8 +56,15 @@ func (e *LoopExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*
 		}
 	}
 
-	// Get iteration config with abuse prevention limits
-	iterationCount := len(items)
+	// With batchSize, each iteration gets a chunk of items instead of a single item
+	batchSize := loopBatchSize(execCtx.NodeConfig)
+	iterationItems := items
+	if batchSize > 1 {
+		iterationItems = batchItems(items, batchSize)
+	}
+
+	// Get iteration config with abuse prevention limits (batched loops count chunks)
+	iterationCount := len(iterationItems)
 
 	// Global maximum to prevent system abuse (10,000 iterations)
 	const globalMaxIterations = 10000
@@ -95,11 +102,15 @@ func (e *LoopExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*
 
 	// Store results from each iteration
 	results := make([]interface{}, iterationCount)
-	for i, item := range items {
-		results[i] = map[string]interface{}{
+	for i, item := range iterationItems {
+		result := map[string]interface{}{
 			indexVariable: i,
 			itemVariable:  item,
 		}
+		if batchSize > 1 {
+			result["offset"] = i * batchSize
+		}
+		results[i] = result
 	}
 
 	output := map[string]interface{}{
@@ -109,6 +120,10 @@ func (e *LoopExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*
 		"items":           items,
 	}
 
+	if batchSize > 1 {
+		output["batch_size"] = batchSize
+	}
+
 	return &ExecutionResult{
 		Success: true,
 		Output:  output,
@@ -149,3 +164,25 @@ func (e *LoopExecutor) extractArrayFromPath(data interface{}, path string) ([]in
 
 	return nil, false
 }
+
+// loopBatchSize reads a loop's batchSize config; 0 or 1 means one item per iteration
+func loopBatchSize(config map[string]interface{}) int {
+	size, _ := config["batchSize"].(float64)
+	if size < 1 {
+		return 1
+	}
+	return int(size)
+}
+
+// batchItems splits items into chunks of size items; the last chunk may be shorter
+func batchItems(items []interface{}, size int) []interface{} {
+	chunks := make([]interface{}, 0, (len(items)+size-1)/size)
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[start:end:end])
+	}
+	return chunks
+}
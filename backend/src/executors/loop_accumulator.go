@@ -59,8 +59,15 @@ func (e *LoopAccumulatorExecutor) Execute(ctx context.Context, execCtx Execution
 		}
 	}
 
-	// Get iteration config with abuse prevention limits
-	iterationCount := len(items)
+	// With batchSize, each iteration gets a chunk of items instead of a single item
+	batchSize := loopBatchSize(execCtx.NodeConfig)
+	iterationItems := items
+	if batchSize > 1 {
+		iterationItems = batchItems(items, batchSize)
+	}
+
+	// Get iteration config with abuse prevention limits (batched loops count chunks)
+	iterationCount := len(iterationItems)
 
 	// Global maximum to prevent system abuse (10,000 iterations)
 	const globalMaxIterations = 10000
@@ -109,12 +116,16 @@ func (e *LoopAccumulatorExecutor) Execute(ctx context.Context, execCtx Execution
 
 	// Store results from each iteration
 	results := make([]interface{}, iterationCount)
-	for i, item := range items {
-		results[i] = map[string]interface{}{
+	for i, item := range iterationItems {
+		result := map[string]interface{}{
 			indexVariable:       i,
 			itemVariable:        item,
 			accumulatorVariable: []interface{}{}, // Initial empty accumulator
 		}
+		if batchSize > 1 {
+			result["offset"] = i * batchSize
+		}
+		results[i] = result
 	}
 
 	output := map[string]interface{}{
@@ -126,6 +137,10 @@ func (e *LoopAccumulatorExecutor) Execute(ctx context.Context, execCtx Execution
 		"accumulatorVariable": accumulatorVariable,
 	}
 
+	if batchSize > 1 {
+		output["batch_size"] = batchSize
+	}
+
 	return &ExecutionResult{
 		Success: true,
 		Output:  output,
@@ -115,6 +115,32 @@ func TestLoopExecutor(t *testing.T) {
 		assert.Contains(t, result.Error, "exceeds maximum")
 	})
 
+	t.Run("Batch size splits items into chunks", func(t *testing.T) {
+		input := []interface{}{1.0, 2.0, 3.0, 4.0, 5.0}
+		execCtx := ExecutionContext{
+			NodeID:     "loop-node",
+			NodeConfig: map[string]interface{}{"batchSize": float64(2), "max_iterations": float64(3)},
+			Input:      input,
+		}
+
+		result, err := executor.Execute(context.Background(), execCtx)
+
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, 3, result.Output["iteration_count"], "max_iterations counts chunks")
+		assert.Equal(t, 2, result.Output["batch_size"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"index": 0, "item": []interface{}{1.0, 2.0}, "offset": 0},
+			map[string]interface{}{"index": 1, "item": []interface{}{3.0, 4.0}, "offset": 2},
+			map[string]interface{}{"index": 2, "item": []interface{}{5.0}, "offset": 4},
+		}, result.Output["results"])
+		assert.Len(t, result.Output["items"], 5)
+
+		execCtx.NodeConfig["batchSize"] = float64(1)
+		result, _ = executor.Execute(context.Background(), execCtx)
+		assert.False(t, result.Success, "5 single-item iterations exceed max_iterations")
+	})
+
 	t.Run("Invalid input - not an array", func(t *testing.T) {
 		execCtx := ExecutionContext{
 			NodeID:      "loop-node",
@@ -18,6 +18,7 @@ const (
 	NodeTypeJSON            = "json"
 	NodeTypeJSONArray       = "json-array"
 	NodeTypeJSONToCSV       = "json_to_csv"
+	NodeTypeWasm            = "wasm"
 
 	// End node types
 	NodeTypeEnd = "end"
@@ -31,10 +32,12 @@ var (
 	// EndNodeTypes are node types that mark workflow completion
 	EndNodeTypes = []string{NodeTypeEnd}
 
-	// AsyncNodeTypes are node types that require the outbox pattern
+	// AsyncNodeTypes are the built-in node types that require the outbox pattern
+	// (custom node types declare this on registration; see IsAsyncNode)
 	AsyncNodeTypes = []string{NodeTypeEmail, NodeTypeSlack}
 
-	// AllValidNodeTypes contains all supported node types for validation
+	// AllValidNodeTypes contains the built-in node types
+	// (custom node types are added to the registry; see IsValidNodeType)
 	AllValidNodeTypes = []string{
 		NodeTypeStart,
 		NodeTypeConditional,
@@ -49,6 +52,7 @@ var (
 		NodeTypeJSON,
 		NodeTypeJSONArray,
 		NodeTypeJSONToCSV,
+		NodeTypeWasm,
 		NodeTypeEnd,
 	}
 )
@@ -61,22 +65,20 @@ func IsSkippableNode(nodeType string) bool {
 
 // IsAsyncNode returns true if node requires outbox pattern
 func IsAsyncNode(nodeType string) bool {
-	for _, t := range AsyncNodeTypes {
-		if t == nodeType {
-			return true
-		}
-	}
-	return false
+	t, ok := defaultRegistry.Lookup(nodeType)
+	return ok && t.Async
+}
+
+// SupportsAsync returns true if the node type can run through the outbox, always or when its config opts in
+func SupportsAsync(nodeType string) bool {
+	t, ok := defaultRegistry.Lookup(nodeType)
+	return ok && (t.Async || t.AsyncOptIn)
 }
 
-// IsValidNodeType returns true if the node type is supported
+// IsValidNodeType returns true if the node type is registered
 func IsValidNodeType(nodeType string) bool {
-	for _, t := range AllValidNodeTypes {
-		if t == nodeType {
-			return true
-		}
-	}
-	return false
+	_, ok := defaultRegistry.Lookup(nodeType)
+	return ok
 }
 
 // IsTriggerNode returns true if the node type is a trigger node
@@ -0,0 +1,381 @@
+package executors
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Dependencies are the shared resources handed to executor constructors
+type Dependencies struct {
+	DB           *gorm.DB
+	EmailService EmailServiceInterface
+	HTTPClient   *http.Client // egress-policy aware, shared across executors
+	Wasm         *WasmRuntime
+}
+
+// Constructor builds a new executor instance for one node run
+type Constructor func(deps Dependencies) Executor
+
+// Config field types
+const (
+	FieldString  = "string"
+	FieldNumber  = "number"
+	FieldBoolean = "boolean"
+	FieldObject  = "object"
+	FieldArray   = "array"
+	FieldAny     = "any"
+)
+
+// ConfigField describes one field of a node type's data.config
+type ConfigField struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// NodeType is a registered node type
+type NodeType struct {
+	Type        string
+	Name        string
+	Description string
+	Category    string
+	Config      []ConfigField
+
+	// New builds the executor; nil for structural nodes (start, end) that are traversed but not executed
+	New Constructor
+
+	// Async nodes run through the outbox; EventType is the outbox event they are dispatched with
+	// (defaults to "<type>.send" for async nodes). AsyncOptIn nodes run synchronously unless their
+	// config sets "async": true
+	Async      bool
+	AsyncOptIn bool
+	EventType  string
+
+	Builtin bool
+}
+
+// Registry holds the node types the engine, validator and outbox worker know about
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]NodeType
+}
+
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[string]NodeType)}
+}
+
+// Register adds a node type; registering an existing type is an error
+func (r *Registry) Register(nodeType NodeType) error {
+	if nodeType.Type == "" {
+		return fmt.Errorf("node type is required")
+	}
+	if (nodeType.Async || nodeType.AsyncOptIn) && nodeType.EventType == "" {
+		nodeType.EventType = nodeType.Type + ".send"
+	}
+	if (nodeType.Async || nodeType.AsyncOptIn) && nodeType.New == nil {
+		return fmt.Errorf("async node type %s needs an executor constructor", nodeType.Type)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.types[nodeType.Type]; exists {
+		return fmt.Errorf("node type %s is already registered", nodeType.Type)
+	}
+	for _, existing := range r.types {
+		if nodeType.EventType != "" && existing.EventType == nodeType.EventType {
+			return fmt.Errorf("event type %s is already used by node type %s", nodeType.EventType, existing.Type)
+		}
+	}
+	r.types[nodeType.Type] = nodeType
+	return nil
+}
+
+// Lookup returns a registered node type
+func (r *Registry) Lookup(nodeType string) (NodeType, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.types[nodeType]
+	return t, ok
+}
+
+// LookupEventType returns the node type dispatched with an outbox event type
+func (r *Registry) LookupEventType(eventType string) (NodeType, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.types {
+		if t.EventType != "" && t.EventType == eventType {
+			return t, true
+		}
+	}
+	return NodeType{}, false
+}
+
+// List returns all node types, built-ins first, then by type name
+func (r *Registry) List() []NodeType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]NodeType, 0, len(r.types))
+	for _, t := range r.types {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Builtin != list[j].Builtin {
+			return list[i].Builtin
+		}
+		return list[i].Type < list[j].Type
+	})
+	return list
+}
+
+// defaultRegistry is the process-wide registry, seeded with the built-in node types
+var defaultRegistry = newBuiltinRegistry()
+
+// DefaultRegistry returns the process-wide node type registry
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// RegisterNodeType adds a custom node type to the process-wide registry.
+// Call it before the server starts (e.g. from main or an init function in a package main imports)
+func RegisterNodeType(nodeType NodeType) error {
+	nodeType.Builtin = false
+	return defaultRegistry.Register(nodeType)
+}
+
+// EventTypeForNodeType returns the outbox event type of a node type, or "unknown"
+func EventTypeForNodeType(nodeType string) string {
+	if t, ok := defaultRegistry.Lookup(nodeType); ok && t.EventType != "" {
+		return t.EventType
+	}
+	return "unknown"
+}
+
+func newBuiltinRegistry() *Registry {
+	r := NewRegistry()
+	for _, t := range builtinNodeTypes() {
+		t.Builtin = true
+		if err := r.Register(t); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+func builtinNodeTypes() []NodeType {
+	return []NodeType{
+		{
+			Type:        NodeTypeStart,
+			Name:        "Start",
+			Description: "Entry point; its output is the workflow input",
+			Category:    "trigger",
+		},
+		{
+			Type:        NodeTypeEnd,
+			Name:        "End",
+			Description: "Marks workflow completion",
+			Category:    "control",
+		},
+		{
+			Type:        NodeTypeConditional,
+			Name:        "Conditional",
+			Description: "Evaluates a condition for branching",
+			Category:    "control",
+			New:         func(Dependencies) Executor { return NewConditionalExecutor() },
+			Config: []ConfigField{
+				{Name: "condition", Type: FieldString, Description: "Expression, e.g. input.total > 100"},
+				{Name: "conditions", Type: FieldArray, Description: "Structured conditions ({left, operator, right}) used when condition is empty"},
+				{Name: "logicalOperator", Type: FieldString, Enum: []string{"AND", "OR"}},
+			},
+		},
+		{
+			Type:        NodeTypeTransform,
+			Name:        "Transform",
+			Description: "Reshapes data with a list of operations",
+			Category:    "data",
+			New:         func(Dependencies) Executor { return NewTransformExecutor() },
+			Config: []ConfigField{
+				{Name: "operations", Type: FieldArray, Description: "extract, map, filter, ... applied in order"},
+			},
+		},
+		{
+			Type:        NodeTypeDelay,
+			Name:        "Delay",
+			Description: "Waits in-process for a short duration",
+			Category:    "control",
+			New:         func(Dependencies) Executor { return NewDelayExecutor() },
+			Config: []ConfigField{
+				{Name: "duration", Type: FieldNumber, Description: "Milliseconds (default 1000)"},
+			},
+		},
+		{
+			Type:        NodeTypeSleep,
+			Name:        "Sleep",
+			Description: "Pauses the workflow until a date or for a duration",
+			Category:    "control",
+			New:         func(Dependencies) Executor { return NewSleepExecutor() },
+			Config: []ConfigField{
+				{Name: "mode", Type: FieldString, Required: true, Enum: []string{"absolute", "relative"}},
+				{Name: "target_date", Type: FieldString, Description: "Required for absolute mode"},
+				{Name: "duration_value", Type: FieldNumber, Description: "Required for relative mode"},
+				{Name: "duration_unit", Type: FieldString, Description: "Required for relative mode"},
+				{Name: "timezone", Type: FieldString},
+			},
+		},
+		{
+			Type:        NodeTypeEmail,
+			Name:        "Email",
+			Description: "Sends an email through the outbox",
+			Category:    "action",
+			New:         func(deps Dependencies) Executor { return NewEmailExecutor(deps.DB, deps.EmailService) },
+			Async:       true,
+			EventType:   "email.send",
+			Config: []ConfigField{
+				{Name: "to", Type: FieldString, Required: true},
+				{Name: "subject", Type: FieldString, Required: true},
+				{Name: "body", Type: FieldString},
+				{Name: "html", Type: FieldString},
+				{Name: "cc", Type: FieldString},
+				{Name: "bcc", Type: FieldString},
+				{Name: "provider", Type: FieldString},
+				{Name: "template", Type: FieldString},
+				{Name: "templateVariables", Type: FieldObject},
+				{Name: "templateDialect", Type: FieldString, Enum: []string{"simple", "go", "auto"}},
+				{Name: "retry", Type: FieldObject, Description: "Retry policy: maxAttempts, backoff (exponential, linear, fixed), initialDelayMs, maxDelayMs, deadlineMs"},
+				{Name: "maxRetries", Type: FieldNumber, Description: "Retries after the first attempt; retry.maxAttempts takes precedence"},
+			},
+		},
+		{
+			Type:        NodeTypeHTTP,
+			Name:        "HTTP Request",
+			Description: "Calls an HTTP API; the response is available to downstream nodes unless the call is async",
+			Category:    "action",
+			New:         func(deps Dependencies) Executor { return NewHTTPExecutor(deps.HTTPClient) },
+			AsyncOptIn:  true,
+			EventType:   "http.request",
+			Config: []ConfigField{
+				{Name: "url", Type: FieldString, Required: true},
+				{Name: "method", Type: FieldString, Enum: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"}},
+				{Name: "headers", Type: FieldObject},
+				{Name: "body", Type: FieldAny},
+				{Name: "auth", Type: FieldObject},
+				{Name: "signing", Type: FieldObject},
+				{Name: "pagination", Type: FieldObject},
+				{Name: "responseType", Type: FieldString},
+				{Name: "maxResponseSize", Type: FieldNumber},
+				{Name: "acceptedStatusCodes", Type: FieldArray},
+				{Name: "extractHeaders", Type: FieldArray},
+				{Name: "extractCookies", Type: FieldAny},
+				{Name: "async", Type: FieldBoolean, Description: "Send through the outbox with retries; downstream nodes don't get the response"},
+				{Name: "idempotencyHeader", Type: FieldString, Description: "Header carrying the outbox idempotency key on async calls (default Idempotency-Key)"},
+				{Name: "retry", Type: FieldObject, Description: "Retry policy of async calls: maxAttempts, backoff (exponential, linear, fixed), initialDelayMs, maxDelayMs, deadlineMs"},
+			},
+		},
+		{
+			Type:        NodeTypeSlack,
+			Name:        "Slack",
+			Description: "Posts a message to a Slack webhook through the outbox",
+			Category:    "action",
+			New:         func(deps Dependencies) Executor { return NewSlackExecutor(deps.HTTPClient) },
+			Async:       true,
+			EventType:   "slack.send",
+			Config: []ConfigField{
+				{Name: "webhookUrl", Type: FieldString, Required: true},
+				{Name: "channel", Type: FieldString},
+				{Name: "text", Type: FieldString},
+				{Name: "username", Type: FieldString},
+				{Name: "iconUrl", Type: FieldString},
+				{Name: "blocks", Type: FieldArray},
+				{Name: "retry", Type: FieldObject, Description: "Retry policy: maxAttempts, backoff (exponential, linear, fixed), initialDelayMs, maxDelayMs, deadlineMs"},
+				{Name: "maxRetries", Type: FieldNumber, Description: "Retries after the first attempt; retry.maxAttempts takes precedence"},
+			},
+		},
+		{
+			Type:        NodeTypeLoop,
+			Name:        "Loop",
+			Description: "Runs its child nodes once per array item",
+			Category:    "control",
+			New:         func(Dependencies) Executor { return NewLoopExecutor() },
+			Config: []ConfigField{
+				{Name: "arrayPath", Type: FieldString},
+				{Name: "itemVariable", Type: FieldString},
+				{Name: "indexVariable", Type: FieldString},
+				{Name: "max_iterations", Type: FieldNumber},
+				{Name: "iterationDelay", Type: FieldNumber, Description: "Milliseconds between iteration starts"},
+				{Name: "concurrency", Type: FieldNumber, Description: "Iterations running at once (default 1)"},
+				{Name: "batchSize", Type: FieldNumber, Description: "Items per iteration; each iteration gets a chunk (default 1)"},
+				{Name: "errorHandling", Type: FieldString, Enum: []string{"skip", "fail", "threshold"}},
+				{Name: "maxFailureRatio", Type: FieldNumber, Description: "Share of iterations (0-1) allowed to fail with errorHandling 'threshold'"},
+			},
+		},
+		{
+			Type:        NodeTypeLoopAccumulator,
+			Name:        "Loop Accumulator",
+			Description: "Runs its loop-output body per item and collects the results",
+			Category:    "control",
+			New:         func(Dependencies) Executor { return NewLoopAccumulatorExecutor() },
+			Config: []ConfigField{
+				{Name: "arrayPath", Type: FieldString},
+				{Name: "itemVariable", Type: FieldString},
+				{Name: "indexVariable", Type: FieldString},
+				{Name: "accumulatorVariable", Type: FieldString},
+				{Name: "accumulationMode", Type: FieldString, Enum: []string{"array", "last"}},
+				{Name: "errorHandling", Type: FieldString, Enum: []string{"skip", "fail", "threshold"}},
+				{Name: "maxFailureRatio", Type: FieldNumber, Description: "Share of iterations (0-1) allowed to fail with errorHandling 'threshold'"},
+				{Name: "unwrapData", Type: FieldBoolean},
+				{Name: "max_iterations", Type: FieldNumber},
+				{Name: "iterationDelay", Type: FieldNumber, Description: "Milliseconds between iteration starts"},
+				{Name: "concurrency", Type: FieldNumber, Description: "Iterations running at once (default 1)"},
+				{Name: "batchSize", Type: FieldNumber, Description: "Items per iteration; each iteration gets a chunk (default 1)"},
+			},
+		},
+		{
+			Type:        NodeTypeJSON,
+			Name:        "JSON",
+			Description: "Emits static JSON data",
+			Category:    "data",
+			New:         func(Dependencies) Executor { return NewJSONExecutor() },
+			Config: []ConfigField{
+				{Name: "data", Type: FieldAny, Required: true},
+			},
+		},
+		{
+			Type:        NodeTypeJSONArray,
+			Name:        "JSON Array",
+			Description: "Emits a JSON array as the workflow data",
+			Category:    "data",
+			New:         func(Dependencies) Executor { return NewJsonArrayTriggerExecutor() },
+			Config: []ConfigField{
+				{Name: "jsonArray", Type: FieldString, Required: true},
+				{Name: "validateSchema", Type: FieldBoolean},
+			},
+		},
+		{
+			Type:        NodeTypeJSONToCSV,
+			Name:        "JSON to CSV",
+			Description: "Converts an array of objects to CSV",
+			Category:    "data",
+			New:         func(Dependencies) Executor { return NewJSONToCSVExecutor() },
+			Config: []ConfigField{
+				{Name: "data", Type: FieldAny, Description: "Defaults to the node input"},
+			},
+		},
+		{
+			Type:        NodeTypeWasm,
+			Name:        "WASM Plugin",
+			Description: "Runs an uploaded WebAssembly plugin in a sandbox",
+			Category:    "data",
+			New:         func(deps Dependencies) Executor { return NewWasmExecutor(deps.Wasm, deps.HTTPClient) },
+			Config: []ConfigField{
+				{Name: "plugin", Type: FieldString, Required: true, Description: "Name of an uploaded plugin"},
+				{Name: "config", Type: FieldObject, Description: "Passed to the plugin as config (templates are rendered)"},
+			},
+		},
+	}
+}
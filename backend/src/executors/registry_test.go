@@ -0,0 +1,75 @@
+package executors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type billingExecutor struct{}
+
+func (e *billingExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*ExecutionResult, error) {
+	return &ExecutionResult{Success: true, Output: map[string]interface{}{"charged": execCtx.NodeConfig["amount"]}}, nil
+}
+
+func TestRegistry_Builtins(t *testing.T) {
+	for _, nodeType := range AllValidNodeTypes {
+		registered, ok := DefaultRegistry().Lookup(nodeType)
+		assert.True(t, ok, nodeType)
+		assert.True(t, registered.Builtin, nodeType)
+		assert.Equal(t, nodeType != NodeTypeStart && nodeType != NodeTypeEnd, registered.New != nil, nodeType)
+	}
+
+	for _, nodeType := range AsyncNodeTypes {
+		assert.True(t, IsAsyncNode(nodeType), nodeType)
+	}
+	assert.False(t, IsAsyncNode(NodeTypeHTTP))
+	assert.True(t, SupportsAsync(NodeTypeHTTP))
+	assert.False(t, SupportsAsync(NodeTypeTransform))
+	assert.False(t, NodeRequiresOutbox(NodeTypeHTTP, map[string]interface{}{}))
+	assert.True(t, NodeRequiresOutbox(NodeTypeHTTP, map[string]interface{}{"async": true}))
+	assert.False(t, NodeRequiresOutbox(NodeTypeTransform, map[string]interface{}{"async": true}))
+
+	assert.Equal(t, "email.send", EventTypeForNodeType(NodeTypeEmail))
+	assert.Equal(t, "http.request", EventTypeForNodeType(NodeTypeHTTP))
+	assert.Equal(t, "unknown", EventTypeForNodeType(NodeTypeTransform))
+
+	slack, ok := DefaultRegistry().LookupEventType("slack.send")
+	assert.True(t, ok)
+	assert.Equal(t, NodeTypeSlack, slack.Type)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	newBilling := func(Dependencies) Executor { return &billingExecutor{} }
+
+	assert.NoError(t, r.Register(NodeType{Type: "billing-charge", New: newBilling, Async: true}))
+	registered, _ := r.Lookup("billing-charge")
+	assert.Equal(t, "billing-charge.send", registered.EventType, "async types default their event type")
+
+	assert.Error(t, r.Register(NodeType{Type: "billing-charge", New: newBilling}), "duplicate type")
+	assert.Error(t, r.Register(NodeType{Type: "billing-refund", New: newBilling, EventType: "billing-charge.send"}), "duplicate event type")
+	assert.Error(t, r.Register(NodeType{Type: "billing-void", Async: true}), "async without constructor")
+	assert.Error(t, r.Register(NodeType{}))
+}
+
+func TestRegisterNodeType_CustomExecutor(t *testing.T) {
+	err := RegisterNodeType(NodeType{
+		Type:   "test-billing",
+		Name:   "Billing",
+		Config: []ConfigField{{Name: "amount", Type: FieldNumber, Required: true}},
+		New:    func(Dependencies) Executor { return &billingExecutor{} },
+	})
+	assert.NoError(t, err)
+	assert.True(t, IsValidNodeType("test-billing"))
+
+	executor, err := NewExecutorFactory(nil, nil).GetExecutor("test-billing")
+	assert.NoError(t, err)
+	result, err := executor.Execute(context.Background(), ExecutionContext{NodeConfig: map[string]interface{}{"amount": float64(5)}})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(5), result.Output["charged"])
+
+	_, err = NewExecutorFactory(nil, nil).GetExecutor(NodeTypeStart)
+	assert.Error(t, err, "structural nodes have no executor")
+}
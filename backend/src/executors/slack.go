@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/patali/yantra/src/breaker"
+	"github.com/patali/yantra/src/templating"
 )
 
 type SlackExecutor struct {
@@ -31,8 +34,9 @@ func (e *SlackExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (
 	webhookURL, ok := execCtx.NodeConfig["webhookUrl"].(string)
 	if !ok || webhookURL == "" {
 		return &ExecutionResult{
-			Success: false,
-			Error:   "webhookUrl is required",
+			Success:   false,
+			Error:     "webhookUrl is required",
+			Permanent: true,
 		}, nil
 	}
 
@@ -44,7 +48,7 @@ func (e *SlackExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (
 		message.Channel = channel
 	}
 	if text, ok := execCtx.NodeConfig["text"].(string); ok {
-		message.Text = text
+		message.Text = renderTemplate(text, execCtx, templating.EscapeNone)
 	}
 	if username, ok := execCtx.NodeConfig["username"].(string); ok {
 		message.Username = username
@@ -57,7 +61,7 @@ func (e *SlackExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (
 	if blocks, ok := execCtx.NodeConfig["blocks"].([]interface{}); ok {
 		message.Blocks = make([]map[string]interface{}, len(blocks))
 		for i, block := range blocks {
-			if blockMap, ok := block.(map[string]interface{}); ok {
+			if blockMap, ok := renderTemplateValue(block, execCtx, templating.EscapeNone).(map[string]interface{}); ok {
 				message.Blocks[i] = blockMap
 			}
 		}
@@ -67,17 +71,20 @@ func (e *SlackExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (
 	payload, err := json.Marshal(message)
 	if err != nil {
 		return &ExecutionResult{
-			Success: false,
-			Error:   fmt.Sprintf("failed to marshal message: %v", err),
+			Success:   false,
+			Error:     fmt.Sprintf("failed to marshal message: %v", err),
+			Permanent: true,
 		}, nil
 	}
 
 	// Send POST request to Slack webhook
+	ctx = WithEgressAccount(ctx, execCtx.AccountID)
 	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(payload))
 	if err != nil {
 		return &ExecutionResult{
-			Success: false,
-			Error:   fmt.Sprintf("failed to create request: %v", err),
+			Success:   false,
+			Error:     fmt.Sprintf("failed to create request: %v", err),
+			Permanent: true,
 		}, nil
 	}
 
@@ -85,6 +92,20 @@ func (e *SlackExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (
 
 	resp, err := e.httpClient.Do(req)
 	if err != nil {
+		if egressErr, ok := AsEgressDenied(err); ok {
+			return &ExecutionResult{
+				Success:   false,
+				Error:     egressErr.Error(),
+				Permanent: true,
+			}, nil
+		}
+		// Nothing was sent: the outbox holds the message until the breaker lets a probe through
+		if openErr, ok := breaker.AsOpen(err); ok {
+			return &ExecutionResult{
+				Success: false,
+				Error:   openErr.Error(),
+			}, openErr
+		}
 		return &ExecutionResult{
 			Success: false,
 			Error:   fmt.Sprintf("failed to send webhook: %v", err),
@@ -95,8 +116,9 @@ func (e *SlackExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		return &ExecutionResult{
-			Success: false,
-			Error:   fmt.Sprintf("slack webhook returned status %d", resp.StatusCode),
+			Success:   false,
+			Error:     fmt.Sprintf("slack webhook returned status %d", resp.StatusCode),
+			Permanent: IsPermanentStatus(resp.StatusCode),
 		}, nil
 	}
 
@@ -3,6 +3,7 @@ package executors
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -27,6 +28,31 @@ func TestSlackExecutor(t *testing.T) {
 		assert.NoError(t, err)
 		assert.False(t, result.Success)
 		assert.Contains(t, result.Error, "webhookUrl is required")
+		assert.True(t, result.Permanent)
+	})
+
+	t.Run("Rejected and unavailable webhooks", func(t *testing.T) {
+		for status, permanent := range map[int]bool{
+			http.StatusNotFound:            true,
+			http.StatusForbidden:           true,
+			http.StatusTooManyRequests:     false,
+			http.StatusServiceUnavailable:  false,
+			http.StatusInternalServerError: false,
+		} {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+
+			result, err := executor.Execute(context.Background(), ExecutionContext{
+				NodeID:     "slack-node",
+				NodeConfig: map[string]interface{}{"webhookUrl": server.URL, "text": "hi"},
+			})
+			server.Close()
+
+			assert.NoError(t, err)
+			assert.False(t, result.Success)
+			assert.Equal(t, permanent, result.Permanent, "status %d", status)
+		}
 	})
 
 	t.Run("Valid configuration", func(t *testing.T) {
@@ -0,0 +1,66 @@
+package executors
+
+import (
+	"log"
+	"strings"
+
+	"github.com/patali/yantra/src/templating"
+)
+
+// TemplateContext exposes the node input, upstream node outputs, workflow variables
+// and execution metadata to templates and expressions
+func TemplateContext(execCtx ExecutionContext) templating.Context {
+	ctx := templating.Context{
+		Input: execCtx.Input,
+		Execution: templating.Execution{
+			ID:        execCtx.ExecutionID,
+			AccountID: execCtx.AccountID,
+			NodeID:    execCtx.NodeID,
+		},
+	}
+	if outputs, ok := execCtx.WorkflowData["nodeOutputs"].(map[string]interface{}); ok {
+		ctx.NodeOutputs = outputs
+	}
+	if vars, ok := execCtx.WorkflowData["vars"].(map[string]interface{}); ok {
+		ctx.Vars = vars
+	}
+	if metadata, ok := execCtx.WorkflowData["execution"].(map[string]interface{}); ok {
+		ctx.Execution.WorkflowID, _ = metadata["workflowId"].(string)
+		ctx.Execution.TriggerType, _ = metadata["triggerType"].(string)
+	}
+	return ctx
+}
+
+// renderTemplate renders a simple-dialect template for the node, keeping unresolved placeholders
+func renderTemplate(text string, execCtx ExecutionContext, escape templating.Escape) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	rendered, err := templating.RenderSimple(text, TemplateContext(execCtx), escape)
+	if err != nil {
+		log.Printf("⚠️ Template evaluation failed in node %s: %v", execCtx.NodeID, err)
+	}
+	return rendered
+}
+
+// renderTemplateValue renders every string inside a config value (maps and arrays are walked)
+func renderTemplateValue(value interface{}, execCtx ExecutionContext, escape templating.Escape) interface{} {
+	switch v := value.(type) {
+	case string:
+		return renderTemplate(v, execCtx, escape)
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered[key] = renderTemplateValue(item, execCtx, escape)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = renderTemplateValue(item, execCtx, escape)
+		}
+		return rendered
+	default:
+		return value
+	}
+}
@@ -0,0 +1,26 @@
+package executors
+
+import (
+	"testing"
+
+	"github.com/patali/yantra/src/templating"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTemplateContextExecutionMetadata tests that {{execution.*}} renders the node context and workflow data metadata
+func TestTemplateContextExecutionMetadata(t *testing.T) {
+	execCtx := ExecutionContext{
+		NodeID:      "node-1",
+		ExecutionID: "exec-1",
+		AccountID:   "acct-1",
+		WorkflowData: map[string]interface{}{
+			"execution": map[string]interface{}{"workflowId": "wf-1", "triggerType": "webhook"},
+		},
+	}
+
+	rendered, err := templating.RenderSimple(
+		"{{execution.id}}/{{execution.workflowId}}/{{execution.accountId}}/{{execution.nodeId}}/{{execution.triggerType}}",
+		TemplateContext(execCtx), templating.EscapeNone)
+	assert.NoError(t, err)
+	assert.Equal(t, "exec-1/wf-1/acct-1/node-1/webhook", rendered)
+}
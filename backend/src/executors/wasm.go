@@ -0,0 +1,96 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/patali/yantra/src/templating"
+)
+
+// WasmExecutor runs an account's uploaded WASM plugin
+type WasmExecutor struct {
+	runtime    *WasmRuntime
+	httpClient *http.Client
+}
+
+func NewWasmExecutor(runtime *WasmRuntime, client *http.Client) *WasmExecutor {
+	return &WasmExecutor{
+		runtime:    runtime,
+		httpClient: client,
+	}
+}
+
+type wasmPayload struct {
+	Config interface{} `json:"config"`
+	Input  interface{} `json:"input"`
+	NodeID string      `json:"nodeId"`
+}
+
+func (e *WasmExecutor) Execute(ctx context.Context, execCtx ExecutionContext) (*ExecutionResult, error) {
+	pluginName, ok := execCtx.NodeConfig["plugin"].(string)
+	if !ok || pluginName == "" {
+		return &ExecutionResult{
+			Success: false,
+			Error:   "plugin is required",
+		}, nil
+	}
+
+	plugin, err := e.runtime.Load(ctx, execCtx.AccountID, pluginName)
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Sprintf("failed to load plugin %s: %v", pluginName, err),
+		}, nil
+	}
+
+	// The plugin's own settings are templated like any other node config
+	pluginConfig := execCtx.NodeConfig["config"]
+	if pluginConfig == nil {
+		pluginConfig = map[string]interface{}{}
+	}
+	payload, err := json.Marshal(wasmPayload{
+		Config: renderTemplateValue(pluginConfig, execCtx, templating.EscapeNone),
+		Input:  execCtx.Input,
+		NodeID: execCtx.NodeID,
+	})
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Sprintf("failed to encode plugin input: %v", err),
+		}, nil
+	}
+
+	output, err := e.runtime.Run(ctx, plugin, payload, execCtx.AccountID, e.httpClient)
+	if err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return &ExecutionResult{
+			Success: false,
+			Error:   fmt.Sprintf("plugin %s returned invalid JSON: %v", pluginName, err),
+		}, nil
+	}
+	if object, ok := data.(map[string]interface{}); ok {
+		if message, ok := object["error"].(string); ok && message != "" {
+			return &ExecutionResult{
+				Success: false,
+				Error:   fmt.Sprintf("plugin %s: %s", pluginName, message),
+			}, nil
+		}
+	}
+
+	return &ExecutionResult{
+		Success: true,
+		Output: map[string]interface{}{
+			"data":   data,
+			"plugin": pluginName,
+		},
+	}, nil
+}
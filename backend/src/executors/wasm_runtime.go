@@ -0,0 +1,514 @@
+package executors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WASM plugin limits
+const (
+	DefaultWasmMemoryLimitMB = 64
+	DefaultWasmFuel          = 50_000_000 // guest function calls per run
+	DefaultWasmTimeout       = 5 * time.Second
+
+	MaxWasmModuleBytes       = 16 << 20
+	MaxWasmOutputBytes       = 10 << 20
+	maxWasmHTTPResponseBytes = 5 << 20
+	maxWasmLogLineBytes      = 1024
+	wasmCompiledCacheSize    = 64
+	wasmPageSize             = 64 * 1024
+
+	// wasmHostModule is the import module plugins use for host functions
+	wasmHostModule = "yantra"
+)
+
+// WasmLimits bound a single plugin run.
+// Fuel counts guest function calls; tight loops without calls are bounded by Timeout
+type WasmLimits struct {
+	MemoryLimitMB int
+	Fuel          int64
+	Timeout       time.Duration
+}
+
+// DefaultWasmLimits returns the default server-wide maximums
+func DefaultWasmLimits() WasmLimits {
+	return WasmLimits{
+		MemoryLimitMB: DefaultWasmMemoryLimitMB,
+		Fuel:          DefaultWasmFuel,
+		Timeout:       DefaultWasmTimeout,
+	}
+}
+
+// clamp applies max to unset or larger limits
+func (l WasmLimits) clamp(max WasmLimits) WasmLimits {
+	if l.MemoryLimitMB <= 0 || l.MemoryLimitMB > max.MemoryLimitMB {
+		l.MemoryLimitMB = max.MemoryLimitMB
+	}
+	if l.Fuel <= 0 || l.Fuel > max.Fuel {
+		l.Fuel = max.Fuel
+	}
+	if l.Timeout <= 0 || l.Timeout > max.Timeout {
+		l.Timeout = max.Timeout
+	}
+	return l
+}
+
+// WasmPlugin is an uploaded plugin as seen by the runtime (module bytes are fetched separately)
+type WasmPlugin struct {
+	ID        string
+	Name      string
+	Hash      string // hex SHA-256 of the module
+	Limits    WasmLimits
+	AllowHTTP bool // grants the http_request host function
+}
+
+// WasmPluginStore loads account plugins for the wasm node
+type WasmPluginStore interface {
+	GetPlugin(ctx context.Context, accountID, name string) (*WasmPlugin, error)
+	GetModule(ctx context.Context, pluginID string) ([]byte, error)
+}
+
+// WasmRuntime runs plugin modules in a pure-Go sandbox (wazero).
+// Plugins get no filesystem, network, environment or clock access beyond the granted host functions.
+//
+// Plugin ABI:
+//   - export "memory", "alloc(size i32) i32" and "run(ptr i32, len i32) i64"
+//   - run receives {"config", "input", "nodeId"} as JSON and returns the output JSON location
+//     packed as ptr<<32 | len; an object with an "error" string fails the node
+//   - optional imports from "yantra": log(ptr, len) and http_request(ptr, len) i64
+//   - WASI modules are supported; "_initialize" runs if exported
+type WasmRuntime struct {
+	max   WasmLimits
+	store WasmPluginStore
+
+	mu       sync.Mutex
+	runtimes map[uint32]wazero.Runtime // by memory limit in pages
+	compiled map[string]wazero.CompiledModule
+	order    []string // compiled cache insertion order for eviction
+}
+
+// NewWasmRuntime creates a runtime; zero limits fall back to the defaults
+func NewWasmRuntime(max WasmLimits) *WasmRuntime {
+	defaults := DefaultWasmLimits()
+	if max.MemoryLimitMB <= 0 {
+		max.MemoryLimitMB = defaults.MemoryLimitMB
+	}
+	if max.Fuel <= 0 {
+		max.Fuel = defaults.Fuel
+	}
+	if max.Timeout <= 0 {
+		max.Timeout = defaults.Timeout
+	}
+	return &WasmRuntime{
+		max:      max,
+		runtimes: make(map[uint32]wazero.Runtime),
+		compiled: make(map[string]wazero.CompiledModule),
+	}
+}
+
+// SetPluginStore sets where the wasm node loads plugins from
+func (r *WasmRuntime) SetPluginStore(store WasmPluginStore) {
+	r.store = store
+}
+
+// Limits returns the server-wide maximum limits
+func (r *WasmRuntime) Limits() WasmLimits {
+	return r.max
+}
+
+// Load returns an account's plugin by name
+func (r *WasmRuntime) Load(ctx context.Context, accountID, name string) (*WasmPlugin, error) {
+	if r.store == nil {
+		return nil, fmt.Errorf("wasm plugins are not configured")
+	}
+	return r.store.GetPlugin(ctx, accountID, name)
+}
+
+// Validate compiles a module and checks it exports the plugin ABI
+func (r *WasmRuntime) Validate(ctx context.Context, module []byte) error {
+	if len(module) == 0 {
+		return fmt.Errorf("module is empty")
+	}
+	if len(module) > MaxWasmModuleBytes {
+		return fmt.Errorf("module exceeds %d bytes", MaxWasmModuleBytes)
+	}
+
+	rt, err := r.runtime(ctx, r.max.MemoryLimitMB)
+	if err != nil {
+		return err
+	}
+	compiled, err := rt.CompileModule(ctx, module)
+	if err != nil {
+		return fmt.Errorf("invalid wasm module: %w", err)
+	}
+	defer compiled.Close(ctx)
+
+	exports := compiled.ExportedFunctions()
+	for _, name := range []string{"alloc", "run"} {
+		if _, ok := exports[name]; !ok {
+			return fmt.Errorf("module must export a %q function", name)
+		}
+	}
+	if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+		return fmt.Errorf("module must export its memory as \"memory\"")
+	}
+	for _, imported := range compiled.ImportedFunctions() {
+		moduleName, _, _ := imported.Import()
+		if moduleName != wasmHostModule && moduleName != wasi_snapshot_preview1.ModuleName {
+			return fmt.Errorf("module imports unsupported host module %q", moduleName)
+		}
+	}
+	return nil
+}
+
+// Run executes a plugin with a JSON payload and returns its JSON output
+func (r *WasmRuntime) Run(ctx context.Context, plugin *WasmPlugin, payload []byte, accountID string, httpClient *http.Client) ([]byte, error) {
+	limits := plugin.Limits.clamp(r.max)
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+	call := &wasmCall{
+		plugin:     plugin,
+		accountID:  accountID,
+		httpClient: httpClient,
+		cancel:     cancel,
+	}
+	call.fuel.Store(limits.Fuel)
+	ctx = context.WithValue(ctx, wasmCallKey{}, call)
+
+	compiled, rt, err := r.compile(ctx, plugin, limits.MemoryLimitMB)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each run gets a fresh instance: no state leaks between runs or accounts
+	mod, err := rt.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, call.wrapError(ctx, limits, err)
+	}
+	defer mod.Close(context.Background())
+
+	run := mod.ExportedFunction("run")
+	if run == nil {
+		return nil, fmt.Errorf("module does not export run")
+	}
+	ptr, length, err := writeGuest(ctx, mod, payload)
+	if err != nil {
+		return nil, call.wrapError(ctx, limits, err)
+	}
+	results, err := run.Call(ctx, uint64(ptr), uint64(length))
+	if err != nil {
+		return nil, call.wrapError(ctx, limits, err)
+	}
+
+	outPtr, outLen := uint32(results[0]>>32), uint32(results[0])
+	if outLen > MaxWasmOutputBytes {
+		return nil, fmt.Errorf("plugin output exceeds %d bytes", MaxWasmOutputBytes)
+	}
+	output, ok := mod.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("plugin returned an out of range output (ptr=%d, len=%d)", outPtr, outLen)
+	}
+	// Copy before the instance (and its memory) is closed
+	return append([]byte(nil), output...), nil
+}
+
+// Close releases compiled modules and runtimes
+func (r *WasmRuntime) Close(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.runtimes {
+		rt.Close(ctx)
+	}
+	r.runtimes = make(map[uint32]wazero.Runtime)
+	r.compiled = make(map[string]wazero.CompiledModule)
+	r.order = nil
+	return nil
+}
+
+// compile returns the cached compiled module for a plugin, fetching the module bytes on a miss
+func (r *WasmRuntime) compile(ctx context.Context, plugin *WasmPlugin, memoryLimitMB int) (wazero.CompiledModule, wazero.Runtime, error) {
+	rt, err := r.runtime(ctx, memoryLimitMB)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := fmt.Sprintf("%d:%s", memoryLimitMB, plugin.Hash)
+
+	r.mu.Lock()
+	compiled, ok := r.compiled[key]
+	r.mu.Unlock()
+	if ok && plugin.Hash != "" {
+		return compiled, rt, nil
+	}
+
+	if r.store == nil {
+		return nil, nil, fmt.Errorf("wasm plugins are not configured")
+	}
+	module, err := r.store.GetModule(ctx, plugin.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load plugin %s: %w", plugin.Name, err)
+	}
+	// Key by the bytes actually loaded in case the module was replaced since the plugin was looked up
+	key = fmt.Sprintf("%d:%s", memoryLimitMB, WasmModuleHash(module))
+	return r.compileModule(ctx, rt, key, module)
+}
+
+func (r *WasmRuntime) compileModule(ctx context.Context, rt wazero.Runtime, key string, module []byte) (wazero.CompiledModule, wazero.Runtime, error) {
+	// Fuel is metered by a listener compiled into every guest function
+	compiled, err := rt.CompileModule(experimental.WithFunctionListenerFactory(ctx, fuelListenerFactory{}), module)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid wasm module: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.compiled[key]; ok {
+		compiled.Close(ctx)
+		return existing, rt, nil
+	}
+	r.compiled[key] = compiled
+	r.order = append(r.order, key)
+	for len(r.order) > wasmCompiledCacheSize {
+		// Instances created from an evicted module keep running; Close only drops the compiled code
+		r.compiled[r.order[0]].Close(context.Background())
+		delete(r.compiled, r.order[0])
+		r.order = r.order[1:]
+	}
+	return compiled, rt, nil
+}
+
+// runtime returns the wazero runtime for a memory limit, creating it with the host modules on first use
+func (r *WasmRuntime) runtime(ctx context.Context, memoryLimitMB int) (wazero.Runtime, error) {
+	pages := uint32(memoryLimitMB * 1024 * 1024 / wasmPageSize)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rt, ok := r.runtimes[pages]; ok {
+		return rt, nil
+	}
+
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(pages).
+		WithCloseOnContextDone(true))
+
+	// WASI without any preopened directories, environment or real clocks
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	if _, err := rt.NewHostModuleBuilder(wasmHostModule).
+		NewFunctionBuilder().WithFunc(wasmHostLog).Export("log").
+		NewFunctionBuilder().WithFunc(wasmHostHTTPRequest).Export("http_request").
+		Instantiate(ctx); err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate host functions: %w", err)
+	}
+
+	r.runtimes[pages] = rt
+	return rt, nil
+}
+
+// WasmModuleHash returns the hex SHA-256 used to identify compiled modules
+func WasmModuleHash(module []byte) string {
+	sum := sha256.Sum256(module)
+	return hex.EncodeToString(sum[:])
+}
+
+// wasmCall is the per-run state host functions and the fuel meter read from the context
+type wasmCall struct {
+	plugin     *WasmPlugin
+	accountID  string
+	httpClient *http.Client
+	cancel     context.CancelFunc
+
+	fuel          atomic.Int64
+	fuelExhausted atomic.Bool
+}
+
+type wasmCallKey struct{}
+
+func wasmCallFromContext(ctx context.Context) *wasmCall {
+	call, _ := ctx.Value(wasmCallKey{}).(*wasmCall)
+	return call
+}
+
+// wrapError explains why a run stopped
+func (c *wasmCall) wrapError(ctx context.Context, limits WasmLimits, err error) error {
+	switch {
+	case c.fuelExhausted.Load():
+		return fmt.Errorf("plugin %s exceeded its fuel limit (%d calls)", c.plugin.Name, limits.Fuel)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("plugin %s exceeded its time limit (%s)", c.plugin.Name, limits.Timeout)
+	case ctx.Err() != nil:
+		return ctx.Err()
+	}
+	return fmt.Errorf("plugin %s failed: %w", c.plugin.Name, err)
+}
+
+// fuelListenerFactory charges one unit of fuel per guest function call
+type fuelListenerFactory struct{}
+
+func (fuelListenerFactory) NewFunctionListener(api.FunctionDefinition) experimental.FunctionListener {
+	return fuelListener{}
+}
+
+type fuelListener struct{}
+
+func (fuelListener) Before(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	call := wasmCallFromContext(ctx)
+	if call == nil {
+		return
+	}
+	if call.fuel.Add(-1) < 0 && !call.fuelExhausted.Swap(true) {
+		// Closes the module at its next call or loop iteration (WithCloseOnContextDone)
+		call.cancel()
+	}
+}
+
+func (fuelListener) After(context.Context, api.Module, api.FunctionDefinition, []uint64) {}
+
+func (fuelListener) Abort(context.Context, api.Module, api.FunctionDefinition, error) {}
+
+// writeGuest copies data into guest memory allocated by the module's alloc export
+func writeGuest(ctx context.Context, mod api.Module, data []byte) (uint32, uint32, error) {
+	alloc := mod.ExportedFunction("alloc")
+	if alloc == nil {
+		return 0, 0, fmt.Errorf("module does not export alloc")
+	}
+	results, err := alloc.Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, 0, err
+	}
+	ptr := uint32(results[0])
+	if !mod.Memory().Write(ptr, data) {
+		return 0, 0, fmt.Errorf("alloc returned an out of range pointer (ptr=%d, len=%d)", ptr, len(data))
+	}
+	return ptr, uint32(len(data)), nil
+}
+
+// writeGuestJSON writes a host function result and returns it packed as ptr<<32 | len
+func writeGuestJSON(ctx context.Context, mod api.Module, value interface{}) uint64 {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data = []byte(`{"error":"failed to encode host response"}`)
+	}
+	ptr, length, err := writeGuest(ctx, mod, data)
+	if err != nil {
+		// A broken alloc is a guest bug; returning 0 lets the plugin detect it
+		return 0
+	}
+	return uint64(ptr)<<32 | uint64(length)
+}
+
+// wasmHostLog implements yantra.log(ptr, len)
+func wasmHostLog(ctx context.Context, mod api.Module, ptr, length uint32) {
+	if length > maxWasmLogLineBytes {
+		length = maxWasmLogLineBytes
+	}
+	message, ok := mod.Memory().Read(ptr, length)
+	if !ok {
+		return
+	}
+	name := "unknown"
+	if call := wasmCallFromContext(ctx); call != nil {
+		name = call.plugin.Name
+	}
+	log.Printf("🧩 [wasm:%s] %s", name, strings.TrimSpace(string(message)))
+}
+
+type wasmHTTPRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// wasmHostHTTPRequest implements yantra.http_request(ptr, len) i64.
+// SECURITY: Only granted plugins may call out, and only through the egress-filtered client
+func wasmHostHTTPRequest(ctx context.Context, mod api.Module, ptr, length uint32) uint64 {
+	call := wasmCallFromContext(ctx)
+	if call == nil || !call.plugin.AllowHTTP || call.httpClient == nil {
+		return writeGuestJSON(ctx, mod, map[string]interface{}{"error": "http access is not granted to this plugin"})
+	}
+
+	raw, ok := mod.Memory().Read(ptr, length)
+	if !ok {
+		return writeGuestJSON(ctx, mod, map[string]interface{}{"error": "request is out of range"})
+	}
+	var request wasmHTTPRequest
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return writeGuestJSON(ctx, mod, map[string]interface{}{"error": fmt.Sprintf("invalid request: %v", err)})
+	}
+	if request.Method == "" {
+		request.Method = http.MethodGet
+	}
+
+	req, err := http.NewRequestWithContext(WithEgressAccount(ctx, call.accountID), strings.ToUpper(request.Method), request.URL, strings.NewReader(request.Body))
+	if err != nil {
+		return writeGuestJSON(ctx, mod, map[string]interface{}{"error": err.Error()})
+	}
+	for key, value := range request.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := call.httpClient.Do(req)
+	if err != nil {
+		return writeGuestJSON(ctx, mod, map[string]interface{}{"error": err.Error()})
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxWasmHTTPResponseBytes+1))
+	if err != nil {
+		return writeGuestJSON(ctx, mod, map[string]interface{}{"error": err.Error()})
+	}
+	if len(body) > maxWasmHTTPResponseBytes {
+		return writeGuestJSON(ctx, mod, map[string]interface{}{"error": fmt.Sprintf("response exceeds %d bytes", maxWasmHTTPResponseBytes)})
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	for key := range resp.Header {
+		headers[key] = resp.Header.Get(key)
+	}
+	return writeGuestJSON(ctx, mod, map[string]interface{}{
+		"status":  resp.StatusCode,
+		"headers": headers,
+		"body":    string(body),
+	})
+}
+
+var (
+	defaultWasmRuntime   = NewWasmRuntime(DefaultWasmLimits())
+	defaultWasmRuntimeMu sync.RWMutex
+)
+
+// SetDefaultWasmRuntime installs the runtime used by executor factories created afterwards
+func SetDefaultWasmRuntime(r *WasmRuntime) {
+	defaultWasmRuntimeMu.Lock()
+	defer defaultWasmRuntimeMu.Unlock()
+	defaultWasmRuntime = r
+}
+
+// DefaultWasmRuntime returns the process-wide plugin runtime
+func DefaultWasmRuntime() *WasmRuntime {
+	defaultWasmRuntimeMu.RLock()
+	defer defaultWasmRuntimeMu.RUnlock()
+	return defaultWasmRuntime
+}
@@ -0,0 +1,129 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Hand-assembled run bodies for testWasmModule
+var (
+	// run returns its input unchanged: ptr<<32 | len
+	wasmEchoBody = []byte{0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b}
+	// run calls an empty function forever
+	wasmCallLoopBody = []byte{0x00, 0x03, 0x40, 0x10, 0x02, 0x0c, 0x00, 0x0b, 0x42, 0x00, 0x0b}
+	// run spins without calling anything
+	wasmSpinBody = []byte{0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x42, 0x00, 0x0b}
+)
+
+// testWasmModule builds a plugin exporting memory, a bump allocator alloc, and run with the given body
+func testWasmModule(runBody []byte) []byte {
+	section := func(id byte, contents ...byte) []byte {
+		return append([]byte{id, byte(len(contents))}, contents...)
+	}
+	allocBody := []byte{0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b}
+	nopBody := []byte{0x00, 0x0b}
+
+	code := []byte{0x03}
+	for _, body := range [][]byte{allocBody, runBody, nopBody} {
+		code = append(code, byte(len(body)))
+		code = append(code, body...)
+	}
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, 0x03,
+		0x60, 0x01, 0x7f, 0x01, 0x7f, // (i32) -> i32
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e, // (i32, i32) -> i64
+		0x60, 0x00, 0x00)...) // () -> ()
+	module = append(module, section(3, 0x03, 0x00, 0x01, 0x02)...)
+	module = append(module, section(5, 0x01, 0x00, 0x01)...)
+	module = append(module, section(6, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b)...) // mut i32 = 1024
+	module = append(module, section(7, 0x03,
+		0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+		0x05, 'a', 'l', 'l', 'o', 'c', 0x00, 0x00,
+		0x03, 'r', 'u', 'n', 0x00, 0x01)...)
+	module = append(module, section(10, code...)...)
+	return module
+}
+
+type testWasmStore map[string][]byte
+
+func (s testWasmStore) GetPlugin(ctx context.Context, accountID, name string) (*WasmPlugin, error) {
+	module, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("plugin not found")
+	}
+	return &WasmPlugin{ID: name, Name: name, Hash: WasmModuleHash(module)}, nil
+}
+
+func (s testWasmStore) GetModule(ctx context.Context, pluginID string) ([]byte, error) {
+	return s[pluginID], nil
+}
+
+func TestWasmRuntime_Validate(t *testing.T) {
+	runtime := NewWasmRuntime(WasmLimits{})
+	defer runtime.Close(context.Background())
+
+	assert.NoError(t, runtime.Validate(context.Background(), testWasmModule(wasmEchoBody)))
+	assert.Error(t, runtime.Validate(context.Background(), []byte("not wasm")))
+	assert.Error(t, runtime.Validate(context.Background(), nil))
+}
+
+func TestWasmExecutor(t *testing.T) {
+	runtime := NewWasmRuntime(WasmLimits{Timeout: 2 * time.Second, Fuel: 10_000})
+	defer runtime.Close(context.Background())
+	runtime.SetPluginStore(testWasmStore{
+		"echo":      testWasmModule(wasmEchoBody),
+		"call-loop": testWasmModule(wasmCallLoopBody),
+		"spin":      testWasmModule(wasmSpinBody),
+	})
+	executor := NewWasmExecutor(runtime, nil)
+
+	t.Run("config and input round trip as JSON", func(t *testing.T) {
+		result, err := executor.Execute(context.Background(), ExecutionContext{
+			NodeID:     "plugin-1",
+			NodeConfig: map[string]interface{}{"plugin": "echo", "config": map[string]interface{}{"greeting": "hi {{input.name}}"}},
+			Input:      map[string]interface{}{"name": "Ada"},
+		})
+		assert.NoError(t, err)
+		assert.True(t, result.Success, result.Error)
+		assert.Equal(t, map[string]interface{}{
+			"config": map[string]interface{}{"greeting": "hi Ada"},
+			"input":  map[string]interface{}{"name": "Ada"},
+			"nodeId": "plugin-1",
+		}, result.Output["data"])
+		assert.Equal(t, "echo", result.Output["plugin"])
+	})
+
+	t.Run("plugin is required", func(t *testing.T) {
+		result, _ := executor.Execute(context.Background(), ExecutionContext{NodeConfig: map[string]interface{}{}})
+		assert.False(t, result.Success)
+		assert.Equal(t, "plugin is required", result.Error)
+	})
+
+	t.Run("fuel limit", func(t *testing.T) {
+		result, _ := executor.Execute(context.Background(), ExecutionContext{NodeConfig: map[string]interface{}{"plugin": "call-loop"}})
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "fuel limit")
+	})
+
+	t.Run("time limit", func(t *testing.T) {
+		runtime.max.Timeout = 100 * time.Millisecond
+		defer func() { runtime.max.Timeout = 2 * time.Second }()
+
+		started := time.Now()
+		result, _ := executor.Execute(context.Background(), ExecutionContext{NodeConfig: map[string]interface{}{"plugin": "spin"}})
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "time limit")
+		assert.Less(t, time.Since(started), time.Second)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		result, _ := executor.Execute(context.Background(), ExecutionContext{NodeConfig: map[string]interface{}{"plugin": "missing"}})
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "plugin not found")
+	})
+}
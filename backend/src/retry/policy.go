@@ -0,0 +1,202 @@
+// Package retry describes how outbox deliveries are retried.
+//
+// A Policy sets the attempt budget, the backoff curve between attempts, a cap on the delay and
+// an optional deadline after which no more attempts are made. Policies are layered: the built-in
+// Default, then the account default for the node type, then the node's own "retry" config. Unset
+// (zero) fields fall through to the layer below.
+package retry
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Backoff curves
+const (
+	BackoffExponential = "exponential" // initialDelay * 2^(attempt-1)
+	BackoffLinear      = "linear"      // initialDelay * attempt
+	BackoffFixed       = "fixed"       // initialDelay
+)
+
+const (
+	// MaxAttemptsLimit caps the attempt budget of any policy
+	MaxAttemptsLimit = 25
+	// MaxDelayLimit caps the wait between two attempts
+	MaxDelayLimit = 7 * 24 * time.Hour
+)
+
+// Policy controls the retries of one outbox message. Zero fields are unset and inherited
+type Policy struct {
+	MaxAttempts    int    `json:"maxAttempts,omitempty"`
+	Backoff        string `json:"backoff,omitempty"`
+	InitialDelayMs int64  `json:"initialDelayMs,omitempty"`
+	MaxDelayMs     int64  `json:"maxDelayMs,omitempty"`
+	DeadlineMs     int64  `json:"deadlineMs,omitempty"` // no attempt starts later than this after the message was queued
+}
+
+// Default is the policy used when neither the account nor the node sets one:
+// 4 attempts, 2 minutes doubling per attempt, at most an hour apart, no deadline
+func Default() Policy {
+	return Policy{
+		MaxAttempts:    4,
+		Backoff:        BackoffExponential,
+		InitialDelayMs: (2 * time.Minute).Milliseconds(),
+		MaxDelayMs:     time.Hour.Milliseconds(),
+	}
+}
+
+// Merge returns p with its unset fields taken from fallback
+func (p Policy) Merge(fallback Policy) Policy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = fallback.MaxAttempts
+	}
+	if p.Backoff == "" {
+		p.Backoff = fallback.Backoff
+	}
+	if p.InitialDelayMs == 0 {
+		p.InitialDelayMs = fallback.InitialDelayMs
+	}
+	if p.MaxDelayMs == 0 {
+		p.MaxDelayMs = fallback.MaxDelayMs
+	}
+	if p.DeadlineMs == 0 {
+		p.DeadlineMs = fallback.DeadlineMs
+	}
+	return p
+}
+
+// Validate reports the first field outside its allowed range; unset fields are valid
+func (p Policy) Validate() error {
+	if p.MaxAttempts < 0 || p.MaxAttempts > MaxAttemptsLimit {
+		return fmt.Errorf("maxAttempts must be between 1 and %d", MaxAttemptsLimit)
+	}
+	switch p.Backoff {
+	case "", BackoffExponential, BackoffLinear, BackoffFixed:
+	default:
+		return fmt.Errorf("backoff must be '%s', '%s' or '%s'", BackoffExponential, BackoffLinear, BackoffFixed)
+	}
+	if p.InitialDelayMs < 0 || p.InitialDelayMs > MaxDelayLimit.Milliseconds() {
+		return fmt.Errorf("initialDelayMs must be between 0 and %d", MaxDelayLimit.Milliseconds())
+	}
+	if p.MaxDelayMs < 0 || p.MaxDelayMs > MaxDelayLimit.Milliseconds() {
+		return fmt.Errorf("maxDelayMs must be between 0 and %d", MaxDelayLimit.Milliseconds())
+	}
+	if p.MaxDelayMs > 0 && p.InitialDelayMs > p.MaxDelayMs {
+		return fmt.Errorf("initialDelayMs must not exceed maxDelayMs")
+	}
+	if p.DeadlineMs < 0 {
+		return fmt.Errorf("deadlineMs must not be negative")
+	}
+	return nil
+}
+
+// FromConfig reads the policy set by a node's config: its "retry" object, plus the older
+// "maxRetries" key, which sets the attempt budget when retry.maxAttempts is absent
+func FromConfig(config map[string]interface{}) (Policy, error) {
+	var p Policy
+
+	if raw, ok := config["retry"]; ok && raw != nil {
+		retryConfig, ok := raw.(map[string]interface{})
+		if !ok {
+			return p, fmt.Errorf("retry must be an object")
+		}
+
+		var err error
+		if p.MaxAttempts, err = intField(retryConfig, "maxAttempts"); err != nil {
+			return p, err
+		}
+		if backoff, ok := retryConfig["backoff"]; ok && backoff != nil {
+			if p.Backoff, ok = backoff.(string); !ok {
+				return p, fmt.Errorf("backoff must be a string")
+			}
+		}
+		if p.InitialDelayMs, err = int64Field(retryConfig, "initialDelayMs"); err != nil {
+			return p, err
+		}
+		if p.MaxDelayMs, err = int64Field(retryConfig, "maxDelayMs"); err != nil {
+			return p, err
+		}
+		if p.DeadlineMs, err = int64Field(retryConfig, "deadlineMs"); err != nil {
+			return p, err
+		}
+	}
+
+	if p.MaxAttempts == 0 {
+		if maxRetries, ok := config["maxRetries"].(float64); ok {
+			// maxRetries counts retries after the first attempt
+			p.MaxAttempts = int(math.Max(0, math.Min(maxRetries, MaxAttemptsLimit-1))) + 1
+		}
+	}
+
+	return p, p.Validate()
+}
+
+func int64Field(config map[string]interface{}, key string) (int64, error) {
+	raw, ok := config[key]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	value, ok := raw.(float64)
+	if !ok || value != math.Trunc(value) {
+		return 0, fmt.Errorf("%s must be a whole number", key)
+	}
+	return int64(value), nil
+}
+
+func intField(config map[string]interface{}, key string) (int, error) {
+	value, err := int64Field(config, key)
+	return int(value), err
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based) before the next one
+func (p Policy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	initial := time.Duration(p.InitialDelayMs) * time.Millisecond
+	maxDelay := time.Duration(p.MaxDelayMs) * time.Millisecond
+	if maxDelay <= 0 || maxDelay > MaxDelayLimit {
+		maxDelay = MaxDelayLimit
+	}
+
+	var delay time.Duration
+	switch p.Backoff {
+	case BackoffFixed:
+		delay = initial
+	case BackoffLinear:
+		delay = initial * time.Duration(attempt)
+		if attempt > 0 && delay/time.Duration(attempt) != initial {
+			delay = maxDelay // overflow
+		}
+	default:
+		if attempt > 40 {
+			delay = maxDelay
+		} else {
+			delay = initial << uint(attempt-1)
+			if delay>>uint(attempt-1) != initial {
+				delay = maxDelay // overflow
+			}
+		}
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// Exhausted reports whether the given attempt is the last one: the budget is spent, or the next
+// attempt would start after the deadline counted from queuedAt
+func (p Policy) Exhausted(attempt int, queuedAt, now time.Time) bool {
+	if attempt >= p.MaxAttempts {
+		return true
+	}
+	if p.DeadlineMs > 0 {
+		deadline := queuedAt.Add(time.Duration(p.DeadlineMs) * time.Millisecond)
+		if now.Add(p.Delay(attempt)).After(deadline) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	exponential := Policy{Backoff: BackoffExponential, InitialDelayMs: 1000, MaxDelayMs: 10000}
+	assert.Equal(t, time.Second, exponential.Delay(1))
+	assert.Equal(t, 2*time.Second, exponential.Delay(2))
+	assert.Equal(t, 8*time.Second, exponential.Delay(4))
+	assert.Equal(t, 10*time.Second, exponential.Delay(5), "capped at maxDelay")
+	assert.Equal(t, 10*time.Second, exponential.Delay(200), "large attempts do not overflow")
+
+	linear := Policy{Backoff: BackoffLinear, InitialDelayMs: 1000}
+	assert.Equal(t, 3*time.Second, linear.Delay(3))
+
+	fixed := Policy{Backoff: BackoffFixed, InitialDelayMs: 500}
+	assert.Equal(t, 500*time.Millisecond, fixed.Delay(7))
+
+	assert.Equal(t, 2*time.Minute, Default().Delay(1))
+	assert.Equal(t, time.Hour, Default().Delay(10))
+}
+
+func TestPolicy_Merge(t *testing.T) {
+	account := Policy{MaxAttempts: 10, Backoff: BackoffLinear}
+	node := Policy{MaxAttempts: 2, DeadlineMs: 60000}
+
+	merged := node.Merge(account.Merge(Default()))
+	assert.Equal(t, Policy{
+		MaxAttempts:    2,
+		Backoff:        BackoffLinear,
+		InitialDelayMs: Default().InitialDelayMs,
+		MaxDelayMs:     Default().MaxDelayMs,
+		DeadlineMs:     60000,
+	}, merged)
+}
+
+func TestFromConfig(t *testing.T) {
+	p, err := FromConfig(map[string]interface{}{
+		"retry": map[string]interface{}{
+			"maxAttempts":    float64(6),
+			"backoff":        "fixed",
+			"initialDelayMs": float64(5000),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Policy{MaxAttempts: 6, Backoff: BackoffFixed, InitialDelayMs: 5000}, p)
+
+	p, err = FromConfig(map[string]interface{}{"maxRetries": float64(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, p.MaxAttempts, "maxRetries counts retries after the first attempt")
+
+	p, err = FromConfig(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, Policy{}, p)
+
+	_, err = FromConfig(map[string]interface{}{"retry": map[string]interface{}{"backoff": "random"}})
+	assert.Error(t, err)
+
+	_, err = FromConfig(map[string]interface{}{"retry": map[string]interface{}{"maxAttempts": float64(100)}})
+	assert.Error(t, err)
+
+	_, err = FromConfig(map[string]interface{}{"retry": map[string]interface{}{"initialDelayMs": float64(5000), "maxDelayMs": float64(1000)}})
+	assert.Error(t, err)
+
+	_, err = FromConfig(map[string]interface{}{"retry": "often"})
+	assert.Error(t, err)
+}
+
+func TestPolicy_Exhausted(t *testing.T) {
+	queuedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := Policy{MaxAttempts: 5, Backoff: BackoffFixed, InitialDelayMs: time.Minute.Milliseconds(), DeadlineMs: (10 * time.Minute).Milliseconds()}
+
+	assert.False(t, p.Exhausted(1, queuedAt, queuedAt.Add(time.Minute)))
+	assert.True(t, p.Exhausted(5, queuedAt, queuedAt.Add(time.Minute)), "attempt budget spent")
+	assert.True(t, p.Exhausted(2, queuedAt, queuedAt.Add(9*time.Minute+30*time.Second)), "next attempt would start after the deadline")
+}
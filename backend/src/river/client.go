@@ -17,8 +17,8 @@ type Client struct {
 	pgxpool     *pgxpool.Pool
 }
 
-// NewClient creates a new River client
-func NewClient(ctx context.Context, databaseURL string, engine WorkflowEngine) (*Client, error) {
+// NewClient creates a new River client. outboxWorkers bounds how many outbox deliveries run at once
+func NewClient(ctx context.Context, databaseURL string, engine WorkflowEngine, deliverer OutboxDeliverer, outboxWorkers int) (*Client, error) {
 	// Create pgx connection pool
 	pool, err := pgxpool.New(ctx, databaseURL)
 	if err != nil {
@@ -32,11 +32,15 @@ func NewClient(ctx context.Context, databaseURL string, engine WorkflowEngine) (
 	workflowWorker := NewWorkflowExecutionWorker(engine)
 	river.AddWorker(workers, workflowWorker)
 
+	// Register outbox delivery worker
+	river.AddWorker(workers, NewOutboxDeliveryWorker(deliverer))
+
 	// Create River client
 	riverClient, err := river.NewClient(riverpgxv5.New(pool), &river.Config{
 		Queues: map[string]river.QueueConfig{
 			river.QueueDefault: {MaxWorkers: 10},
 			"workflow":         {MaxWorkers: 5},
+			QueueOutbox:        {MaxWorkers: outboxWorkers},
 		},
 		Workers: workers,
 	})
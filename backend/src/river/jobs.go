@@ -2,9 +2,12 @@ package river
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/patali/yantra/src/retry"
 	"github.com/riverqueue/river"
 )
 
@@ -68,3 +71,61 @@ func (p *PeriodicWorkflowJob) GetPeriodicHandle() string {
 	// Use compact workflow ID (remove hyphens) similar to PG-Boss approach
 	return fmt.Sprintf("wfs_%s", p.WorkflowID)
 }
+
+// QueueOutbox is the queue outbox deliveries run on
+const QueueOutbox = "outbox"
+
+// OutboxDeliveryArgs defines the job arguments for delivering an outbox message.
+// The job is inserted in the same transaction as the message, so River owns its retries and discard
+type OutboxDeliveryArgs struct {
+	MessageID string       `json:"message_id"`
+	Retry     retry.Policy `json:"retry"` // Backoff and deadline between attempts; the job's MaxAttempts is the budget
+}
+
+// Kind returns the job type identifier
+func (OutboxDeliveryArgs) Kind() string {
+	return "outbox_delivery"
+}
+
+// InsertOpts routes outbox deliveries to their own queue
+func (OutboxDeliveryArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{Queue: QueueOutbox}
+}
+
+// OutboxDeliverer delivers outbox messages; implemented by the outbox worker service
+type OutboxDeliverer interface {
+	// DeliverOutboxMessage runs one delivery attempt. final is set when no retry will follow a failure:
+	// the message must then be dead lettered. A returned error makes River retry the job unless final
+	DeliverOutboxMessage(ctx context.Context, messageID string, attempt int, final bool) error
+}
+
+// OutboxDeliveryWorker implements the River worker for outbox deliveries
+type OutboxDeliveryWorker struct {
+	river.WorkerDefaults[OutboxDeliveryArgs]
+	deliverer OutboxDeliverer
+}
+
+// NewOutboxDeliveryWorker creates a new outbox delivery worker
+func NewOutboxDeliveryWorker(deliverer OutboxDeliverer) *OutboxDeliveryWorker {
+	return &OutboxDeliveryWorker{
+		deliverer: deliverer,
+	}
+}
+
+// Work delivers the outbox message
+func (w *OutboxDeliveryWorker) Work(ctx context.Context, job *river.Job[OutboxDeliveryArgs]) error {
+	policy := job.Args.Retry.Merge(retry.Default())
+	final := job.Attempt >= job.MaxAttempts || policy.Exhausted(job.Attempt, job.CreatedAt, time.Now())
+
+	err := w.deliverer.DeliverOutboxMessage(ctx, job.Args.MessageID, job.Attempt, final)
+	if err != nil && final && job.Attempt < job.MaxAttempts && !errors.Is(err, &river.JobSnoozeError{}) {
+		// Past the retry deadline: stop even though attempts remain
+		return river.JobCancel(err)
+	}
+	return err
+}
+
+// NextRetry schedules the next attempt with the message's backoff
+func (w *OutboxDeliveryWorker) NextRetry(job *river.Job[OutboxDeliveryArgs]) time.Time {
+	return time.Now().Add(job.Args.Retry.Merge(retry.Default()).Delay(job.Attempt))
+}
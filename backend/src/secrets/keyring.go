@@ -0,0 +1,278 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Envelope format for encrypted values stored in the database:
+//
+//	enc:v1:<keyID>:<base64 wrapped data key>:<base64 nonce+ciphertext>
+//
+// Each value is encrypted with its own random data key (AES-256-GCM), and the
+// data key is wrapped with a master key. Rotating the master key only requires
+// re-wrapping, and values written under older keys stay readable as long as
+// those keys remain in the keyring.
+const (
+	envelopePrefix = "enc:v1:"
+	dataKeySize    = 32
+)
+
+// MaskedValue is returned in API responses in place of stored secrets
+const MaskedValue = "********"
+
+// DevKeyID is the ID of the development key derived from JWT_SECRET when no master key is configured
+const DevKeyID = "dev"
+
+// Keyring holds the master keys used for envelope encryption
+// New values are always encrypted with the primary key; all keys can decrypt
+type Keyring struct {
+	primaryID string
+	keys      map[string][]byte
+}
+
+// NewKeyring creates a keyring with a primary key and optional previous keys (for rotation)
+func NewKeyring(primaryID string, primaryKey []byte, previous map[string][]byte) (*Keyring, error) {
+	if primaryID == "" {
+		return nil, fmt.Errorf("primary key ID is required")
+	}
+	if strings.Contains(primaryID, ":") {
+		return nil, fmt.Errorf("key ID %q must not contain ':'", primaryID)
+	}
+	if len(primaryKey) != 32 {
+		return nil, fmt.Errorf("master key %q must be 32 bytes, got %d", primaryID, len(primaryKey))
+	}
+
+	keys := map[string][]byte{primaryID: primaryKey}
+	for id, key := range previous {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid previous key ID %q", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("master key %q must be 32 bytes, got %d", id, len(key))
+		}
+		if _, exists := keys[id]; exists {
+			continue // Primary wins if an ID is listed twice
+		}
+		keys[id] = key
+	}
+
+	return &Keyring{primaryID: primaryID, keys: keys}, nil
+}
+
+// ParseKeyring builds a keyring from configuration values
+// primaryKey is base64-encoded; previousKeys is a comma-separated list of "id:base64key"
+func ParseKeyring(primaryID, primaryKey, previousKeys string) (*Keyring, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(primaryKey))
+	if err != nil {
+		return nil, fmt.Errorf("master key is not valid base64: %w", err)
+	}
+
+	previous := make(map[string][]byte)
+	for _, entry := range strings.Split(previousKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("previous key entry must be in the form id:base64key")
+		}
+		prevKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("previous key %q is not valid base64: %w", parts[0], err)
+		}
+		previous[strings.TrimSpace(parts[0])] = prevKey
+	}
+
+	return NewKeyring(primaryID, key, previous)
+}
+
+// WithPreviousKey returns a copy of the keyring that can also decrypt values under key id
+// A key already in the keyring under id is kept
+func (k *Keyring) WithPreviousKey(id string, key []byte) (*Keyring, error) {
+	previous := make(map[string][]byte, len(k.keys))
+	for existingID, existingKey := range k.keys {
+		if existingID != k.primaryID {
+			previous[existingID] = existingKey
+		}
+	}
+	if _, exists := k.keys[id]; !exists {
+		previous[id] = key
+	}
+	return NewKeyring(k.primaryID, k.keys[k.primaryID], previous)
+}
+
+// DeriveKey derives a 32-byte key from an arbitrary passphrase
+// Only intended for development fallbacks; production should use a random key
+func DeriveKey(passphrase string) []byte {
+	sum := sha256.Sum256([]byte("yantra-secrets:" + passphrase))
+	return sum[:]
+}
+
+// PrimaryKeyID returns the ID of the key used for new encryptions
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primaryID
+}
+
+// Encrypt encrypts a plaintext value with a fresh data key wrapped by the primary key
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	wrappedKey, err := seal(k.keys[k.primaryID], dataKey, []byte(k.primaryID))
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, []byte(plaintext), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt value: %w", err)
+	}
+
+	return envelopePrefix + k.primaryID + ":" +
+		base64.StdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt decrypts an envelope-encrypted value
+// Values without the envelope prefix are treated as legacy plaintext and returned unchanged
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	keyID, wrappedKey, ciphertext, err := parseEnvelope(value)
+	if err != nil {
+		return "", err
+	}
+
+	masterKey, ok := k.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("unknown master key %q", keyID)
+	}
+
+	dataKey, err := open(masterKey, wrappedKey, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	plaintext, err := open(dataKey, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsReencryption reports whether a stored value is plaintext or was encrypted with a non-primary key
+func (k *Keyring) NeedsReencryption(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !IsEncrypted(value) {
+		return true
+	}
+	keyID, _, _, err := parseEnvelope(value)
+	if err != nil {
+		return false // Corrupt values are left alone rather than overwritten
+	}
+	return keyID != k.primaryID
+}
+
+// IsEncrypted reports whether a value is in envelope format
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// IsMasked reports whether a value is the placeholder returned by the API
+// Clients echo it back when a secret was not changed
+func IsMasked(value string) bool {
+	return value == MaskedValue
+}
+
+func parseEnvelope(value string) (keyID string, wrappedKey, ciphertext []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, envelopePrefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, fmt.Errorf("malformed encrypted value")
+	}
+
+	wrappedKey, err = base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("malformed wrapped key: %w", err)
+	}
+	ciphertext, err = base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("malformed ciphertext: %w", err)
+	}
+
+	return parts[0], wrappedKey, ciphertext, nil
+}
+
+// seal encrypts with AES-GCM and prepends the nonce
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a nonce-prefixed AES-GCM ciphertext
+func open(key, data, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var (
+	defaultKeyring *Keyring
+	defaultMu      sync.RWMutex
+)
+
+// SetDefault installs the process-wide keyring (called once at startup)
+func SetDefault(k *Keyring) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultKeyring = k
+}
+
+// Default returns the process-wide keyring
+func Default() (*Keyring, error) {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if defaultKeyring == nil {
+		return nil, fmt.Errorf("secrets keyring is not configured")
+	}
+	return defaultKeyring, nil
+}
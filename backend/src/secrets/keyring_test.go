@@ -0,0 +1,135 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestKeyring_EncryptDecryptRoundTrip(t *testing.T) {
+	keyring, err := NewKeyring("k1", testKey(1), nil)
+	assert.NoError(t, err)
+
+	encrypted, err := keyring.Encrypt("re_super_secret")
+	assert.NoError(t, err)
+
+	assert.True(t, IsEncrypted(encrypted))
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v1:k1:"))
+	assert.NotContains(t, encrypted, "re_super_secret")
+
+	decrypted, err := keyring.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "re_super_secret", decrypted)
+}
+
+func TestKeyring_EncryptUsesFreshDataKey(t *testing.T) {
+	keyring, err := NewKeyring("k1", testKey(1), nil)
+	assert.NoError(t, err)
+
+	first, err := keyring.Encrypt("same")
+	assert.NoError(t, err)
+	second, err := keyring.Encrypt("same")
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+}
+
+func TestKeyring_DecryptPlaintextPassthrough(t *testing.T) {
+	keyring, err := NewKeyring("k1", testKey(1), nil)
+	assert.NoError(t, err)
+
+	decrypted, err := keyring.Decrypt("legacy-plaintext")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy-plaintext", decrypted)
+	assert.True(t, keyring.NeedsReencryption("legacy-plaintext"))
+	assert.False(t, keyring.NeedsReencryption(""))
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKeyring, err := NewKeyring("old", testKey(1), nil)
+	assert.NoError(t, err)
+
+	encrypted, err := oldKeyring.Encrypt("smtp-password")
+	assert.NoError(t, err)
+
+	rotated, err := NewKeyring("new", testKey(2), map[string][]byte{"old": testKey(1)})
+	assert.NoError(t, err)
+
+	// Old values remain readable and are flagged for re-encryption
+	decrypted, err := rotated.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "smtp-password", decrypted)
+	assert.True(t, rotated.NeedsReencryption(encrypted))
+
+	reencrypted, err := rotated.Encrypt(decrypted)
+	assert.NoError(t, err)
+	assert.False(t, rotated.NeedsReencryption(reencrypted))
+
+	// Once the old key is dropped, values under it can no longer be read
+	newOnly, err := NewKeyring("new", testKey(2), nil)
+	assert.NoError(t, err)
+	_, err = newOnly.Decrypt(encrypted)
+	assert.Error(t, err)
+}
+
+func TestKeyring_WithPreviousKey(t *testing.T) {
+	devKeyring, err := NewKeyring("dev", testKey(1), nil)
+	assert.NoError(t, err)
+	encrypted, err := devKeyring.Encrypt("smtp-password")
+	assert.NoError(t, err)
+
+	keyring, err := NewKeyring("primary", testKey(2), map[string][]byte{"old": testKey(3)})
+	assert.NoError(t, err)
+	withDev, err := keyring.WithPreviousKey("dev", testKey(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "primary", withDev.PrimaryKeyID())
+
+	decrypted, err := withDev.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "smtp-password", decrypted)
+	assert.True(t, withDev.NeedsReencryption(encrypted))
+
+	// The original keyring is unchanged, and configured keys win over the added one
+	_, err = keyring.Decrypt(encrypted)
+	assert.Error(t, err)
+	kept, err := withDev.WithPreviousKey("old", testKey(4))
+	assert.NoError(t, err)
+	assert.Equal(t, testKey(3), kept.keys["old"])
+}
+
+func TestKeyring_TamperedCiphertextFails(t *testing.T) {
+	keyring, err := NewKeyring("k1", testKey(1), nil)
+	assert.NoError(t, err)
+
+	encrypted, err := keyring.Encrypt("secret")
+	assert.NoError(t, err)
+
+	// Swapping the key ID must not allow decryption under a different key
+	tampered := strings.Replace(encrypted, "enc:v1:k1:", "enc:v1:k2:", 1)
+	other, err := NewKeyring("k2", testKey(1), nil)
+	assert.NoError(t, err)
+	_, err = other.Decrypt(tampered)
+	assert.Error(t, err)
+}
+
+func TestParseKeyring(t *testing.T) {
+	primary := base64.StdEncoding.EncodeToString(testKey(3))
+	previous := "old:" + base64.StdEncoding.EncodeToString(testKey(4))
+
+	keyring, err := ParseKeyring("current", primary, previous)
+	assert.NoError(t, err)
+	assert.Equal(t, "current", keyring.PrimaryKeyID())
+
+	_, err = ParseKeyring("current", base64.StdEncoding.EncodeToString([]byte("short")), "")
+	assert.Error(t, err)
+
+	_, err = ParseKeyring("current", primary, "missing-separator")
+	assert.Error(t, err)
+}
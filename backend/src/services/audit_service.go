@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/patali/yantra/src/db/models"
+	"gorm.io/gorm"
+)
+
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// GetAuditLogs returns an account's audit log, newest first, optionally for a single resource
+func (s *AuditService) GetAuditLogs(ctx context.Context, accountID, resourceType, resourceID string, limit, offset int) ([]models.AuditLog, error) {
+	query := s.db.WithContext(ctx).Where("account_id = ?", accountID)
+	if resourceType != "" {
+		query = query.Where("resource_type = ?", resourceType)
+	}
+	if resourceID != "" {
+		query = query.Where("resource_id = ?", resourceID)
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch audit log: %w", err)
+	}
+	return logs, nil
+}
+
+// newMessageAuditLog builds an audit entry for an outbox message; details are stored as JSON
+func newMessageAuditLog(accountID, userID, action, messageID string, details map[string]interface{}) *models.AuditLog {
+	entry := &models.AuditLog{
+		AccountID:    accountID,
+		Action:       action,
+		ResourceType: "outbox_message",
+		ResourceID:   messageID,
+	}
+	if userID != "" {
+		entry.UserID = &userID
+	}
+	if len(details) > 0 {
+		if data, err := json.Marshal(details); err == nil {
+			detailsJSON := string(data)
+			entry.Details = &detailsJSON
+		}
+	}
+	return entry
+}
@@ -150,36 +150,6 @@ func (s *CleanupService) FixStuckExecutions(ctx context.Context) error {
 	return nil
 }
 
-// FixOrphanedOutboxMessages finds outbox messages whose node executions or workflows don't exist
-func (s *CleanupService) FixOrphanedOutboxMessages(ctx context.Context) error {
-	log.Println("🧹 Starting cleanup: Checking for orphaned outbox messages...")
-
-	// Find messages with non-existent node executions
-	orphanedCount, err := s.repo.Outbox().CountOrphanedMessages(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to count orphaned messages: %w", err)
-	}
-
-	if orphanedCount > 0 {
-		log.Printf("⚠️  Found %d orphaned outbox messages, marking as dead_letter", orphanedCount)
-
-		err = s.repo.Outbox().UpdateOrphanedMessages(ctx, map[string]interface{}{
-			"status":     "dead_letter",
-			"last_error": "Node execution not found (orphaned message)",
-		})
-
-		if err != nil {
-			return fmt.Errorf("failed to update orphaned messages: %w", err)
-		}
-
-		log.Printf("✅ Marked %d orphaned messages as dead_letter", orphanedCount)
-	} else {
-		log.Println("✅ No orphaned outbox messages found")
-	}
-
-	return nil
-}
-
 // RunAllCleanups runs all cleanup routines
 func (s *CleanupService) RunAllCleanups(ctx context.Context) error {
 	log.Println("🧹 Starting all cleanup routines...")
@@ -188,10 +158,6 @@ func (s *CleanupService) RunAllCleanups(ctx context.Context) error {
 		log.Printf("⚠️  Error in FixStuckExecutions: %v", err)
 	}
 
-	if err := s.FixOrphanedOutboxMessages(ctx); err != nil {
-		log.Printf("⚠️  Error in FixOrphanedOutboxMessages: %v", err)
-	}
-
 	log.Println("🧹 All cleanup routines completed")
 	return nil
 }
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/executors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxEgressHostRules caps each account host list
+const MaxEgressHostRules = 200
+
+type EgressPolicyService struct {
+	db *gorm.DB
+}
+
+func NewEgressPolicyService(db *gorm.DB) *EgressPolicyService {
+	return &EgressPolicyService{db: db}
+}
+
+// GetRules returns the account's egress rules (empty lists if none are configured)
+// Also used as the executors.EgressRulesLoader
+func (s *EgressPolicyService) GetRules(ctx context.Context, accountID string) (*executors.EgressRules, error) {
+	var policy models.AccountEgressPolicy
+	err := s.db.WithContext(ctx).Where("account_id = ?", accountID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &executors.EgressRules{AllowedHosts: []string{}, DeniedHosts: []string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load egress policy: %w", err)
+	}
+
+	rules := &executors.EgressRules{AllowedHosts: []string{}, DeniedHosts: []string{}}
+	if err := json.Unmarshal([]byte(policy.AllowedHosts), &rules.AllowedHosts); err != nil {
+		return nil, fmt.Errorf("invalid allowed hosts: %w", err)
+	}
+	if err := json.Unmarshal([]byte(policy.DeniedHosts), &rules.DeniedHosts); err != nil {
+		return nil, fmt.Errorf("invalid denied hosts: %w", err)
+	}
+	return rules, nil
+}
+
+// UpdateRules validates and stores the account's egress rules
+func (s *EgressPolicyService) UpdateRules(ctx context.Context, accountID string, rules executors.EgressRules) (*executors.EgressRules, error) {
+	allowed, err := validateHostPatterns(rules.AllowedHosts)
+	if err != nil {
+		return nil, fmt.Errorf("allowedHosts: %w", err)
+	}
+	denied, err := validateHostPatterns(rules.DeniedHosts)
+	if err != nil {
+		return nil, fmt.Errorf("deniedHosts: %w", err)
+	}
+
+	allowedJSON, _ := json.Marshal(allowed)
+	deniedJSON, _ := json.Marshal(denied)
+
+	policy := models.AccountEgressPolicy{
+		AccountID:    accountID,
+		AllowedHosts: string(allowedJSON),
+		DeniedHosts:  string(deniedJSON),
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "account_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"allowed_hosts", "denied_hosts", "updated_at"}),
+	}).Create(&policy).Error; err != nil {
+		return nil, fmt.Errorf("failed to save egress policy: %w", err)
+	}
+
+	executors.DefaultEgressPolicy().InvalidateAccount(accountID)
+
+	return &executors.EgressRules{AllowedHosts: allowed, DeniedHosts: denied}, nil
+}
+
+// validateHostPatterns accepts hostnames, IPs and "*.example.com" wildcards
+func validateHostPatterns(patterns []string) ([]string, error) {
+	if len(patterns) > MaxEgressHostRules {
+		return nil, fmt.Errorf("at most %d entries are allowed", MaxEgressHostRules)
+	}
+
+	result := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if strings.Contains(pattern, "://") || strings.ContainsAny(pattern, "/ :?#@") {
+			return nil, fmt.Errorf("%q must be a hostname without scheme, port or path", pattern)
+		}
+		if strings.Contains(strings.TrimPrefix(pattern, "*."), "*") {
+			return nil, fmt.Errorf("%q: only a leading '*.' wildcard is supported", pattern)
+		}
+		result = append(result, pattern)
+	}
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/secrets"
+	"gorm.io/gorm"
+)
+
+// emailProviderSecretFields maps the encrypted-at-rest columns to their model fields
+func emailProviderSecretFields(p *models.EmailProviderSettings) map[string]**string {
+	return map[string]**string{
+		"api_key":           &p.APIKey,
+		"secret_access_key": &p.SecretAccessKey,
+		"smtp_password":     &p.SMTPPassword,
+	}
+}
+
+// EncryptSecret encrypts an optional credential with the default keyring (nil stays nil)
+func EncryptSecret(value *string) (*string, error) {
+	if value == nil || *value == "" || secrets.IsEncrypted(*value) {
+		return value, nil
+	}
+
+	keyring, err := secrets.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := keyring.Encrypt(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &encrypted, nil
+}
+
+// EncryptEmailProviderSecrets encrypts the credential fields of a provider in place
+func EncryptEmailProviderSecrets(p *models.EmailProviderSettings) error {
+	for column, field := range emailProviderSecretFields(p) {
+		encrypted, err := EncryptSecret(*field)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", column, err)
+		}
+		*field = encrypted
+	}
+	return nil
+}
+
+// DecryptEmailProviderSecrets decrypts the credential fields of a provider in place
+// Legacy plaintext values are returned unchanged
+func DecryptEmailProviderSecrets(p *models.EmailProviderSettings) error {
+	var keyring *secrets.Keyring
+	for column, field := range emailProviderSecretFields(p) {
+		if *field == nil || !secrets.IsEncrypted(**field) {
+			continue
+		}
+
+		if keyring == nil {
+			var err error
+			if keyring, err = secrets.Default(); err != nil {
+				return err
+			}
+		}
+
+		plaintext, err := keyring.Decrypt(**field)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", column, err)
+		}
+		*field = &plaintext
+	}
+	return nil
+}
+
+// MaskEmailProviderSecrets returns a copy of the provider with credentials replaced by a placeholder
+// SECURITY: Always use this before returning provider settings from the API
+func MaskEmailProviderSecrets(p models.EmailProviderSettings) models.EmailProviderSettings {
+	for _, field := range emailProviderSecretFields(&p) {
+		if *field != nil && **field != "" {
+			masked := secrets.MaskedValue
+			*field = &masked
+		}
+	}
+	return p
+}
+
+// MigrateEmailProviderSecrets encrypts plaintext credentials and re-encrypts values
+// written under retired master keys. Safe to run on every startup.
+// Returns the number of provider rows updated.
+func MigrateEmailProviderSecrets(ctx context.Context, db *gorm.DB) (int, error) {
+	keyring, err := secrets.Default()
+	if err != nil {
+		return 0, err
+	}
+
+	var providers []models.EmailProviderSettings
+	if err := db.WithContext(ctx).Find(&providers).Error; err != nil {
+		return 0, fmt.Errorf("failed to load email providers: %w", err)
+	}
+
+	updated := 0
+	for i := range providers {
+		provider := &providers[i]
+		updates := make(map[string]interface{})
+
+		for column, field := range emailProviderSecretFields(provider) {
+			if *field == nil || !keyring.NeedsReencryption(**field) {
+				continue
+			}
+
+			plaintext, err := keyring.Decrypt(**field)
+			if err != nil {
+				// Leave the value untouched; an operator may still need to add the retired key
+				log.Printf("⚠️  Cannot decrypt %s for email provider %s: %v", column, provider.ID, err)
+				continue
+			}
+
+			encrypted, err := keyring.Encrypt(plaintext)
+			if err != nil {
+				return updated, fmt.Errorf("failed to encrypt %s for email provider %s: %w", column, provider.ID, err)
+			}
+			updates[column] = encrypted
+		}
+
+		if len(updates) == 0 {
+			continue
+		}
+
+		if err := db.WithContext(ctx).Model(&models.EmailProviderSettings{}).
+			Where("id = ?", provider.ID).
+			Updates(updates).Error; err != nil {
+			return updated, fmt.Errorf("failed to update email provider %s: %w", provider.ID, err)
+		}
+		updated++
+	}
+
+	return updated, nil
+}
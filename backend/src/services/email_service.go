@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"log"
 	"net/smtp"
-	"regexp"
+	"net/textproto"
 	"strings"
 	"sync"
 
@@ -15,10 +17,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/ses"
 	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/mailgun/mailgun-go/v4"
+	"github.com/patali/yantra/src/breaker"
 	"github.com/patali/yantra/src/db/models"
 	"github.com/patali/yantra/src/db/repositories"
 	"github.com/patali/yantra/src/executors"
+	"github.com/patali/yantra/src/templating"
 	"github.com/resend/resend-go/v2"
 	"gorm.io/gorm"
 )
@@ -101,6 +107,9 @@ func (s *EmailService) GetActiveProvider(accountID string) (*models.EmailProvide
 	if err != nil {
 		return nil, err
 	}
+	if err := DecryptEmailProviderSecrets(settings); err != nil {
+		return nil, err
+	}
 	return settings, nil
 }
 
@@ -114,6 +123,9 @@ func (s *EmailService) GetProviderByType(accountID string, provider EmailProvide
 	if settings == nil {
 		return nil, fmt.Errorf("provider %s not configured", provider)
 	}
+	if err := DecryptEmailProviderSecrets(settings); err != nil {
+		return nil, err
+	}
 	return settings, nil
 }
 
@@ -141,7 +153,24 @@ func (s *EmailService) SendEmail(ctx context.Context, accountID string, options
 		options.HTML = s.RenderTemplate(options.Template, options.TemplateVariables)
 	}
 
-	// Route to appropriate provider
+	// Breakers are per account: one account's broken credentials don't pause another account's mail
+	key := breaker.Key{AccountID: accountID, Destination: breaker.EmailDestination(providerConfig.Provider)}
+	breakers := breaker.Default()
+	if err := breakers.Allow(key); err != nil {
+		return &executors.EmailResult{Success: false, Error: err.Error()}, err
+	}
+
+	result, err := s.send(ctx, options, providerConfig)
+	if err != nil && (result == nil || !result.Permanent) {
+		breakers.Failure(key, err)
+	} else {
+		breakers.Success(key)
+	}
+	return result, err
+}
+
+// send routes an email to the provider's API
+func (s *EmailService) send(ctx context.Context, options executors.EmailOptions, providerConfig *models.EmailProviderSettings) (*executors.EmailResult, error) {
 	switch EmailProvider(providerConfig.Provider) {
 	case ProviderResend:
 		return s.sendViaResend(ctx, options, providerConfig)
@@ -153,16 +182,58 @@ func (s *EmailService) SendEmail(ctx context.Context, accountID string, options
 		return s.sendViaSMTP(ctx, options, providerConfig)
 	default:
 		return &executors.EmailResult{
-			Success: false,
-			Error:   fmt.Sprintf("unknown email provider: %s", providerConfig.Provider),
+			Success:   false,
+			Error:     fmt.Sprintf("unknown email provider: %s", providerConfig.Provider),
+			Permanent: true,
 		}, fmt.Errorf("unknown provider")
 	}
 }
 
+// emailFailure builds the result of a failed provider call
+func emailFailure(err error) *executors.EmailResult {
+	return &executors.EmailResult{
+		Success:   false,
+		Error:     err.Error(),
+		Permanent: isPermanentEmailError(err),
+	}
+}
+
+// isPermanentEmailError reports whether the provider rejected the email itself (a 4xx response or a 5xx SMTP reply),
+// as opposed to an outage or rate limit a retry can get past. Resend doesn't expose the status of other errors,
+// so those count as transient
+func isPermanentEmailError(err error) bool {
+	var missingFields *resend.MissingRequiredFieldsError
+	if errors.As(err, &missingFields) {
+		return true
+	}
+
+	var mailgunErr *mailgun.UnexpectedResponseError
+	if errors.As(err, &mailgunErr) {
+		return executors.IsPermanentStatus(mailgunErr.Actual)
+	}
+
+	// SES reports throttling as a 400
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && strings.Contains(apiErr.ErrorCode(), "Throttl") {
+		return false
+	}
+	var responseErr *smithyhttp.ResponseError
+	if errors.As(err, &responseErr) {
+		return executors.IsPermanentStatus(responseErr.HTTPStatusCode())
+	}
+
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 500
+	}
+
+	return false
+}
+
 // sendViaResend sends email via Resend API
 func (s *EmailService) sendViaResend(_ctx context.Context, options executors.EmailOptions, config *models.EmailProviderSettings) (*executors.EmailResult, error) {
 	if config.APIKey == nil || *config.APIKey == "" {
-		return &executors.EmailResult{Success: false, Error: "Resend API key not configured"}, fmt.Errorf("API key missing")
+		return &executors.EmailResult{Success: false, Error: "Resend API key not configured", Permanent: true}, fmt.Errorf("API key missing")
 	}
 
 	// Get cached Resend client
@@ -195,7 +266,7 @@ func (s *EmailService) sendViaResend(_ctx context.Context, options executors.Ema
 	} else if options.Text != "" {
 		params.Text = options.Text
 	} else {
-		return &executors.EmailResult{Success: false, Error: "email must have either text or HTML content"}, fmt.Errorf("missing content")
+		return &executors.EmailResult{Success: false, Error: "email must have either text or HTML content", Permanent: true}, fmt.Errorf("missing content")
 	}
 
 	// Add attachments if any
@@ -213,10 +284,7 @@ func (s *EmailService) sendViaResend(_ctx context.Context, options executors.Ema
 	// Send email
 	sent, err := client.Emails.Send(params)
 	if err != nil {
-		return &executors.EmailResult{
-			Success: false,
-			Error:   err.Error(),
-		}, err
+		return emailFailure(err), err
 	}
 
 	return &executors.EmailResult{
@@ -228,7 +296,7 @@ func (s *EmailService) sendViaResend(_ctx context.Context, options executors.Ema
 // sendViaMailgun sends email via Mailgun API
 func (s *EmailService) sendViaMailgun(ctx context.Context, options executors.EmailOptions, config *models.EmailProviderSettings) (*executors.EmailResult, error) {
 	if config.APIKey == nil || *config.APIKey == "" || config.Domain == nil || *config.Domain == "" {
-		return &executors.EmailResult{Success: false, Error: "Mailgun API key and domain required"}, fmt.Errorf("missing configuration")
+		return &executors.EmailResult{Success: false, Error: "Mailgun API key and domain required", Permanent: true}, fmt.Errorf("missing configuration")
 	}
 
 	mg := s.getMailgunClient(*config.Domain, *config.APIKey)
@@ -265,10 +333,7 @@ func (s *EmailService) sendViaMailgun(ctx context.Context, options executors.Ema
 
 	_, id, err := mg.Send(ctx, message)
 	if err != nil {
-		return &executors.EmailResult{
-			Success: false,
-			Error:   err.Error(),
-		}, err
+		return emailFailure(err), err
 	}
 
 	return &executors.EmailResult{
@@ -280,7 +345,7 @@ func (s *EmailService) sendViaMailgun(ctx context.Context, options executors.Ema
 // sendViaSES sends email via AWS SES
 func (s *EmailService) sendViaSES(ctx context.Context, options executors.EmailOptions, providerConfig *models.EmailProviderSettings) (*executors.EmailResult, error) {
 	if providerConfig.AccessKeyID == nil || providerConfig.SecretAccessKey == nil || providerConfig.Region == nil {
-		return &executors.EmailResult{Success: false, Error: "AWS SES requires accessKeyId, secretAccessKey, and region"}, fmt.Errorf("missing configuration")
+		return &executors.EmailResult{Success: false, Error: "AWS SES requires accessKeyId, secretAccessKey, and region", Permanent: true}, fmt.Errorf("missing configuration")
 	}
 
 	// Get cached SES client
@@ -326,12 +391,12 @@ func (s *EmailService) sendViaSES(ctx context.Context, options executors.EmailOp
 	}
 
 	if options.HTML == "" && options.Text == "" {
-		return &executors.EmailResult{Success: false, Error: "email must have either text or HTML content"}, fmt.Errorf("missing content")
+		return &executors.EmailResult{Success: false, Error: "email must have either text or HTML content", Permanent: true}, fmt.Errorf("missing content")
 	}
 
 	result, err := client.SendEmail(ctx, input)
 	if err != nil {
-		return &executors.EmailResult{Success: false, Error: err.Error()}, err
+		return emailFailure(err), err
 	}
 
 	return &executors.EmailResult{
@@ -343,7 +408,7 @@ func (s *EmailService) sendViaSES(ctx context.Context, options executors.EmailOp
 // sendViaSMTP sends email via SMTP
 func (s *EmailService) sendViaSMTP(ctx context.Context, options executors.EmailOptions, config *models.EmailProviderSettings) (*executors.EmailResult, error) {
 	if config.SMTPHost == nil || config.SMTPPort == nil || config.SMTPUser == nil || config.SMTPPassword == nil {
-		return &executors.EmailResult{Success: false, Error: "SMTP requires host, port, user, and password"}, fmt.Errorf("missing configuration")
+		return &executors.EmailResult{Success: false, Error: "SMTP requires host, port, user, and password", Permanent: true}, fmt.Errorf("missing configuration")
 	}
 
 	from := s.buildFromAddress(config)
@@ -412,7 +477,7 @@ func (s *EmailService) sendViaSMTP(ctx context.Context, options executors.EmailO
 	addr := fmt.Sprintf("%s:%d", *config.SMTPHost, *config.SMTPPort)
 	err := smtp.SendMail(addr, auth, *config.SMTPUser, recipients, buf.Bytes())
 	if err != nil {
-		return &executors.EmailResult{Success: false, Error: err.Error()}, err
+		return emailFailure(err), err
 	}
 
 	return &executors.EmailResult{
@@ -452,13 +517,11 @@ func (s *EmailService) TestProviderToEmail(ctx context.Context, accountID string
 	}
 }
 
-// RenderTemplate renders a template with variables
+// RenderTemplate renders an HTML email template with variables using the shared template language
 func (s *EmailService) RenderTemplate(template string, variables map[string]interface{}) string {
-	rendered := template
-	for key, value := range variables {
-		pattern := fmt.Sprintf(`{{\s*%s\s*}}`, regexp.QuoteMeta(key))
-		re := regexp.MustCompile(pattern)
-		rendered = re.ReplaceAllString(rendered, fmt.Sprintf("%v", value))
+	rendered, err := templating.Render(template, templating.DialectAuto, templating.Context{Input: variables}, templating.EscapeHTML)
+	if err != nil {
+		log.Printf("❌ Email template rendering failed: %v", err)
 	}
 	return rendered
 }
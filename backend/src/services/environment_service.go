@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/workflows"
+	"gorm.io/gorm"
+)
+
+// MaxEnvironmentVariables caps the variables of one environment
+const MaxEnvironmentVariables = 200
+
+// ErrEnvironmentNotFound is returned when an execution names an environment the account doesn't have
+var ErrEnvironmentNotFound = errors.New("environment not found")
+
+// environmentNamePattern keeps environment names usable in query strings and logs
+var environmentNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// EnvironmentService manages account environments and resolves the variables an execution sees
+type EnvironmentService struct {
+	db *gorm.DB
+}
+
+func NewEnvironmentService(db *gorm.DB) *EnvironmentService {
+	return &EnvironmentService{db: db}
+}
+
+// ListEnvironments returns the account's environments
+func (s *EnvironmentService) ListEnvironments(ctx context.Context, accountID string) ([]dto.EnvironmentResponse, error) {
+	var environments []models.Environment
+	if err := s.db.WithContext(ctx).Where("account_id = ?", accountID).Order("name").Find(&environments).Error; err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	response := make([]dto.EnvironmentResponse, 0, len(environments))
+	for i := range environments {
+		env, err := toEnvironmentResponse(&environments[i])
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *env)
+	}
+	return response, nil
+}
+
+// CreateEnvironment creates an environment; making it the default unsets the previous default
+func (s *EnvironmentService) CreateEnvironment(ctx context.Context, accountID string, req dto.CreateEnvironmentRequest) (*dto.EnvironmentResponse, error) {
+	if !environmentNamePattern.MatchString(req.Name) {
+		return nil, fmt.Errorf("name must be 1-63 lowercase letters, digits, '-' or '_'")
+	}
+	variablesJSON, err := encodeEnvironmentVariables(req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	env := models.Environment{
+		AccountID: accountID,
+		Name:      req.Name,
+		IsDefault: req.IsDefault,
+		Variables: variablesJSON,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		tx.Model(&models.Environment{}).Where("account_id = ? AND name = ?", accountID, req.Name).Count(&existing)
+		if existing > 0 {
+			return fmt.Errorf("an environment named %s already exists", req.Name)
+		}
+		if env.IsDefault {
+			if err := clearDefaultEnvironment(tx, accountID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&env).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEnvironmentResponse(&env)
+}
+
+// UpdateEnvironment renames an environment, replaces its variables or changes the default.
+// Executions are pinned by name, so a rename also detaches queued or resumable executions
+func (s *EnvironmentService) UpdateEnvironment(ctx context.Context, accountID, id string, req dto.UpdateEnvironmentRequest) (*dto.EnvironmentResponse, error) {
+	var env models.Environment
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND account_id = ?", id, accountID).First(&env).Error; err != nil {
+			return fmt.Errorf("environment not found: %w", err)
+		}
+
+		updates := map[string]interface{}{}
+		if req.Name != nil && *req.Name != env.Name {
+			if !environmentNamePattern.MatchString(*req.Name) {
+				return fmt.Errorf("name must be 1-63 lowercase letters, digits, '-' or '_'")
+			}
+			var existing int64
+			tx.Model(&models.Environment{}).Where("account_id = ? AND name = ?", accountID, *req.Name).Count(&existing)
+			if existing > 0 {
+				return fmt.Errorf("an environment named %s already exists", *req.Name)
+			}
+			updates["name"] = *req.Name
+		}
+		if req.Variables != nil {
+			variablesJSON, err := encodeEnvironmentVariables(req.Variables)
+			if err != nil {
+				return err
+			}
+			updates["variables"] = variablesJSON
+		}
+		if req.IsDefault != nil {
+			if *req.IsDefault && !env.IsDefault {
+				if err := clearDefaultEnvironment(tx, accountID); err != nil {
+					return err
+				}
+			}
+			updates["is_default"] = *req.IsDefault
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(&env).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update environment: %w", err)
+		}
+		return tx.First(&env, "id = ?", id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEnvironmentResponse(&env)
+}
+
+// DeleteEnvironment deletes an environment.
+// Executions already pinned to it fail when resumed, since their variables can no longer be resolved
+func (s *EnvironmentService) DeleteEnvironment(ctx context.Context, accountID, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND account_id = ?", id, accountID).Delete(&models.Environment{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete environment: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("environment not found: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// ResolveEnvironment finds the environment an execution runs in: the named one, or the account default
+// when name is empty. Returns nil (and no error) when no name is given and the account has no default
+func (s *EnvironmentService) ResolveEnvironment(ctx context.Context, accountID, name string) (*models.Environment, error) {
+	var env models.Environment
+	query := s.db.WithContext(ctx).Where("account_id = ?", accountID)
+	if name != "" {
+		query = query.Where("name = ?", name)
+	} else {
+		query = query.Where("is_default = ?", true)
+	}
+
+	err := query.First(&env).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if name != "" {
+			return nil, fmt.Errorf("%w: %s", ErrEnvironmentNotFound, name)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environment: %w", err)
+	}
+	return &env, nil
+}
+
+// ResolveVariables returns the {{vars}} of an execution: the workflow's variables with the
+// environment's overrides on top. environment is the name pinned on the execution (nil = account default)
+func (s *EnvironmentService) ResolveVariables(ctx context.Context, accountID *string, environment *string, workflowVariables map[string]interface{}) (map[string]interface{}, error) {
+	if accountID == nil {
+		return workflows.MergeVariables(workflowVariables, nil), nil
+	}
+
+	name := ""
+	if environment != nil {
+		name = *environment
+	}
+	env, err := s.ResolveEnvironment(ctx, *accountID, name)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		return workflows.MergeVariables(workflowVariables, nil), nil
+	}
+
+	overrides, err := decodeEnvironmentVariables(env.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("environment %s: %w", env.Name, err)
+	}
+	return workflows.MergeVariables(workflowVariables, overrides), nil
+}
+
+func clearDefaultEnvironment(tx *gorm.DB, accountID string) error {
+	if err := tx.Model(&models.Environment{}).
+		Where("account_id = ? AND is_default = ?", accountID, true).
+		Update("is_default", false).Error; err != nil {
+		return fmt.Errorf("failed to update default environment: %w", err)
+	}
+	return nil
+}
+
+func encodeEnvironmentVariables(variables map[string]interface{}) (string, error) {
+	if len(variables) > MaxEnvironmentVariables {
+		return "", fmt.Errorf("at most %d variables are allowed", MaxEnvironmentVariables)
+	}
+	for name := range variables {
+		if !workflows.ValidVariableName(name) {
+			return "", fmt.Errorf("variable '%s' must start with a letter or underscore and contain only letters, digits and underscores", name)
+		}
+	}
+	if variables == nil {
+		variables = map[string]interface{}{}
+	}
+	data, err := json.Marshal(variables)
+	if err != nil {
+		return "", fmt.Errorf("invalid variables: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeEnvironmentVariables(data string) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+	if data == "" {
+		return variables, nil
+	}
+	if err := json.Unmarshal([]byte(data), &variables); err != nil {
+		return nil, fmt.Errorf("invalid variables: %w", err)
+	}
+	return variables, nil
+}
+
+func toEnvironmentResponse(env *models.Environment) (*dto.EnvironmentResponse, error) {
+	variables, err := decodeEnvironmentVariables(env.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("environment %s: %w", env.Name, err)
+	}
+	return &dto.EnvironmentResponse{
+		ID:        env.ID,
+		Name:      env.Name,
+		IsDefault: env.IsDefault,
+		Variables: variables,
+		CreatedAt: env.CreatedAt,
+		UpdatedAt: env.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
+	"github.com/patali/yantra/src/templating"
+	"github.com/patali/yantra/src/workflows"
+	"gorm.io/gorm"
+)
+
+// DialectExpression evaluates the source as a condition expression (conditional nodes)
+const DialectExpression = "expression"
+
+var (
+	// ErrExpressionSourceNotFound is returned when the referenced execution or node input does not exist
+	ErrExpressionSourceNotFound = errors.New("execution or node not found")
+	// ErrInvalidExpressionRequest wraps request validation errors
+	ErrInvalidExpressionRequest = errors.New("invalid expression request")
+)
+
+// ExpressionService evaluates expressions and templates for authoring
+// It builds the same ExecutionContext the engine does and evaluates through the executors' context builders
+type ExpressionService struct {
+	db *gorm.DB
+}
+
+func NewExpressionService(db *gorm.DB) *ExpressionService {
+	return &ExpressionService{db: db}
+}
+
+// Evaluate evaluates the request's source against a sample or past-execution context
+func (s *ExpressionService) Evaluate(ctx context.Context, accountID string, req dto.EvaluateExpressionRequest) (*dto.EvaluateExpressionResponse, error) {
+	execCtx := executors.ExecutionContext{
+		NodeID:       req.NodeID,
+		AccountID:    accountID,
+		WorkflowData: map[string]interface{}{"nodeOutputs": map[string]interface{}{}},
+	}
+
+	if req.ExecutionID != "" {
+		if req.NodeID == "" {
+			return nil, fmt.Errorf("%w: nodeId is required with executionId", ErrInvalidExpressionRequest)
+		}
+		loaded, err := s.loadExecutionContext(ctx, accountID, req.ExecutionID, req.NodeID)
+		if err != nil {
+			return nil, err
+		}
+		execCtx = *loaded
+	}
+
+	if sample := req.Context; sample != nil {
+		if sample.Input != nil {
+			execCtx.Input = sample.Input
+		}
+		if sample.NodeOutputs != nil {
+			execCtx.WorkflowData["nodeOutputs"] = sample.NodeOutputs
+		}
+		if sample.Vars != nil {
+			execCtx.WorkflowData["vars"] = sample.Vars
+		}
+		if sample.WorkflowInput != nil {
+			execCtx.WorkflowData["input"] = sample.WorkflowInput
+		}
+	}
+
+	return EvaluateExpression(req.Source, req.Dialect, req.Escape, execCtx)
+}
+
+// EvaluateExpression evaluates source for a node execution context, the way the executors do:
+// expressions use the conditional node context, templates the node template context
+func EvaluateExpression(source, dialect, escape string, execCtx executors.ExecutionContext) (*dto.EvaluateExpressionResponse, error) {
+	var result interface{}
+	var err error
+
+	switch strings.ToLower(dialect) {
+	case "", DialectExpression:
+		result, err = templating.Evaluate(source, executors.ConditionContext(execCtx))
+	case templating.DialectSimple, templating.DialectGo, templating.DialectAuto:
+		esc, escErr := parseEscape(escape)
+		if escErr != nil {
+			return nil, escErr
+		}
+		if err = templating.CheckTemplate(source, strings.ToLower(dialect)); err == nil {
+			result, err = templating.Render(source, strings.ToLower(dialect), executors.TemplateContext(execCtx), esc)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported dialect %q (must be expression, simple, go or auto)", ErrInvalidExpressionRequest, dialect)
+	}
+
+	if err != nil {
+		resp := &dto.EvaluateExpressionResponse{Valid: false, Error: err.Error()}
+		if parseErr, ok := templating.AsParseError(err); ok {
+			resp.Error = parseErr.Message
+			resp.Position = &dto.ExpressionPosition{Line: parseErr.Position.Line, Column: parseErr.Position.Column}
+		}
+		return resp, nil
+	}
+
+	return &dto.EvaluateExpressionResponse{Valid: true, Result: result, Type: jsonType(result)}, nil
+}
+
+func parseEscape(escape string) (templating.Escape, error) {
+	switch strings.ToLower(escape) {
+	case "", "none":
+		return templating.EscapeNone, nil
+	case "url":
+		return templating.EscapeURL, nil
+	case "header":
+		return templating.EscapeHeader, nil
+	case "html":
+		return templating.EscapeHTML, nil
+	case "json":
+		return templating.EscapeJSON, nil
+	default:
+		return 0, fmt.Errorf("%w: unsupported escape %q (must be none, url, header, html or json)", ErrInvalidExpressionRequest, escape)
+	}
+}
+
+// loadExecutionContext rebuilds a node's ExecutionContext from a past execution:
+// the node's recorded input, the outputs of nodes that succeeded, and the workflow input
+func (s *ExpressionService) loadExecutionContext(ctx context.Context, accountID, executionID, nodeID string) (*executors.ExecutionContext, error) {
+	var execution models.WorkflowExecution
+	err := s.db.WithContext(ctx).Table("workflow_executions").
+		Select("workflow_executions.*").
+		Joins("INNER JOIN workflows ON workflows.id = workflow_executions.workflow_id").
+		Where("workflow_executions.id = ? AND workflows.account_id = ?", executionID, accountID).
+		First(&execution).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExpressionSourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load execution: %w", err)
+	}
+
+	var nodeExecutions []models.WorkflowNodeExecution
+	if err := s.db.WithContext(ctx).
+		Where("execution_id = ?", executionID).
+		Order("started_at ASC").
+		Find(&nodeExecutions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load node executions: %w", err)
+	}
+
+	var input interface{}
+	found := false
+	nodeOutputs := make(map[string]interface{})
+	for _, ne := range nodeExecutions {
+		// The last recorded run wins (loops run a node many times)
+		if ne.NodeID == nodeID && ne.Input != nil {
+			found = true
+			input = nil
+			json.Unmarshal([]byte(*ne.Input), &input)
+		}
+		if ne.Status == "success" && ne.Output != nil {
+			var output interface{}
+			if json.Unmarshal([]byte(*ne.Output), &output) == nil {
+				nodeOutputs[ne.NodeID] = output
+			}
+		}
+	}
+	if !found {
+		return nil, ErrExpressionSourceNotFound
+	}
+
+	var workflowInput interface{}
+	if execution.Input != nil {
+		json.Unmarshal([]byte(*execution.Input), &workflowInput)
+	}
+
+	return &executors.ExecutionContext{
+		NodeID:      nodeID,
+		Input:       input,
+		ExecutionID: executionID,
+		AccountID:   accountID,
+		WorkflowData: map[string]interface{}{
+			"nodeOutputs": nodeOutputs,
+			"input":       workflowInput,
+			"vars":        s.executionVariables(ctx, accountID, &execution),
+			"execution":   executionMetadata(&execution),
+		},
+	}, nil
+}
+
+// executionVariables resolves the variables of a past execution with the environment's current values.
+// Falls back to the workflow's own variables if the environment no longer exists
+func (s *ExpressionService) executionVariables(ctx context.Context, accountID string, execution *models.WorkflowExecution) map[string]interface{} {
+	var version models.WorkflowVersion
+	if err := s.db.WithContext(ctx).
+		Where("workflow_id = ? AND version = ?", execution.WorkflowID, execution.Version).
+		First(&version).Error; err != nil {
+		return map[string]interface{}{}
+	}
+	def, err := workflows.Parse([]byte(version.Definition))
+	if err != nil {
+		return map[string]interface{}{}
+	}
+
+	vars, err := NewEnvironmentService(s.db).ResolveVariables(ctx, &accountID, execution.Environment, def.Variables)
+	if err != nil {
+		return def.Variables
+	}
+	return vars
+}
+
+// jsonType names the JSON type of a value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int32, int64, uint, uint32, uint64:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/patali/yantra/src/executors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	execCtx := executors.ExecutionContext{
+		NodeID: "check",
+		Input:  map[string]interface{}{"data": map[string]interface{}{"count": float64(5)}, "name": "Ada"},
+		WorkflowData: map[string]interface{}{
+			"nodeOutputs": map[string]interface{}{"fetch": map[string]interface{}{"data": "ok"}},
+			"vars":        map[string]interface{}{"LIMIT": float64(3)},
+		},
+	}
+
+	t.Run("Condition uses the conditional node context", func(t *testing.T) {
+		resp, err := EvaluateExpression("count > vars.LIMIT && fetch.data == \"ok\"", "", "", execCtx)
+		assert.NoError(t, err)
+		assert.True(t, resp.Valid)
+		assert.Equal(t, true, resp.Result)
+		assert.Equal(t, "boolean", resp.Type)
+	})
+
+	t.Run("Template renders with escaping", func(t *testing.T) {
+		resp, err := EvaluateExpression("https://x.test/?q={{input.name | upper}}&n={{execution.nodeId}}", "simple", "url", execCtx)
+		assert.NoError(t, err)
+		assert.True(t, resp.Valid)
+		assert.Equal(t, "https://x.test/?q=ADA&n=check", resp.Result)
+	})
+
+	t.Run("Parse error has a position", func(t *testing.T) {
+		resp, err := EvaluateExpression("Hi\n{{ name + }}", "simple", "", execCtx)
+		assert.NoError(t, err)
+		assert.False(t, resp.Valid)
+		if assert.NotNil(t, resp.Position) {
+			assert.Equal(t, 2, resp.Position.Line)
+		}
+	})
+
+	t.Run("Unknown dialect", func(t *testing.T) {
+		_, err := EvaluateExpression("1", "xml", "", execCtx)
+		assert.ErrorIs(t, err, ErrInvalidExpressionRequest)
+	})
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
+	"gorm.io/gorm"
+)
+
+// Dead letter queue limits
+const (
+	DeadLetterPageLimit    = 50   // Default page size of a listing
+	DeadLetterMaxPageLimit = 500  // Largest page a listing returns
+	DeadLetterBulkLimit    = 1000 // Messages handled by one bulk retry or discard
+)
+
+// ErrDeadLetterNotFound is returned when a message is not in the account's dead letter queue
+var ErrDeadLetterNotFound = errors.New("message not found or access denied")
+
+// DeadLetterPage is one page of an account's dead letter messages
+type DeadLetterPage struct {
+	Messages []models.OutboxMessage `json:"messages"`
+	Total    int64                  `json:"total"`
+	Limit    int                    `json:"limit"`
+	Offset   int                    `json:"offset"`
+}
+
+// DeadLetterMessageDetail is a dead letter message with its workflow and audit history
+type DeadLetterMessageDetail struct {
+	models.OutboxMessage
+	WorkflowID string            `json:"workflowId"`
+	AuditLog   []models.AuditLog `json:"auditLog"`
+}
+
+// SECURITY: every dead letter query joins through node_executions -> workflow_executions -> workflows
+// to filter by account
+
+// deadLetterQuery selects the account's dead letter messages matching filter
+func (s *OutboxService) deadLetterQuery(ctx context.Context, accountID string, filter dto.DeadLetterFilter) *gorm.DB {
+	query := s.db.WithContext(ctx).Table("outbox_messages").
+		Joins("INNER JOIN workflow_node_executions ON workflow_node_executions.id = outbox_messages.node_execution_id").
+		Joins("INNER JOIN workflow_executions ON workflow_executions.id = workflow_node_executions.execution_id").
+		Joins("INNER JOIN workflows ON workflows.id = workflow_executions.workflow_id").
+		Where("workflows.account_id = ?", accountID).
+		Where("outbox_messages.status = ?", "dead_letter")
+
+	if filter.WorkflowID != "" {
+		query = query.Where("workflows.id = ?", filter.WorkflowID)
+	}
+	if filter.EventType != "" {
+		query = query.Where("outbox_messages.event_type = ?", filter.EventType)
+	}
+	if filter.Error != "" {
+		query = query.Where("outbox_messages.last_error ILIKE ?", "%"+escapeLike(filter.Error)+"%")
+	}
+	if filter.From != nil {
+		query = query.Where("COALESCE(outbox_messages.last_attempt_at, outbox_messages.created_at) >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("COALESCE(outbox_messages.last_attempt_at, outbox_messages.created_at) < ?", *filter.To)
+	}
+	if len(filter.MessageIDs) > 0 {
+		query = query.Where("outbox_messages.id IN ?", filter.MessageIDs)
+	}
+	return query
+}
+
+// escapeLike escapes the LIKE wildcards in a literal search term
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// GetDeadLetterMessagesByAccount returns a page of the account's dead letter messages matching filter,
+// most recently failed first
+func (s *OutboxService) GetDeadLetterMessagesByAccount(ctx context.Context, accountID string, filter dto.DeadLetterFilter, limit, offset int) (*DeadLetterPage, error) {
+	if limit <= 0 {
+		limit = DeadLetterPageLimit
+	}
+	if limit > DeadLetterMaxPageLimit {
+		limit = DeadLetterMaxPageLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	page := &DeadLetterPage{Messages: []models.OutboxMessage{}, Limit: limit, Offset: offset}
+	if err := s.deadLetterQuery(ctx, accountID, filter).Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count dead letter messages: %w", err)
+	}
+
+	err := s.deadLetterQuery(ctx, accountID, filter).
+		Select("outbox_messages.*").
+		Order("outbox_messages.last_attempt_at DESC NULLS LAST, outbox_messages.id").
+		Limit(limit).
+		Offset(offset).
+		Preload("NodeExecution").
+		Find(&page.Messages).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dead letter messages: %w", err)
+	}
+
+	return page, nil
+}
+
+// findDeadLetterMessage returns one of the account's dead letter messages
+func (s *OutboxService) findDeadLetterMessage(ctx context.Context, messageID, accountID string) (*models.OutboxMessage, error) {
+	var message models.OutboxMessage
+	err := s.deadLetterQuery(ctx, accountID, dto.DeadLetterFilter{MessageIDs: []string{messageID}}).
+		Select("outbox_messages.*").
+		First(&message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dead letter message: %w", err)
+	}
+	return &message, nil
+}
+
+// GetDeadLetterMessageByAccount returns a dead letter message with its workflow and audit history
+func (s *OutboxService) GetDeadLetterMessageByAccount(ctx context.Context, messageID, accountID string) (*DeadLetterMessageDetail, error) {
+	detail := &DeadLetterMessageDetail{}
+	err := s.deadLetterQuery(ctx, accountID, dto.DeadLetterFilter{MessageIDs: []string{messageID}}).
+		Select("outbox_messages.*").
+		Preload("NodeExecution").
+		First(&detail.OutboxMessage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dead letter message: %w", err)
+	}
+
+	var execution models.WorkflowExecution
+	if err := s.db.WithContext(ctx).Select("workflow_id").First(&execution, "id = ?", detail.NodeExecution.ExecutionID).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch workflow execution: %w", err)
+	}
+	detail.WorkflowID = execution.WorkflowID
+
+	detail.AuditLog, err = NewAuditService(s.db).GetAuditLogs(ctx, accountID, "outbox_message", messageID, 100, 0)
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// RetryDeadLetterMessageByAccount requeues a dead letter message with a fresh delivery job, with account ownership check
+func (s *OutboxService) RetryDeadLetterMessageByAccount(ctx context.Context, messageID, accountID, userID string) error {
+	message, err := s.findDeadLetterMessage(ctx, messageID, accountID)
+	if err != nil {
+		return err
+	}
+
+	return s.requeue(ctx, message, newMessageAuditLog(accountID, userID, models.AuditDeadLetterRetry, message.ID, map[string]interface{}{
+		"eventType": message.EventType,
+		"lastError": message.LastError,
+	}))
+}
+
+// RetryDeadLetterMessagesByAccount requeues up to DeadLetterBulkLimit dead letter messages matching filter.
+// Each message is requeued and audited in its own transaction, so one failure does not hold back the rest
+func (s *OutboxService) RetryDeadLetterMessagesByAccount(ctx context.Context, accountID, userID string, req dto.DeadLetterBulkRequest) (*dto.DeadLetterBulkResponse, error) {
+	messages, hasMore, err := s.bulkDeadLetterMessages(ctx, accountID, req.DeadLetterFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.DeadLetterBulkResponse{Matched: len(messages), HasMore: hasMore, Failed: map[string]string{}}
+	for i := range messages {
+		audit := newMessageAuditLog(accountID, userID, models.AuditDeadLetterRetry, messages[i].ID, map[string]interface{}{
+			"bulk":      true,
+			"eventType": messages[i].EventType,
+			"lastError": messages[i].LastError,
+			"reason":    req.Reason,
+		})
+		if err := s.requeue(ctx, &messages[i], audit); err != nil {
+			response.Failed[messages[i].ID] = err.Error()
+			continue
+		}
+		response.Succeeded++
+	}
+
+	log.Printf("🔁 Bulk retry requeued %d/%d dead letter message(s) for account %s", response.Succeeded, response.Matched, accountID)
+	return response, nil
+}
+
+// DiscardDeadLetterMessagesByAccount removes up to DeadLetterBulkLimit dead letter messages matching filter
+// from the queue. Messages are kept with status discarded, and every discard is audited in the same transaction
+func (s *OutboxService) DiscardDeadLetterMessagesByAccount(ctx context.Context, accountID, userID string, req dto.DeadLetterBulkRequest) (*dto.DeadLetterBulkResponse, error) {
+	messages, hasMore, err := s.bulkDeadLetterMessages(ctx, accountID, req.DeadLetterFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.DeadLetterBulkResponse{Matched: len(messages), HasMore: hasMore}
+	if len(messages) == 0 {
+		return response, nil
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := make([]string, len(messages))
+		audits := make([]*models.AuditLog, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+			audits[i] = newMessageAuditLog(accountID, userID, models.AuditDeadLetterDiscard, message.ID, map[string]interface{}{
+				"eventType": message.EventType,
+				"lastError": message.LastError,
+				"reason":    req.Reason,
+			})
+		}
+
+		// The status check skips messages retried since they were selected
+		result := tx.Model(&models.OutboxMessage{}).
+			Where("id IN ? AND status = ?", ids, "dead_letter").
+			Updates(map[string]interface{}{
+				"status":       "discarded",
+				"processed_at": time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to discard messages: %w", result.Error)
+		}
+		response.Succeeded = int(result.RowsAffected)
+
+		if err := tx.Create(&audits).Error; err != nil {
+			return fmt.Errorf("failed to record audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🗑️  Discarded %d dead letter message(s) for account %s", response.Succeeded, accountID)
+	return response, nil
+}
+
+// bulkDeadLetterMessages selects the messages of a bulk operation. An empty filter is refused so that
+// a missing body cannot act on the whole queue
+func (s *OutboxService) bulkDeadLetterMessages(ctx context.Context, accountID string, filter dto.DeadLetterFilter) ([]models.OutboxMessage, bool, error) {
+	if filter.IsEmpty() {
+		return nil, false, fmt.Errorf("a filter or messageIds is required for bulk operations")
+	}
+
+	var messages []models.OutboxMessage
+	err := s.deadLetterQuery(ctx, accountID, filter).
+		Select("outbox_messages.*").
+		Order("outbox_messages.last_attempt_at DESC NULLS LAST, outbox_messages.id").
+		Limit(DeadLetterBulkLimit + 1).
+		Find(&messages).Error
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch dead letter messages: %w", err)
+	}
+
+	if len(messages) > DeadLetterBulkLimit {
+		return messages[:DeadLetterBulkLimit], true, nil
+	}
+	return messages, false, nil
+}
+
+// UpdateDeadLetterPayloadByAccount edits the payload of a dead letter message (e.g. to fix a recipient)
+// and optionally requeues it. The previous payload is kept in the audit log
+func (s *OutboxService) UpdateDeadLetterPayloadByAccount(ctx context.Context, messageID, accountID, userID string, req dto.UpdateDeadLetterPayloadRequest) (*models.OutboxMessage, error) {
+	message, err := s.findDeadLetterMessage(ctx, messageID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := editPayload(message.Payload, req)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newMessageAuditLog(accountID, userID, models.AuditDeadLetterEditPayload, message.ID, map[string]interface{}{
+		"previousPayload": json.RawMessage(message.Payload),
+		"reason":          req.Reason,
+	})
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.OutboxMessage{}).
+			Where("id = ? AND status = ?", message.ID, "dead_letter").
+			Update("payload", payload)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update payload: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("message is no longer in the dead letter queue")
+		}
+		return tx.Create(audit).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	message.Payload = payload
+
+	log.Printf("✏️  Payload of dead letter message %s edited", message.ID)
+
+	if req.Retry {
+		retryAudit := newMessageAuditLog(accountID, userID, models.AuditDeadLetterRetry, message.ID, map[string]interface{}{
+			"eventType": message.EventType,
+			"lastError": message.LastError,
+			"reason":    req.Reason,
+		})
+		if err := s.requeue(ctx, message, retryAudit); err != nil {
+			return nil, fmt.Errorf("payload saved but retry failed: %w", err)
+		}
+		message.Status = "pending"
+	}
+
+	return message, nil
+}
+
+// editPayload applies a payload edit to a stored execution context. Only the node config and input can
+// change; the node, execution and account the message belongs to are kept
+func editPayload(stored string, req dto.UpdateDeadLetterPayloadRequest) (string, error) {
+	if req.NodeConfig == nil && len(req.Input) == 0 {
+		return "", fmt.Errorf("nodeConfig or input is required")
+	}
+
+	var payload executors.ExecutionContext
+	if err := json.Unmarshal([]byte(stored), &payload); err != nil {
+		return "", fmt.Errorf("stored payload is invalid: %w", err)
+	}
+
+	if req.NodeConfig != nil {
+		if payload.NodeConfig == nil {
+			payload.NodeConfig = map[string]interface{}{}
+		}
+		for key, value := range req.NodeConfig {
+			if value == nil {
+				delete(payload.NodeConfig, key)
+			} else {
+				payload.NodeConfig[key] = value
+			}
+		}
+	}
+	if len(req.Input) > 0 {
+		var input interface{}
+		if err := json.Unmarshal(req.Input, &input); err != nil {
+			return "", fmt.Errorf("invalid input: %w", err)
+		}
+		payload.Input = input
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditPayload(t *testing.T) {
+	stored := `{"node_id":"notify","node_config":{"to":"ops@exmaple.com","subject":"Report","cc":"old@example.com"},"input":{"n":1},"execution_id":"exec-1","account_id":"acc-1"}`
+
+	edited, err := editPayload(stored, dto.UpdateDeadLetterPayloadRequest{
+		NodeConfig: map[string]interface{}{"to": "ops@example.com", "cc": nil, "node_id": "ignored"},
+	})
+	assert.NoError(t, err)
+
+	var payload executors.ExecutionContext
+	assert.NoError(t, json.Unmarshal([]byte(edited), &payload))
+	assert.Equal(t, "notify", payload.NodeID)
+	assert.Equal(t, "exec-1", payload.ExecutionID)
+	assert.Equal(t, "acc-1", payload.AccountID, "the owning account cannot be edited")
+	assert.Equal(t, "ops@example.com", payload.NodeConfig["to"])
+	assert.Equal(t, "Report", payload.NodeConfig["subject"])
+	assert.NotContains(t, payload.NodeConfig, "cc", "null removes a config key")
+	assert.Equal(t, map[string]interface{}{"n": float64(1)}, payload.Input)
+
+	edited, err = editPayload(stored, dto.UpdateDeadLetterPayloadRequest{Input: json.RawMessage(`[1,2]`)})
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal([]byte(edited), &payload))
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, payload.Input)
+
+	_, err = editPayload(stored, dto.UpdateDeadLetterPayloadRequest{})
+	assert.Error(t, err)
+
+	_, err = editPayload("not json", dto.UpdateDeadLetterPayloadRequest{Input: json.RawMessage(`1`)})
+	assert.Error(t, err)
+}
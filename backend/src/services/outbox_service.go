@@ -8,12 +8,21 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/patali/yantra/src/breaker"
 	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/executors"
+	"github.com/patali/yantra/src/retry"
+	riverinternal "github.com/patali/yantra/src/river"
+	"github.com/riverqueue/river"
 	"gorm.io/gorm"
 )
 
 type OutboxService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	riverClient *river.Client[pgx.Tx] // Set via SetRiverClient; needed to enqueue deliveries
+	pool        *pgxpool.Pool
 }
 
 func NewOutboxService(db *gorm.DB) *OutboxService {
@@ -22,127 +31,273 @@ func NewOutboxService(db *gorm.DB) *OutboxService {
 	}
 }
 
-// ExecuteNodeWithOutbox executes a node and creates an outbox message atomically
-// This ensures that the node execution record and the side effect message are created together
+// SetRiverClient connects the outbox to River (called after initialization, as the River client needs the outbox worker)
+func (s *OutboxService) SetRiverClient(riverClient *river.Client[pgx.Tx], pool *pgxpool.Pool) {
+	s.riverClient = riverClient
+	s.pool = pool
+}
+
+// ExecuteNodeWithOutbox creates a node execution, its outbox message and the River job delivering it atomically.
+// All three are written in one pgx transaction using River's transactional insert, so a delivery job
+// exists exactly when its message does
 func (s *OutboxService) ExecuteNodeWithOutbox(
 	ctx context.Context,
 	executionID string,
 	accountID *string,
 	nodeID, nodeType string,
-	nodeConfig, input map[string]interface{},
+	nodeConfig, input, workflowData map[string]interface{},
 	eventType string,
+) (*models.WorkflowNodeExecution, *models.OutboxMessage, error) {
+	return s.queueNode(ctx, executionID, accountID, nodeID, nodeType, nodeConfig, input, workflowData, eventType, false)
+}
+
+// RetryNodeWithOutbox queues a failed node again as a new node execution and outbox message.
+// With resumeExecution set, the worker continues the workflow execution from its checkpoint once the node succeeds
+func (s *OutboxService) RetryNodeWithOutbox(
+	ctx context.Context,
+	executionID string,
+	accountID *string,
+	nodeID, nodeType string,
+	nodeConfig, input, workflowData map[string]interface{},
+	resumeExecution bool,
+) (*models.WorkflowNodeExecution, *models.OutboxMessage, error) {
+	return s.queueNode(ctx, executionID, accountID, nodeID, nodeType, nodeConfig, input, workflowData, executors.EventTypeForNodeType(nodeType), resumeExecution)
+}
+
+func (s *OutboxService) queueNode(
+	ctx context.Context,
+	executionID string,
+	accountID *string,
+	nodeID, nodeType string,
+	nodeConfig, input, workflowData map[string]interface{},
+	eventType string,
+	resumeExecution bool,
 ) (*models.WorkflowNodeExecution, *models.OutboxMessage, error) {
 	var nodeExecution models.WorkflowNodeExecution
 	var outboxMessage models.OutboxMessage
 
-	// Execute in a transaction
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		// Create idempotency key
-		idempotencyKey := fmt.Sprintf("%s-%s-%s", executionID, nodeID, uuid.New().String())
-
-		// Create node execution record
-		inputJSON, _ := json.Marshal(input)
-		inputStr := string(inputJSON)
-
-		nodeExecution = models.WorkflowNodeExecution{
-			ExecutionID:    executionID,
-			NodeID:         nodeID,
-			NodeType:       nodeType,
-			Status:         "pending",
-			Input:          &inputStr,
-			IdempotencyKey: &idempotencyKey,
-		}
+	if s.riverClient == nil {
+		return nil, nil, fmt.Errorf("outbox is not connected to the job queue")
+	}
 
-		if err := tx.Create(&nodeExecution).Error; err != nil {
-			return fmt.Errorf("failed to create node execution: %w", err)
-		}
+	// Create idempotency key
+	idempotencyKey := fmt.Sprintf("%s-%s-%s", executionID, nodeID, uuid.New().String())
 
-		// Create outbox message payload
-		accountIDStr := ""
-		if accountID != nil {
-			accountIDStr = *accountID
-		}
-		payload := map[string]interface{}{
-			"node_id":       nodeID,
-			"node_config":   nodeConfig,
-			"input":         input,
-			"workflow_data": map[string]interface{}{},
-			"execution_id":  executionID,
-			"account_id":    accountIDStr,
-		}
-		payloadJSON, _ := json.Marshal(payload)
+	// Create node execution record
+	inputJSON, _ := json.Marshal(input)
+	inputStr := string(inputJSON)
 
-		// Get max retries from node config (default: 3)
-		maxRetries := 3
-		if mr, ok := nodeConfig["maxRetries"].(float64); ok {
-			maxRetries = int(mr)
-		}
-		// Ensure maxRetries is at least 0 and at most 10
-		if maxRetries < 0 {
-			maxRetries = 0
-		}
-		if maxRetries > 10 {
-			maxRetries = 10
-		}
-		// MaxAttempts = maxRetries + 1 (initial attempt + retries)
-		maxAttempts := maxRetries + 1
-		log.Printf("  🔄 Node %s outbox message configured with %d max attempts (maxRetries=%d)", nodeID, maxAttempts, maxRetries)
+	nodeExecution = models.WorkflowNodeExecution{
+		ExecutionID:    executionID,
+		NodeID:         nodeID,
+		NodeType:       nodeType,
+		Status:         "pending",
+		Input:          &inputStr,
+		IdempotencyKey: &idempotencyKey,
+	}
 
-		// Create outbox message
-		now := time.Now()
-		outboxMessage = models.OutboxMessage{
-			NodeExecutionID: nodeExecution.ID,
-			EventType:       eventType,
-			Payload:         string(payloadJSON),
-			Status:          "pending",
-			IdempotencyKey:  idempotencyKey,
-			Attempts:        0,
-			MaxAttempts:     maxAttempts,
-			NextRetryAt:     &now, // Process immediately
-		}
+	// Create outbox message payload
+	accountIDStr := ""
+	if accountID != nil {
+		accountIDStr = *accountID
+	}
+	payload := map[string]interface{}{
+		"node_id":       nodeID,
+		"node_config":   nodeConfig,
+		"input":         input,
+		"workflow_data": outboxWorkflowData(workflowData),
+		"execution_id":  executionID,
+		"account_id":    accountIDStr,
+	}
+	payloadJSON, _ := json.Marshal(payload)
 
-		if err := tx.Create(&outboxMessage).Error; err != nil {
-			return fmt.Errorf("failed to create outbox message: %w", err)
-		}
+	// Retry policy: node config over the account default for the node type
+	policy, err := NewRetryPolicyService(s.db).Resolve(ctx, accountID, nodeType, nodeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	policyJSON, _ := json.Marshal(policy)
+	policyStr := string(policyJSON)
+	log.Printf("  🔄 Node %s outbox message configured with %d max attempts (%s backoff)", nodeID, policy.MaxAttempts, policy.Backoff)
 
-		return nil
-	})
+	// Create outbox message
+	now := time.Now()
+	outboxMessage = models.OutboxMessage{
+		EventType:       eventType,
+		Payload:         string(payloadJSON),
+		Status:          "pending",
+		IdempotencyKey:  idempotencyKey,
+		Attempts:        0,
+		MaxAttempts:     policy.MaxAttempts,
+		NextRetryAt:     &now, // Process immediately
+		RetryPolicy:     &policyStr,
+		ResumeExecution: resumeExecution,
+	}
 
+	// Execute in a transaction
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := execTx(ctx, tx, s.dryRun().Create(&nodeExecution)); err != nil {
+		return nil, nil, fmt.Errorf("failed to create node execution: %w", err)
+	}
+
+	outboxMessage.NodeExecutionID = nodeExecution.ID
+	if _, err := execTx(ctx, tx, s.dryRun().Create(&outboxMessage)); err != nil {
+		return nil, nil, fmt.Errorf("failed to create outbox message: %w", err)
+	}
+
+	if err := s.enqueueTx(ctx, tx, &outboxMessage, policy); err != nil {
 		return nil, nil, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit outbox message: %w", err)
+	}
+
 	return &nodeExecution, &outboxMessage, nil
 }
 
-// GetPendingMessages retrieves pending outbox messages ready to be processed
-func (s *OutboxService) GetPendingMessages(limit int) ([]models.OutboxMessage, error) {
-	var messages []models.OutboxMessage
-	now := time.Now()
+// outboxWorkflowData keeps the parts of workflowData async executors need for templates: the resolved
+// variables, upstream node outputs and execution metadata
+func outboxWorkflowData(workflowData map[string]interface{}) map[string]interface{} {
+	data := map[string]interface{}{}
+	for _, key := range []string{"vars", "nodeOutputs", "execution"} {
+		if value, ok := workflowData[key]; ok {
+			data[key] = value
+		}
+	}
+	return data
+}
 
-	// Only fetch pending messages (exclude cancelled, completed, dead_letter, processing)
-	err := s.db.Where("status = ? AND next_retry_at <= ?", "pending", now).
-		Order("created_at ASC").
-		Limit(limit).
-		Preload("NodeExecution").
-		Find(&messages).Error
+// dryRun returns a session that builds statements without running them, so they can run on a pgx transaction
+func (s *OutboxService) dryRun() *gorm.DB {
+	return s.db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true})
+}
 
+// execTx runs a statement built by a dry-run session on tx and returns the number of rows affected
+func execTx(ctx context.Context, tx pgx.Tx, query *gorm.DB) (int64, error) {
+	if query.Error != nil {
+		return 0, query.Error
+	}
+	tag, err := tx.Exec(ctx, query.Statement.SQL.String(), query.Statement.Vars...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pending messages: %w", err)
+		return 0, err
 	}
+	return tag.RowsAffected(), nil
+}
 
-	return messages, nil
+// enqueueTx inserts the River job delivering a message and links it to the message, inside tx
+func (s *OutboxService) enqueueTx(ctx context.Context, tx pgx.Tx, message *models.OutboxMessage, policy retry.Policy) error {
+	result, err := s.riverClient.InsertTx(ctx, tx, riverinternal.OutboxDeliveryArgs{
+		MessageID: message.ID,
+		Retry:     policy,
+	}, &river.InsertOpts{
+		MaxAttempts: policy.MaxAttempts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbox delivery: %w", err)
+	}
+
+	update := s.dryRun().Model(&models.OutboxMessage{}).
+		Where("id = ?", message.ID).
+		Update("river_job_id", result.Job.ID)
+	if _, err := execTx(ctx, tx, update); err != nil {
+		return fmt.Errorf("failed to link outbox message to its job: %w", err)
+	}
+
+	message.RiverJobID = &result.Job.ID
+	return nil
 }
 
-// MarkMessageProcessing marks a message as being processed
-func (s *OutboxService) MarkMessageProcessing(messageID string) error {
+// EnqueueOrphanedMessages inserts delivery jobs for pending or processing messages that have none.
+// Messages queued before deliveries became River jobs were picked up by a poller that no longer exists.
+// All jobs are inserted in one transaction; replicas starting together skip rows another one has locked
+func (s *OutboxService) EnqueueOrphanedMessages(ctx context.Context) (int, error) {
+	if s.riverClient == nil {
+		return 0, fmt.Errorf("outbox is not connected to the job queue")
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT id, max_attempts, retry_policy FROM outbox_messages
+		WHERE river_job_id IS NULL AND status IN ('pending', 'processing')
+		FOR UPDATE SKIP LOCKED`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find orphaned outbox messages: %w", err)
+	}
+	var messages []models.OutboxMessage
+	for rows.Next() {
+		var message models.OutboxMessage
+		if err := rows.Scan(&message.ID, &message.MaxAttempts, &message.RetryPolicy); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read orphaned outbox message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to find orphaned outbox messages: %w", err)
+	}
+
+	for i := range messages {
+		if err := s.enqueueTx(ctx, tx, &messages[i], messageRetryPolicy(&messages[i])); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit orphaned message jobs: %w", err)
+	}
+	return len(messages), nil
+}
+
+// BeginDelivery marks a message as processing for a delivery attempt and returns it.
+// It returns nil when the message no longer needs delivering (cancelled, completed or dead lettered)
+func (s *OutboxService) BeginDelivery(messageID string, attempt int) (*models.OutboxMessage, error) {
+	var message models.OutboxMessage
+	if err := s.db.First(&message, "id = ?", messageID).Error; err != nil {
+		return nil, err
+	}
+
+	if message.Status != "pending" && message.Status != "processing" {
+		return nil, nil
+	}
+
 	now := time.Now()
-	return s.db.Model(&models.OutboxMessage{}).
+	if err := s.db.Model(&models.OutboxMessage{}).
 		Where("id = ?", messageID).
 		Updates(map[string]interface{}{
 			"status":          "processing",
+			"attempts":        attempt,
 			"last_attempt_at": now,
-			"attempts":        gorm.Expr("attempts + 1"),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to mark message as processing: %w", err)
+	}
+
+	message.Status = "processing"
+	message.Attempts = attempt
+	message.LastAttemptAt = &now
+	return &message, nil
+}
+
+// PauseDelivery returns a message to pending after its destination's circuit breaker rejected the delivery.
+// The attempt is given back and next_retry_at set to when the breaker probes again
+func (s *OutboxService) PauseDelivery(messageID string, attempt int, openErr *breaker.OpenError) error {
+	return s.db.Model(&models.OutboxMessage{}).
+		Where("id = ? AND status = ?", messageID, "processing").
+		Updates(map[string]interface{}{
+			"status":        "pending",
+			"attempts":      max(attempt-1, 0),
+			"next_retry_at": openErr.RetryAt,
+			"last_error":    openErr.Error(),
 		}).Error
 }
 
@@ -196,8 +351,40 @@ func (s *OutboxService) MarkMessageCompleted(messageID string, output map[string
 	})
 }
 
-// MarkMessageFailed marks a message as failed and schedules retry or moves to dead letter
-func (s *OutboxService) MarkMessageFailed(messageID, errorMsg string) error {
+// ResumeExecution queues a workflow execution to continue from its checkpoint after a retried node succeeded.
+// Executions that are still in flight, or were cancelled, are left alone
+func (s *OutboxService) ResumeExecution(ctx context.Context, executionID string) error {
+	var execution models.WorkflowExecution
+	if err := s.db.First(&execution, "id = ?", executionID).Error; err != nil {
+		return fmt.Errorf("execution not found: %w", err)
+	}
+
+	switch execution.Status {
+	case "error", "partially_failed", "interrupted":
+	default:
+		log.Printf("  ⏭️  Not resuming execution %s: status is %s", executionID, execution.Status)
+		return nil
+	}
+
+	input := map[string]interface{}{}
+	if execution.Input != nil {
+		if err := json.Unmarshal([]byte(*execution.Input), &input); err != nil {
+			return fmt.Errorf("failed to parse execution input: %w", err)
+		}
+	}
+
+	jobID, err := NewQueueService(s.riverClient).QueueWorkflowExecution(ctx, execution.WorkflowID, execution.ID, input, models.TriggerTypeNodeRetry)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("  🔄 Execution %s queued to continue after node retry (job: %s)", executionID, jobID)
+	return nil
+}
+
+// MarkMessageFailed records a failed delivery attempt. River schedules the retry of the job; on the final
+// attempt the message moves to the dead letter queue and its node execution and workflow are updated
+func (s *OutboxService) MarkMessageFailed(messageID, errorMsg string, final bool) error {
 	var message models.OutboxMessage
 	if err := s.db.First(&message, "id = ?", messageID).Error; err != nil {
 		return fmt.Errorf("message not found: %w", err)
@@ -205,31 +392,19 @@ func (s *OutboxService) MarkMessageFailed(messageID, errorMsg string) error {
 
 	log.Printf("  📊 Message %s: attempts=%d, maxAttempts=%d", messageID[:8], message.Attempts, message.MaxAttempts)
 
-	// The attempts counter has already been incremented in MarkMessageProcessing
-	// So we check if current attempts >= maxAttempts (not <)
-	shouldRetry := message.Attempts < message.MaxAttempts
-
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		updates := map[string]interface{}{
-			"last_error": errorMsg,
+			"last_error":    errorMsg,
+			"next_retry_at": nil,
 		}
 
-		if shouldRetry {
-			// Calculate exponential backoff
-			retryDelay := time.Duration(1<<uint(message.Attempts)) * time.Minute
-			if retryDelay > time.Hour {
-				retryDelay = time.Hour
-			}
-			nextRetry := time.Now().Add(retryDelay)
-
+		if !final {
 			updates["status"] = "pending"
-			updates["next_retry_at"] = nextRetry
-			log.Printf("  🔄 Message %s will retry (attempt %d/%d) in %v",
-				messageID[:8], message.Attempts, message.MaxAttempts, retryDelay)
+			log.Printf("  🔄 Message %s will be retried by River (attempt %d/%d)",
+				messageID[:8], message.Attempts, message.MaxAttempts)
 		} else {
 			// Move to dead letter queue
 			updates["status"] = "dead_letter"
-			updates["next_retry_at"] = nil
 			log.Printf("  💀 Message %s moving to dead letter (attempt %d/%d)",
 				messageID[:8], message.Attempts, message.MaxAttempts)
 		}
@@ -248,7 +423,7 @@ func (s *OutboxService) MarkMessageFailed(messageID, errorMsg string) error {
 		log.Printf("  ✅ Message %s updated to status: %s", messageID[:8], updates["status"])
 
 		// If message went to dead letter, update node execution and check workflow status
-		if !shouldRetry {
+		if final {
 			// Get the node execution to find the workflow execution
 			var nodeExecution models.WorkflowNodeExecution
 			if err := tx.First(&nodeExecution, "id = ?", message.NodeExecutionID).Error; err != nil {
@@ -260,7 +435,7 @@ func (s *OutboxService) MarkMessageFailed(messageID, errorMsg string) error {
 				Where("id = ?", message.NodeExecutionID).
 				Updates(map[string]interface{}{
 					"status":       "error",
-					"error":        fmt.Sprintf("Failed after %d attempts: %s", message.MaxAttempts, errorMsg),
+					"error":        fmt.Sprintf("Failed after %d attempts: %s", message.Attempts, errorMsg),
 					"completed_at": time.Now(),
 				}).Error; err != nil {
 				return err
@@ -294,58 +469,44 @@ func (s *OutboxService) GetDeadLetterMessages(limit int) ([]models.OutboxMessage
 	return messages, nil
 }
 
-// RetryDeadLetterMessage resets a dead letter message for retry
-func (s *OutboxService) RetryDeadLetterMessage(messageID string) error {
-	now := time.Now()
-	return s.db.Model(&models.OutboxMessage{}).
-		Where("id = ? AND status = ?", messageID, "dead_letter").
-		Updates(map[string]interface{}{
-			"status":          "pending",
-			"attempts":        0,
-			"next_retry_at":   now,
-			"last_error":      nil,
-			"last_attempt_at": nil,
-		}).Error
+// RetryDeadLetterMessage requeues a dead letter message with a fresh delivery job
+func (s *OutboxService) RetryDeadLetterMessage(ctx context.Context, messageID string) error {
+	var message models.OutboxMessage
+	if err := s.db.First(&message, "id = ? AND status = ?", messageID, "dead_letter").Error; err != nil {
+		return fmt.Errorf("dead letter message not found: %w", err)
+	}
+	return s.requeue(ctx, &message, nil)
 }
 
-// SECURITY: Account-filtered versions of the above methods
-
-// GetDeadLetterMessagesByAccount returns dead letter messages filtered by account ID
-func (s *OutboxService) GetDeadLetterMessagesByAccount(accountID string, limit int) ([]models.OutboxMessage, error) {
-	var messages []models.OutboxMessage
+// messageRetryPolicy returns the retry policy a message was queued with
+// (messages queued before policies were stored keep their attempt budget)
+func messageRetryPolicy(message *models.OutboxMessage) retry.Policy {
+	policy := retry.Policy{MaxAttempts: message.MaxAttempts}
+	if message.RetryPolicy != nil {
+		if err := json.Unmarshal([]byte(*message.RetryPolicy), &policy); err != nil {
+			log.Printf("⚠️  Message %s has an invalid retry policy, using defaults: %v", message.ID, err)
+		}
+	}
+	return policy.Merge(retry.Default())
+}
 
-	// Join through node_executions -> workflow_executions -> workflows to filter by account
-	err := s.db.Table("outbox_messages").
-		Select("outbox_messages.*").
-		Joins("INNER JOIN workflow_node_executions ON workflow_node_executions.id = outbox_messages.node_execution_id").
-		Joins("INNER JOIN workflow_executions ON workflow_executions.id = workflow_node_executions.execution_id").
-		Joins("INNER JOIN workflows ON workflows.id = workflow_executions.workflow_id").
-		Where("workflows.account_id = ?", accountID).
-		Where("outbox_messages.status = ?", "dead_letter").
-		Order("outbox_messages.last_attempt_at DESC").
-		Limit(limit).
-		Preload("NodeExecution").
-		Find(&messages).Error
+// requeue resets a dead letter message and inserts a new River job for it with the full attempt budget,
+// recording audit (if set) in the same transaction. The job River discarded stays in river_job until
+// River's cleaner removes it
+func (s *OutboxService) requeue(ctx context.Context, message *models.OutboxMessage, audit *models.AuditLog) error {
+	if s.riverClient == nil {
+		return fmt.Errorf("outbox is not connected to the job queue")
+	}
 
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch dead letter messages: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	return messages, nil
-}
-
-// RetryDeadLetterMessageByAccount resets a dead letter message for retry, with account ownership check
-func (s *OutboxService) RetryDeadLetterMessageByAccount(messageID string, accountID string) error {
 	now := time.Now()
-
-	// Update with account ownership check using joins
-	result := s.db.Table("outbox_messages").
-		Joins("INNER JOIN workflow_node_executions ON workflow_node_executions.id = outbox_messages.node_execution_id").
-		Joins("INNER JOIN workflow_executions ON workflow_executions.id = workflow_node_executions.execution_id").
-		Joins("INNER JOIN workflows ON workflows.id = workflow_executions.workflow_id").
-		Where("outbox_messages.id = ?", messageID).
-		Where("workflows.account_id = ?", accountID).
-		Where("outbox_messages.status = ?", "dead_letter").
+	reset := s.dryRun().Model(&models.OutboxMessage{}).
+		Where("id = ? AND status = ?", message.ID, "dead_letter").
 		Updates(map[string]interface{}{
 			"status":          "pending",
 			"attempts":        0,
@@ -353,15 +514,29 @@ func (s *OutboxService) RetryDeadLetterMessageByAccount(messageID string, accoun
 			"last_error":      nil,
 			"last_attempt_at": nil,
 		})
+	rows, err := execTx(ctx, tx, reset)
+	if err != nil {
+		return fmt.Errorf("failed to reset message: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("message is no longer in the dead letter queue")
+	}
 
-	if result.Error != nil {
-		return result.Error
+	if err := s.enqueueTx(ctx, tx, message, messageRetryPolicy(message)); err != nil {
+		return err
+	}
+
+	if audit != nil {
+		if _, err := execTx(ctx, tx, s.dryRun().Create(audit)); err != nil {
+			return fmt.Errorf("failed to record audit log: %w", err)
+		}
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("message not found or access denied")
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit message retry: %w", err)
 	}
 
+	log.Printf("🔁 Dead letter message %s requeued as job %d", message.ID, *message.RiverJobID)
 	return nil
 }
 
@@ -496,7 +671,8 @@ func (s *OutboxService) updateWorkflowStatusOnNodeFailure(tx *gorm.DB, execution
 }
 
 // CancelPendingMessagesForExecution cancels all pending/processing outbox messages for a given execution
-// This should be called when a workflow execution is cancelled to prevent orphaned side effects
+// This should be called when a workflow execution is cancelled to prevent orphaned side effects.
+// Their River jobs stay queued and finish without delivering once they see the cancelled message
 func (s *OutboxService) CancelPendingMessagesForExecution(executionID string) error {
 	log.Printf("🛑 Cancelling outbox messages for execution %s", executionID)
 
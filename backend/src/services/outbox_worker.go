@@ -3,183 +3,131 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
-	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/breaker"
 	"github.com/patali/yantra/src/executors"
+	"github.com/riverqueue/river"
+	"gorm.io/gorm"
 )
 
+// OutboxWorkerService delivers outbox messages. Deliveries run as River jobs on the outbox queue,
+// and River owns their retries, backoff and discard; each job runs one attempt through this service
 type OutboxWorkerService struct {
 	outboxService   *OutboxService
 	executorFactory *executors.ExecutorFactory
-	isRunning       bool
-	pollInterval    time.Duration
 }
 
 func NewOutboxWorkerService(outboxService *OutboxService, executorFactory *executors.ExecutorFactory) *OutboxWorkerService {
 	return &OutboxWorkerService{
 		outboxService:   outboxService,
 		executorFactory: executorFactory,
-		isRunning:       false,
-		pollInterval:    5 * time.Second,
 	}
 }
 
-// Start starts the outbox worker
-func (w *OutboxWorkerService) Start(ctx context.Context) {
-	if w.isRunning {
-		log.Println("⚠️  Outbox worker is already running")
-		return
-	}
-
-	w.isRunning = true
-	log.Println("🚀 Starting outbox worker...")
-
-	// Process immediately on start
-	go w.processMessages(ctx)
-
-	// Then poll at regular intervals
-	ticker := time.NewTicker(w.pollInterval)
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				if w.isRunning {
-					w.processMessages(ctx)
-				}
-			case <-ctx.Done():
-				ticker.Stop()
-				w.isRunning = false
-				log.Println("✅ Outbox worker stopped")
-				return
-			}
-		}
-	}()
-
-	log.Printf("✅ Outbox worker started (polling every %v)\n", w.pollInterval)
-}
-
-// Stop stops the outbox worker
-func (w *OutboxWorkerService) Stop() {
-	if !w.isRunning {
-		return
+// DeliverOutboxMessage runs one delivery attempt of an outbox message (called by the River outbox worker).
+// A returned error makes River retry the job. The message is dead lettered on the final attempt, or straight
+// away when the failure is permanent, in which case the job is cancelled. Deliveries rejected by an open
+// circuit breaker are snoozed until it probes again, which River does not count as an attempt
+func (w *OutboxWorkerService) DeliverOutboxMessage(ctx context.Context, messageID string, attempt int, final bool) error {
+	message, err := w.outboxService.BeginDelivery(messageID, attempt)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The message was deleted along with its node execution; nothing is left to deliver
+		return river.JobCancel(fmt.Errorf("outbox message %s not found", messageID))
 	}
-
-	log.Println("🛑 Stopping outbox worker...")
-	w.isRunning = false
-}
-
-// processMessages processes pending outbox messages
-func (w *OutboxWorkerService) processMessages(ctx context.Context) {
-	if !w.isRunning {
-		return
-	}
-
-	messages, err := w.outboxService.GetPendingMessages(10)
 	if err != nil {
-		log.Printf("❌ Error fetching pending messages: %v\n", err)
-		return
+		return fmt.Errorf("failed to load outbox message %s: %w", messageID, err)
 	}
-
-	if len(messages) == 0 {
-		return
+	if message == nil {
+		log.Printf("  ⏭️  Skipping message %s: no longer pending\n", messageID)
+		return nil
 	}
 
-	log.Printf("📬 Processing %d outbox messages...\n", len(messages))
+	log.Printf("  ▶ Processing message %s (type: %s, attempt: %d/%d)\n",
+		message.ID, message.EventType, attempt, message.MaxAttempts)
 
-	// Process messages in parallel (with reasonable concurrency)
-	for _, message := range messages {
-		// Process each message
-		w.processMessage(ctx, message)
+	// Parse payload
+	var payload executors.ExecutionContext
+	if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+		log.Printf("  ❌ Failed to parse payload: %v\n", err)
+		return w.failPermanently(message.ID, fmt.Sprintf("Invalid payload: %v", err))
 	}
-}
 
-// processMessage processes a single outbox message
-func (w *OutboxWorkerService) processMessage(ctx context.Context, message models.OutboxMessage) {
-	log.Printf("  ▶ Processing message %s (type: %s, attempt: %d)\n",
-		message.ID, message.EventType, message.Attempts+1)
+	// Executors that call external APIs forward the key so a retried delivery can be deduplicated downstream
+	payload.IdempotencyKey = message.IdempotencyKey
 
-	// Skip cancelled messages (workflow was cancelled while message was pending)
-	if message.Status == "cancelled" {
-		log.Printf("  ⏭️  Skipping cancelled message %s\n", message.ID)
-		return
-	}
+	// Execute with the node type registered for the event type
+	result, err := w.execute(ctx, message.EventType, payload)
 
-	// Mark as processing
-	if err := w.outboxService.MarkMessageProcessing(message.ID); err != nil {
-		log.Printf("  ❌ Failed to mark message as processing: %v\n", err)
-		return
+	// The destination's circuit breaker is open and nothing was sent: hold the message without using an attempt
+	if openErr, ok := breaker.AsOpen(err); ok {
+		log.Printf("  ⏸️  Message %s paused: %v\n", message.ID, openErr)
+		if err := w.outboxService.PauseDelivery(message.ID, attempt, openErr); err != nil {
+			return fmt.Errorf("failed to pause message %s: %w", message.ID, err)
+		}
+		return river.JobSnooze(max(time.Until(openErr.RetryAt), 0))
 	}
 
-	// Parse payload
-	var payload executors.ExecutionContext
-	if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
-		log.Printf("  ❌ Failed to parse payload: %v\n", err)
-		w.outboxService.MarkMessageFailed(message.ID, fmt.Sprintf("Invalid payload: %v", err))
-		return
+	if result != nil && !result.Success && result.Permanent {
+		log.Printf("  ⛔ Message %s failed permanently: %s\n", message.ID, result.Error)
+		return w.failPermanently(message.ID, result.Error)
 	}
 
-	// Execute based on event type
-	var result *executors.ExecutionResult
-	var err error
-
-	switch message.EventType {
-	case "email.send":
-		result, err = w.executeEmail(ctx, payload)
-	case "http.request":
-		result, err = w.executeHTTP(ctx, payload)
-	case "slack.send":
-		result, err = w.executeSlack(ctx, payload)
-	default:
-		err = fmt.Errorf("unknown event type: %s", message.EventType)
+	if executors.IsPermanentError(err) {
+		log.Printf("  ⛔ Message %s failed permanently: %v\n", message.ID, err)
+		return w.failPermanently(message.ID, err.Error())
 	}
 
 	if err != nil {
 		log.Printf("  ❌ Message %s execution error: %v\n", message.ID, err)
-		w.outboxService.MarkMessageFailed(message.ID, err.Error())
-		return
+		return w.fail(message.ID, err.Error(), final)
 	}
 
 	if !result.Success {
 		log.Printf("  ❌ Message %s execution failed: %s\n", message.ID, result.Error)
-		w.outboxService.MarkMessageFailed(message.ID, result.Error)
-		return
+		return w.fail(message.ID, result.Error, final)
 	}
 
 	// Mark as completed
 	if err := w.outboxService.MarkMessageCompleted(message.ID, result.Output); err != nil {
-		log.Printf("  ❌ Failed to mark message as completed: %v\n", err)
-		return
+		return fmt.Errorf("failed to mark message %s as completed: %w", message.ID, err)
 	}
 
 	log.Printf("  ✅ Message %s completed successfully\n", message.ID)
-}
 
-// executeEmail executes an email node
-func (w *OutboxWorkerService) executeEmail(ctx context.Context, execCtx executors.ExecutionContext) (*executors.ExecutionResult, error) {
-	executor, err := w.executorFactory.GetExecutor("email")
-	if err != nil {
-		return nil, err
+	// A successful node retry can continue its workflow; the delivery itself is done either way
+	if message.ResumeExecution {
+		if err := w.outboxService.ResumeExecution(ctx, payload.ExecutionID); err != nil {
+			log.Printf("  ⚠️  Failed to resume execution %s after message %s: %v\n", payload.ExecutionID, message.ID, err)
+		}
 	}
-
-	return executor.Execute(ctx, execCtx)
+	return nil
 }
 
-// executeHTTP executes an HTTP node
-func (w *OutboxWorkerService) executeHTTP(ctx context.Context, execCtx executors.ExecutionContext) (*executors.ExecutionResult, error) {
-	executor, err := w.executorFactory.GetExecutor("http")
-	if err != nil {
-		return nil, err
+// fail records a failed attempt and returns the error River retries or discards the job with
+func (w *OutboxWorkerService) fail(messageID, errorMsg string, final bool) error {
+	if err := w.outboxService.MarkMessageFailed(messageID, errorMsg, final); err != nil {
+		log.Printf("  ❌ Failed to record failure of message %s: %v\n", messageID, err)
 	}
+	return errors.New(errorMsg)
+}
 
-	return executor.Execute(ctx, execCtx)
+// failPermanently dead letters a message whose failure retrying can't fix, and cancels its job
+func (w *OutboxWorkerService) failPermanently(messageID, errorMsg string) error {
+	return river.JobCancel(w.fail(messageID, "permanent failure: "+errorMsg, true))
 }
 
-// executeSlack executes a Slack node
-func (w *OutboxWorkerService) executeSlack(ctx context.Context, execCtx executors.ExecutionContext) (*executors.ExecutionResult, error) {
-	executor, err := w.executorFactory.GetExecutor("slack")
+// execute runs the executor of the node type registered for an outbox event type
+func (w *OutboxWorkerService) execute(ctx context.Context, eventType string, execCtx executors.ExecutionContext) (*executors.ExecutionResult, error) {
+	nodeType, ok := w.executorFactory.Registry().LookupEventType(eventType)
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %s", eventType)
+	}
+
+	executor, err := w.executorFactory.GetExecutor(nodeType.Type)
 	if err != nil {
 		return nil, err
 	}
@@ -195,12 +143,10 @@ func (w *OutboxWorkerService) GetStats(ctx context.Context) (map[string]interfac
 	}
 
 	stats := map[string]interface{}{
-		"is_running":            w.isRunning,
-		"poll_interval_seconds": w.pollInterval.Seconds(),
-		"pending_messages":      integrity["pending_messages"],
-		"processing_messages":   integrity["processing_messages"],
-		"completed_messages":    integrity["completed_messages"],
-		"dead_letter_messages":  integrity["dead_letter_messages"],
+		"pending_messages":     integrity["pending_messages"],
+		"processing_messages":  integrity["processing_messages"],
+		"completed_messages":   integrity["completed_messages"],
+		"dead_letter_messages": integrity["dead_letter_messages"],
 	}
 
 	return stats, nil
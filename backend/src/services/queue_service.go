@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	riverinternal "github.com/patali/yantra/src/river"
@@ -22,6 +23,11 @@ func NewQueueService(riverClient *river.Client[pgx.Tx]) *QueueService {
 
 // QueueWorkflowExecution queues a workflow for immediate execution
 func (s *QueueService) QueueWorkflowExecution(ctx context.Context, workflowID, executionID string, input map[string]interface{}, triggerType string) (string, error) {
+	return s.QueueWorkflowExecutionAt(ctx, workflowID, executionID, input, triggerType, time.Time{})
+}
+
+// QueueWorkflowExecutionAt queues a workflow to run at a time; a zero or past time runs it immediately
+func (s *QueueService) QueueWorkflowExecutionAt(ctx context.Context, workflowID, executionID string, input map[string]interface{}, triggerType string, runAt time.Time) (string, error) {
 	inputJSON, err := json.Marshal(input)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal input: %w", err)
@@ -32,7 +38,7 @@ func (s *QueueService) QueueWorkflowExecution(ctx context.Context, workflowID, e
 		ExecutionID: executionID,
 		Input:       string(inputJSON),
 		TriggerType: triggerType,
-	}, nil)
+	}, &river.InsertOpts{ScheduledAt: runAt})
 
 	if err != nil {
 		return "", fmt.Errorf("failed to insert job: %w", err)
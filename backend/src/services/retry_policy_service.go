@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/executors"
+	"github.com/patali/yantra/src/retry"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RetryPolicyService struct {
+	db *gorm.DB
+}
+
+func NewRetryPolicyService(db *gorm.DB) *RetryPolicyService {
+	return &RetryPolicyService{db: db}
+}
+
+// GetPolicies returns the account's default retry policies by node type
+func (s *RetryPolicyService) GetPolicies(ctx context.Context, accountID string) (map[string]retry.Policy, error) {
+	var stored []models.AccountRetryPolicy
+	if err := s.db.WithContext(ctx).Where("account_id = ?", accountID).Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load retry policies: %w", err)
+	}
+
+	policies := make(map[string]retry.Policy, len(stored))
+	for _, p := range stored {
+		policies[p.NodeType] = toRetryPolicy(p)
+	}
+	return policies, nil
+}
+
+// UpdatePolicy validates and stores the account's default retry policy for an async node type
+func (s *RetryPolicyService) UpdatePolicy(ctx context.Context, accountID, nodeType string, policy retry.Policy) (*retry.Policy, error) {
+	if !executors.SupportsAsync(nodeType) {
+		return nil, fmt.Errorf("'%s' is not an async node type; retry policies only apply to outbox deliveries", nodeType)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	stored := models.AccountRetryPolicy{
+		AccountID:      accountID,
+		NodeType:       nodeType,
+		MaxAttempts:    policy.MaxAttempts,
+		Backoff:        policy.Backoff,
+		InitialDelayMs: policy.InitialDelayMs,
+		MaxDelayMs:     policy.MaxDelayMs,
+		DeadlineMs:     policy.DeadlineMs,
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "account_id"}, {Name: "node_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_attempts", "backoff", "initial_delay_ms", "max_delay_ms", "deadline_ms", "updated_at"}),
+	}).Create(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to save retry policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// DeletePolicy removes the account's default retry policy for a node type
+func (s *RetryPolicyService) DeletePolicy(ctx context.Context, accountID, nodeType string) error {
+	if err := s.db.WithContext(ctx).
+		Where("account_id = ? AND node_type = ?", accountID, nodeType).
+		Delete(&models.AccountRetryPolicy{}).Error; err != nil {
+		return fmt.Errorf("failed to delete retry policy: %w", err)
+	}
+	return nil
+}
+
+// Resolve returns the retry policy of an outbox message: the node's config,
+// then the account default for the node type, then retry.Default()
+func (s *RetryPolicyService) Resolve(ctx context.Context, accountID *string, nodeType string, nodeConfig map[string]interface{}) (retry.Policy, error) {
+	policy, err := retry.FromConfig(nodeConfig)
+	if err != nil {
+		return retry.Policy{}, fmt.Errorf("invalid retry config: %w", err)
+	}
+
+	fallback := retry.Default()
+	if accountID != nil && *accountID != "" {
+		var stored models.AccountRetryPolicy
+		err := s.db.WithContext(ctx).
+			Where("account_id = ? AND node_type = ?", *accountID, nodeType).
+			First(&stored).Error
+		switch {
+		case err == nil:
+			fallback = toRetryPolicy(stored).Merge(fallback)
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return retry.Policy{}, fmt.Errorf("failed to load retry policy: %w", err)
+		}
+	}
+
+	return policy.Merge(fallback), nil
+}
+
+func toRetryPolicy(p models.AccountRetryPolicy) retry.Policy {
+	return retry.Policy{
+		MaxAttempts:    p.MaxAttempts,
+		Backoff:        p.Backoff,
+		InitialDelayMs: p.InitialDelayMs,
+		MaxDelayMs:     p.MaxDelayMs,
+		DeadlineMs:     p.DeadlineMs,
+	}
+}
@@ -3,34 +3,62 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/patali/yantra/src/db/models"
 	"github.com/robfig/cron/v3"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// scheduleTickTolerance is how late a cron tick may run and still be matched to its scheduled time
+const scheduleTickTolerance = time.Minute
+
 // SchedulerService manages cron-based workflow scheduling
 type SchedulerService struct {
 	db           *gorm.DB
 	queueService *QueueService
 	cron         *cron.Cron
 	schedules    map[string]cron.EntryID // workflowID -> cron entryID
+	entries      map[string]cron.EntryID // workflow schedule ID -> cron entryID
 	mu           sync.RWMutex
 	running      bool
 }
 
-// TimezoneSchedule wraps a cron.Schedule to execute in a specific timezone
+// TimezoneSchedule wraps a cron.Schedule to execute in a specific timezone, optionally within a time window
 type TimezoneSchedule struct {
 	schedule cron.Schedule
 	location *time.Location
+	start    *time.Time // No fire times before start (nil = unbounded)
+	end      *time.Time // No fire times after end (nil = unbounded)
 }
 
-// Next returns the next time the schedule should run, adjusted for timezone
+// Next returns the next time the schedule should run, adjusted for timezone.
+// It returns the zero time once the schedule's window has ended, which cron treats as never
 func (ts *TimezoneSchedule) Next(t time.Time) time.Time {
+	if ts.start != nil && t.Before(*ts.start) {
+		t = ts.start.Add(-time.Nanosecond)
+	}
+	next := ts.next(t)
+	if ts.end != nil && next.After(*ts.end) {
+		return time.Time{}
+	}
+	return next
+}
+
+func (ts *TimezoneSchedule) next(t time.Time) time.Time {
+	// @every schedules are aligned to multiples of their interval, so every replica fires at the same times
+	if every, ok := ts.schedule.(cron.ConstantDelaySchedule); ok {
+		return t.Truncate(every.Delay).Add(every.Delay)
+	}
+
 	// Convert current time to the target timezone
 	tInZone := t.In(ts.location)
 
@@ -48,6 +76,7 @@ func NewSchedulerService(db *gorm.DB, queueService *QueueService) *SchedulerServ
 		queueService: queueService,
 		cron:         cron.New(cron.WithSeconds()), // Support seconds-level precision
 		schedules:    make(map[string]cron.EntryID),
+		entries:      make(map[string]cron.EntryID),
 		running:      false,
 	}
 }
@@ -95,7 +124,8 @@ func (s *SchedulerService) Stop(ctx context.Context) error {
 	return nil
 }
 
-// loadSchedules loads all scheduled workflows from the database
+// loadSchedules loads all scheduled workflows from the database and catches up on runs missed while the
+// scheduler was down, following each schedule's misfire policy
 func (s *SchedulerService) loadSchedules(ctx context.Context) error {
 	var workflows []models.Workflow
 
@@ -107,99 +137,329 @@ func (s *SchedulerService) loadSchedules(ctx context.Context) error {
 		return err
 	}
 
+	now := time.Now()
 	for _, workflow := range workflows {
 		if err := s.addWorkflowSchedule(workflow.ID, *workflow.Schedule, workflow.Timezone); err != nil {
 			log.Printf("Failed to schedule workflow %s (%s): %v", workflow.ID, workflow.Name, err)
+			continue
 		}
+		entry, _ := newWorkflowScheduleEntry(workflow.ID, *workflow.Schedule, workflow.Timezone)
+		s.catchUpMissedRuns(ctx, entry, workflow.LastScheduledAt, workflow.MisfirePolicy, workflow.MaxMissedRuns, now)
+	}
+
+	rows, err := s.activeScheduleRows()
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		row := &rows[i]
+		if err := s.addScheduleRow(row); err != nil {
+			log.Printf("Failed to add schedule %s of workflow %s: %v", row.ID, row.WorkflowID, err)
+			continue
+		}
+		entry, _ := newScheduleRowEntry(row)
+		s.catchUpMissedRuns(ctx, entry, row.LastScheduledAt, row.MisfirePolicy, row.MaxMissedRuns, now)
 	}
 
 	return nil
 }
 
-// addWorkflowSchedule adds a workflow to the cron scheduler
-func (s *SchedulerService) addWorkflowSchedule(workflowID, cronExpr, timezone string) error {
-	// Remove existing schedule if it exists
-	s.removeWorkflowSchedule(workflowID)
+// activeScheduleRows returns the enabled workflow_schedules rows of active workflows
+func (s *SchedulerService) activeScheduleRows() ([]models.WorkflowSchedule, error) {
+	var rows []models.WorkflowSchedule
+	err := s.db.Joins("JOIN workflows ON workflows.id = workflow_schedules.workflow_id").
+		Where("workflow_schedules.enabled = ? AND workflows.is_active = ?", true, true).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow schedules: %w", err)
+	}
+	return rows, nil
+}
 
-	// Parse and validate cron expression
-	// River/standard cron format: "second minute hour day month weekday"
-	// For simplicity, we'll use standard 5-field cron and prepend "0" for seconds
-	if len(cronExpr) > 0 && !s.hasSixFields(cronExpr) {
-		cronExpr = "0 " + cronExpr // Add seconds field
+// catchUpMissedRuns starts the runs of a schedule entry that fell between its last started run and now.
+// An entry that has never fired has nothing to catch up on
+func (s *SchedulerService) catchUpMissedRuns(ctx context.Context, entry *scheduleEntry, last *time.Time, policy string, maxRuns int, now time.Time) {
+	if entry == nil || last == nil {
+		return
 	}
 
-	// Load timezone location
-	loc, err := time.LoadLocation(timezone)
-	if err != nil {
-		// Fallback to UTC if timezone is invalid
-		loc = time.UTC
+	runs, dropped := missedRuns(entry.schedule, *last, now, policy, maxRuns)
+	if len(runs) == 0 && dropped == 0 {
+		return
 	}
 
-	// Create the job function
-	job := func() {
-		ctx := context.Background()
+	log.Printf("⏰ Schedule %s of workflow %s missed %d run(s) since %s (policy: %s, starting %d)",
+		entry.key, entry.workflowID, len(runs)+dropped, last.Format(time.RFC3339), policy, len(runs))
 
-		// Get workflow and version info
-		var workflow models.Workflow
-		if err := s.db.First(&workflow, "id = ?", workflowID).Error; err != nil {
-			log.Printf("Failed to find workflow %s: %v", workflowID, err)
-			return
+	for _, scheduledAt := range runs {
+		if err := s.startScheduledRun(ctx, entry, scheduledAt, true); err != nil {
+			log.Printf("Failed to catch up run of workflow %s at %s: %v", entry.workflowID, scheduledAt.Format(time.RFC3339), err)
 		}
+	}
+}
 
-		var latestVersion models.WorkflowVersion
-		if err := s.db.Where("workflow_id = ?", workflowID).
-			Order("version DESC").
-			First(&latestVersion).Error; err != nil {
-			log.Printf("Failed to find version for workflow %s: %v", workflowID, err)
-			return
+// missedRunsLookback bounds how far back the scheduler looks for missed runs
+const missedRunsLookback = 7 * 24 * time.Hour
+
+// missedRuns returns the fire times after last and at or before now that a misfire policy starts, oldest
+// first, and how many missed fire times the policy drops
+func missedRuns(schedule cron.Schedule, last, now time.Time, policy string, maxRuns int) ([]time.Time, int) {
+	limit := 0
+	switch policy {
+	case models.MisfireRunOnce:
+		limit = 1
+	case models.MisfireRunAll:
+		limit = maxRuns
+		if limit <= 0 {
+			limit = models.DefaultMaxMissedRuns
 		}
-
-		// Create execution record
-		execution := models.WorkflowExecution{
-			WorkflowID:  workflowID,
-			Version:     latestVersion.Version,
-			Status:      "queued",
-			TriggerType: models.TriggerTypeScheduled,
+		if limit > models.MaxMissedRunsLimit {
+			limit = models.MaxMissedRunsLimit
 		}
+	}
 
-		if err := s.db.Create(&execution).Error; err != nil {
-			log.Printf("Failed to create execution record for workflow %s: %v", workflowID, err)
-			return
-		}
+	from := last
+	if earliest := now.Add(-missedRunsLookback); from.Before(earliest) {
+		from = earliest
+	}
 
-		_, err := s.queueService.QueueWorkflowExecution(ctx, workflowID, execution.ID, map[string]interface{}{}, "scheduled")
-		if err != nil {
-			log.Printf("Failed to queue scheduled workflow %s: %v", workflowID, err)
-			// Mark execution as failed
-			s.db.Model(&execution).Updates(map[string]interface{}{
-				"status": "error",
-				"error":  "Failed to queue for execution",
-			})
+	// Keep only the most recent fire times; frequent schedules can miss many runs during a long outage
+	var runs []time.Time
+	dropped := 0
+	for t := schedule.Next(from); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		runs = append(runs, t)
+		if len(runs) > limit {
+			runs = runs[1:]
+			dropped++
 		}
 	}
+	return runs, dropped
+}
+
+// newTimezoneSchedule parses a workflow's cron expression into a schedule evaluated in its timezone
+func newTimezoneSchedule(cronExpr, timezone string) (*TimezoneSchedule, error) {
+	// Load timezone location
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		// Fallback to UTC if timezone is invalid
+		loc = time.UTC
+	}
 
 	// Parse the cron expression with timezone awareness
-	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
-	schedule, err := parser.Parse(cronExpr)
+	schedule, err := ParseCronExpression(cronExpr)
 	if err != nil {
-		return fmt.Errorf("invalid cron expression '%s': %w", cronExpr, err)
+		return nil, err
 	}
 
-	// Create a timezone-aware schedule wrapper
-	timezoneSchedule := &TimezoneSchedule{
+	return &TimezoneSchedule{
 		schedule: schedule,
 		location: loc,
+	}, nil
+}
+
+// scheduleEntry is a cron entry that starts runs of a workflow: the workflow's own schedule or one of its
+// workflow_schedules rows
+type scheduleEntry struct {
+	key        string  // Workflow ID for the workflow's own schedule, schedule ID otherwise
+	workflowID string  // Workflow the entry runs
+	scheduleID *string // workflow_schedules row, nil for the workflow's own schedule
+	input      map[string]interface{}
+	jitter     time.Duration
+	schedule   *TimezoneSchedule
+}
+
+// newWorkflowScheduleEntry builds the entry of a workflow's own schedule
+func newWorkflowScheduleEntry(workflowID, cronExpr, timezone string) (*scheduleEntry, error) {
+	schedule, err := newTimezoneSchedule(cronExpr, timezone)
+	if err != nil {
+		return nil, err
+	}
+	return &scheduleEntry{key: workflowID, workflowID: workflowID, schedule: schedule}, nil
+}
+
+// newScheduleRowEntry builds the entry of a workflow_schedules row
+func newScheduleRowEntry(row *models.WorkflowSchedule) (*scheduleEntry, error) {
+	schedule, err := newTimezoneSchedule(row.CronExpression, row.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	schedule.start = row.StartAt
+	schedule.end = row.EndAt
+
+	var input map[string]interface{}
+	if row.Input != "" {
+		if err := json.Unmarshal([]byte(row.Input), &input); err != nil {
+			return nil, fmt.Errorf("invalid schedule input: %w", err)
+		}
 	}
 
-	// Add to cron scheduler with timezone-aware schedule
-	entryID := s.cron.Schedule(timezoneSchedule, cron.FuncJob(job))
+	scheduleID := row.ID
+	return &scheduleEntry{
+		key:        row.ID,
+		workflowID: row.WorkflowID,
+		scheduleID: &scheduleID,
+		input:      input,
+		jitter:     time.Duration(row.JitterSeconds) * time.Second,
+		schedule:   schedule,
+	}, nil
+}
+
+// addWorkflowSchedule adds a workflow to the cron scheduler
+func (s *SchedulerService) addWorkflowSchedule(workflowID, cronExpr, timezone string) error {
+	// Remove existing schedule if it exists
+	s.removeWorkflowSchedule(workflowID)
+
+	// Create a timezone-aware schedule wrapper
+	entry, err := newWorkflowScheduleEntry(workflowID, cronExpr, timezone)
+	if err != nil {
+		return err
+	}
 
 	// Store mapping
-	s.schedules[workflowID] = entryID
+	s.schedules[workflowID] = s.scheduleEntry(entry)
 
 	return nil
 }
 
+// addScheduleRow adds a workflow_schedules row to the cron scheduler
+func (s *SchedulerService) addScheduleRow(row *models.WorkflowSchedule) error {
+	s.removeScheduleRow(row.ID)
+
+	entry, err := newScheduleRowEntry(row)
+	if err != nil {
+		return err
+	}
+
+	s.entries[row.ID] = s.scheduleEntry(entry)
+	return nil
+}
+
+// scheduleEntry adds an entry to cron with its timezone-aware schedule
+func (s *SchedulerService) scheduleEntry(entry *scheduleEntry) cron.EntryID {
+	return s.cron.Schedule(entry.schedule, cron.FuncJob(func() {
+		s.runScheduledWorkflow(entry)
+	}))
+}
+
+// runScheduledWorkflow starts the run of a schedule entry that a cron tick belongs to
+func (s *SchedulerService) runScheduledWorkflow(entry *scheduleEntry) {
+	scheduledAt := scheduledTime(entry.schedule, time.Now())
+	if err := s.startScheduledRun(context.Background(), entry, scheduledAt, false); err != nil {
+		log.Printf("Failed to start scheduled run of workflow %s: %v", entry.workflowID, err)
+	}
+}
+
+// startScheduledRun starts a workflow's run for a scheduled time, passing the time in the execution input.
+// Every replica fires the same cron entries; the run's execution ID is derived from the entry and the
+// scheduled time, so only the first replica to insert it queues the run
+func (s *SchedulerService) startScheduledRun(ctx context.Context, entry *scheduleEntry, scheduledAt time.Time, catchUp bool) error {
+	workflowID := entry.workflowID
+
+	// Get workflow and version info
+	var workflow models.Workflow
+	if err := s.db.First(&workflow, "id = ?", workflowID).Error; err != nil {
+		return fmt.Errorf("failed to find workflow: %w", err)
+	}
+
+	var latestVersion models.WorkflowVersion
+	if err := s.db.Where("workflow_id = ?", workflowID).
+		Order("version DESC").
+		First(&latestVersion).Error; err != nil {
+		return fmt.Errorf("failed to find workflow version: %w", err)
+	}
+
+	input := make(map[string]interface{}, len(entry.input)+3)
+	for k, v := range entry.input {
+		input[k] = v
+	}
+	input["scheduledAt"] = scheduledAt.UTC().Format(time.RFC3339)
+	input["catchUp"] = catchUp
+	if entry.scheduleID != nil {
+		input["scheduleId"] = *entry.scheduleID
+	}
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to serialize input: %w", err)
+	}
+	inputStr := string(inputJSON)
+
+	// Create execution record; a conflict means another replica already started this run
+	execution := models.WorkflowExecution{
+		ID:          scheduledExecutionID(entry.key, scheduledAt),
+		WorkflowID:  workflowID,
+		Version:     latestVersion.Version,
+		Status:      "queued",
+		TriggerType: models.TriggerTypeScheduled,
+		Input:       &inputStr,
+		ScheduledAt: &scheduledAt,
+		ScheduleID:  entry.scheduleID,
+	}
+
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&execution)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create execution record: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("⏭️  Scheduled run of workflow %s at %s was started by another instance", workflowID, scheduledAt.Format(time.RFC3339))
+		return nil
+	}
+
+	// Record the run so a restart knows which runs it missed; never move the mark backwards
+	lastRun := s.db.Model(&models.Workflow{}).Where("id = ?", workflowID)
+	if entry.scheduleID != nil {
+		lastRun = s.db.Model(&models.WorkflowSchedule{}).Where("id = ?", *entry.scheduleID)
+	}
+	if err := lastRun.Where("last_scheduled_at IS NULL OR last_scheduled_at < ?", scheduledAt).
+		Update("last_scheduled_at", scheduledAt).Error; err != nil {
+		log.Printf("⚠️  Failed to record last scheduled run of workflow %s: %v", workflowID, err)
+	}
+
+	runAt := scheduledAt.Add(scheduleJitter(entry.key, scheduledAt, entry.jitter))
+	if _, err := s.queueService.QueueWorkflowExecutionAt(ctx, workflowID, execution.ID, input, models.TriggerTypeScheduled, runAt); err != nil {
+		// Mark execution as failed
+		s.db.Model(&execution).Updates(map[string]interface{}{
+			"status": "error",
+			"error":  "Failed to queue for execution",
+		})
+		return fmt.Errorf("failed to queue scheduled workflow: %w", err)
+	}
+	return nil
+}
+
+// scheduleJitter returns how long a run waits after its scheduled time, below max.
+// It is derived from the entry and the scheduled time, so every replica picks the same delay
+func scheduleJitter(scheduleKey string, scheduledAt time.Time, max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(scheduledExecutionID(scheduleKey, scheduledAt)))
+	return time.Duration(h.Sum64()%uint64(max/time.Second)) * time.Second
+}
+
+// scheduledRunNamespace namespaces the name-based UUIDs of scheduled executions
+var scheduledRunNamespace = uuid.MustParse("5b0f7c3e-8d2a-4e61-9f3b-2c7a1d4e6b90")
+
+// scheduledExecutionID returns the execution ID of a schedule's run at a scheduled time.
+// It is the same on every replica, so the executions table's primary key lets one run through
+func scheduledExecutionID(scheduleKey string, scheduledAt time.Time) string {
+	name := scheduleKey + "@" + scheduledAt.UTC().Format(time.RFC3339)
+	return uuid.NewSHA1(scheduledRunNamespace, []byte(name)).String()
+}
+
+// scheduledTime returns the time a cron tick at now was scheduled for: the last time the schedule fires at
+// or before now. Replicas whose timers fire a little apart still agree on it
+func scheduledTime(schedule cron.Schedule, now time.Time) time.Time {
+	t := schedule.Next(now.Add(-scheduleTickTolerance))
+	if t.IsZero() || t.After(now) {
+		return now.Truncate(time.Second)
+	}
+	for next := schedule.Next(t); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		t = next
+	}
+	return t
+}
+
 // removeWorkflowSchedule removes a workflow from the cron scheduler
 func (s *SchedulerService) removeWorkflowSchedule(workflowID string) {
 	if entryID, exists := s.schedules[workflowID]; exists {
@@ -208,6 +468,34 @@ func (s *SchedulerService) removeWorkflowSchedule(workflowID string) {
 	}
 }
 
+// removeScheduleRow removes a workflow_schedules row from the cron scheduler
+func (s *SchedulerService) removeScheduleRow(scheduleID string) {
+	if entryID, exists := s.entries[scheduleID]; exists {
+		s.cron.Remove(entryID)
+		delete(s.entries, scheduleID)
+	}
+}
+
+// RefreshScheduleRow re-reads a workflow_schedules row and adds, updates or removes its cron entry.
+// Rows that are gone, disabled or belong to an inactive workflow are removed
+func (s *SchedulerService) RefreshScheduleRow(scheduleID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var row models.WorkflowSchedule
+	err := s.db.Joins("JOIN workflows ON workflows.id = workflow_schedules.workflow_id").
+		Where("workflow_schedules.id = ? AND workflow_schedules.enabled = ? AND workflows.is_active = ?", scheduleID, true, true).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.removeScheduleRow(scheduleID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load schedule: %w", err)
+	}
+	return s.addScheduleRow(&row)
+}
+
 // AddSchedule adds or updates a workflow schedule
 func (s *SchedulerService) AddSchedule(workflowID, cronExpr, timezone string) error {
 	s.mu.Lock()
@@ -304,6 +592,28 @@ func (s *SchedulerService) syncSchedules(ctx context.Context) error {
 		}
 	}
 
+	rows, err := s.activeScheduleRows()
+	if err != nil {
+		return err
+	}
+
+	// Remove schedule rows that were deleted, disabled or whose workflow was deactivated
+	dbRows := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		dbRows[row.ID] = true
+	}
+	for scheduleID := range s.entries {
+		if !dbRows[scheduleID] {
+			s.removeScheduleRow(scheduleID)
+		}
+	}
+
+	for i := range rows {
+		if err := s.addScheduleRow(&rows[i]); err != nil {
+			log.Printf("Failed to sync schedule %s of workflow %s: %v", rows[i].ID, rows[i].WorkflowID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -314,27 +624,20 @@ func (s *SchedulerService) scheduleChanged(workflowID, newCron string) bool {
 	return true
 }
 
-// hasSixFields checks if a cron expression has 6 fields (includes seconds)
-func (s *SchedulerService) hasSixFields(cronExpr string) bool {
-	fields := 0
-	inField := false
-
-	for _, char := range cronExpr {
-		if char == ' ' {
-			if inField {
-				fields++
-				inField = false
-			}
-		} else {
-			inField = true
-		}
+// ParseCronExpression parses a workflow schedule
+// Standard 5-field expressions get a "0" seconds field prepended; descriptors (@daily, @every 1h) are passed through
+func ParseCronExpression(cronExpr string) (cron.Schedule, error) {
+	expr := strings.TrimSpace(cronExpr)
+	if expr != "" && !strings.HasPrefix(expr, "@") && len(strings.Fields(expr)) < 6 {
+		expr = "0 " + expr // Add seconds field
 	}
 
-	if inField {
-		fields++
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	schedule, err := parser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': %w", cronExpr, err)
 	}
-
-	return fields >= 6
+	return schedule, nil
 }
 
 // ScheduleSleepWakeUp schedules a one-time wake-up for a sleeping workflow
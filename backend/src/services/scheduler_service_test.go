@@ -209,3 +209,97 @@ func TestSchedulerService_LoadSchedules(t *testing.T) {
 	assert.Contains(t, workflows, workflowID2)
 	assert.NotContains(t, workflows, workflowID3) // inactive workflow
 }
+
+func TestScheduledTime(t *testing.T) {
+	schedule, err := ParseCronExpression("*/5 * * * *")
+	assert.NoError(t, err)
+	due := time.Date(2026, 3, 1, 9, 5, 0, 0, time.UTC)
+
+	// Replicas whose timers fire a little apart agree on the scheduled time
+	assert.Equal(t, due, scheduledTime(schedule, due))
+	assert.Equal(t, due, scheduledTime(schedule, due.Add(1500*time.Millisecond)))
+	assert.Equal(t, scheduledExecutionID("wf-1", due), scheduledExecutionID("wf-1", scheduledTime(schedule, due.Add(2*time.Second))))
+
+	assert.NotEqual(t, scheduledExecutionID("wf-1", due), scheduledExecutionID("wf-1", due.Add(5*time.Minute)))
+	assert.NotEqual(t, scheduledExecutionID("wf-1", due), scheduledExecutionID("wf-2", due))
+}
+
+func TestTimezoneSchedule_EveryIsAligned(t *testing.T) {
+	schedule, err := ParseCronExpression("@every 15m")
+	assert.NoError(t, err)
+	ts := &TimezoneSchedule{schedule: schedule, location: time.UTC}
+
+	// Replicas started at different times fire at the same times
+	expected := time.Date(2026, 3, 1, 9, 15, 0, 0, time.UTC)
+	assert.Equal(t, expected, ts.Next(time.Date(2026, 3, 1, 9, 1, 12, 0, time.UTC)))
+	assert.Equal(t, expected, ts.Next(time.Date(2026, 3, 1, 9, 14, 59, 0, time.UTC)))
+	assert.Equal(t, expected.Add(15*time.Minute), ts.Next(expected))
+}
+
+func TestMissedRuns(t *testing.T) {
+	schedule, err := newTimezoneSchedule("0 9 * * *", "UTC")
+	assert.NoError(t, err)
+	last := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	runs, dropped := missedRuns(schedule, last, now, models.MisfireSkip, 10)
+	assert.Empty(t, runs)
+	assert.Equal(t, 3, dropped)
+
+	runs, dropped = missedRuns(schedule, last, now, models.MisfireRunOnce, 10)
+	assert.Equal(t, []time.Time{time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)}, runs)
+	assert.Equal(t, 2, dropped)
+
+	runs, dropped = missedRuns(schedule, last, now, models.MisfireRunAll, 10)
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC),
+	}, runs)
+	assert.Equal(t, 0, dropped)
+
+	// run_all keeps the most recent runs up to the cap
+	runs, dropped = missedRuns(schedule, last, now, models.MisfireRunAll, 2)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC), runs[0])
+	assert.Equal(t, 1, dropped)
+
+	// Nothing was missed when the last run is the latest fire time
+	runs, dropped = missedRuns(schedule, time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC), now, models.MisfireRunAll, 10)
+	assert.Empty(t, runs)
+	assert.Equal(t, 0, dropped)
+
+	// Outages longer than the lookback only catch up on its window
+	runs, _ = missedRuns(schedule, last.AddDate(0, -1, 0), now, models.MisfireRunAll, 100)
+	assert.Len(t, runs, 7)
+}
+
+func TestTimezoneSchedule_Window(t *testing.T) {
+	schedule, err := newTimezoneSchedule("0 9 * * *", "UTC")
+	assert.NoError(t, err)
+	start := time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	schedule.start = &start
+	schedule.end = &end
+
+	// The start time itself can fire; nothing fires after the end
+	assert.Equal(t, start, schedule.Next(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, start.AddDate(0, 0, 1), schedule.Next(start))
+	assert.True(t, schedule.Next(start.AddDate(0, 0, 1)).IsZero())
+
+	// Missed runs stop at the end of the window
+	runs, _ := missedRuns(schedule, start, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), models.MisfireRunAll, 10)
+	assert.Equal(t, []time.Time{start.AddDate(0, 0, 1)}, runs)
+}
+
+func TestScheduleJitter(t *testing.T) {
+	due := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), scheduleJitter("schedule-1", due, 0))
+
+	// Every replica picks the same delay, within the limit
+	jitter := scheduleJitter("schedule-1", due, 10*time.Minute)
+	assert.Equal(t, jitter, scheduleJitter("schedule-1", due, 10*time.Minute))
+	assert.GreaterOrEqual(t, jitter, time.Duration(0))
+	assert.Less(t, jitter, 10*time.Minute)
+}
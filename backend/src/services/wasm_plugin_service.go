@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
+	"gorm.io/gorm"
+)
+
+// wasmPluginNamePattern keeps plugin names usable as node config values and in logs
+var wasmPluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// wasmPluginColumns are the columns loaded when the module bytes aren't needed
+var wasmPluginColumns = []string{
+	"id", "account_id", "name", "description", "sha256", "size_bytes",
+	"memory_limit_mb", "fuel_limit", "timeout_ms", "allow_http", "created_by", "created_at", "updated_at",
+}
+
+// WasmPluginService manages uploaded WASM plugins
+// Also used as the executors.WasmPluginStore
+type WasmPluginService struct {
+	db      *gorm.DB
+	runtime *executors.WasmRuntime
+}
+
+func NewWasmPluginService(db *gorm.DB, runtime *executors.WasmRuntime) *WasmPluginService {
+	return &WasmPluginService{db: db, runtime: runtime}
+}
+
+// ListPlugins returns the account's plugins without their modules
+func (s *WasmPluginService) ListPlugins(ctx context.Context, accountID string) ([]models.WasmPlugin, error) {
+	plugins := []models.WasmPlugin{}
+	if err := s.db.WithContext(ctx).Select(wasmPluginColumns).
+		Where("account_id = ?", accountID).Order("name").Find(&plugins).Error; err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	return plugins, nil
+}
+
+// GetPluginByID returns one of the account's plugins without its module
+func (s *WasmPluginService) GetPluginByID(ctx context.Context, accountID, id string) (*models.WasmPlugin, error) {
+	var plugin models.WasmPlugin
+	if err := s.db.WithContext(ctx).Select(wasmPluginColumns).
+		Where("id = ? AND account_id = ?", id, accountID).First(&plugin).Error; err != nil {
+		return nil, fmt.Errorf("plugin not found: %w", err)
+	}
+	return &plugin, nil
+}
+
+// CreatePlugin validates and stores a new plugin
+func (s *WasmPluginService) CreatePlugin(ctx context.Context, accountID, userID string, req dto.CreateWasmPluginRequest) (*models.WasmPlugin, error) {
+	if !wasmPluginNamePattern.MatchString(req.Name) {
+		return nil, fmt.Errorf("name must be 1-63 lowercase letters, digits, '-' or '_'")
+	}
+	if err := s.validateLimits(req.MemoryLimitMB, req.FuelLimit, req.TimeoutMs); err != nil {
+		return nil, err
+	}
+	if err := s.runtime.Validate(ctx, req.Module); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	s.db.WithContext(ctx).Model(&models.WasmPlugin{}).Where("account_id = ? AND name = ?", accountID, req.Name).Count(&existing)
+	if existing > 0 {
+		return nil, fmt.Errorf("a plugin named %s already exists", req.Name)
+	}
+
+	plugin := models.WasmPlugin{
+		AccountID:     accountID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Module:        req.Module,
+		SHA256:        executors.WasmModuleHash(req.Module),
+		SizeBytes:     len(req.Module),
+		MemoryLimitMB: req.MemoryLimitMB,
+		FuelLimit:     req.FuelLimit,
+		TimeoutMs:     req.TimeoutMs,
+		AllowHTTP:     req.AllowHTTP,
+		CreatedBy:     userID,
+	}
+	if err := s.db.WithContext(ctx).Create(&plugin).Error; err != nil {
+		return nil, fmt.Errorf("failed to save plugin: %w", err)
+	}
+	plugin.Module = nil
+	return &plugin, nil
+}
+
+// UpdatePlugin replaces a plugin's module or settings
+func (s *WasmPluginService) UpdatePlugin(ctx context.Context, accountID, id string, req dto.UpdateWasmPluginRequest) (*models.WasmPlugin, error) {
+	plugin, err := s.GetPluginByID(ctx, accountID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if req.Description != nil {
+		updates["description"] = req.Description
+	}
+	if req.MemoryLimitMB != nil {
+		plugin.MemoryLimitMB = *req.MemoryLimitMB
+		updates["memory_limit_mb"] = *req.MemoryLimitMB
+	}
+	if req.FuelLimit != nil {
+		plugin.FuelLimit = *req.FuelLimit
+		updates["fuel_limit"] = *req.FuelLimit
+	}
+	if req.TimeoutMs != nil {
+		plugin.TimeoutMs = *req.TimeoutMs
+		updates["timeout_ms"] = *req.TimeoutMs
+	}
+	if req.AllowHTTP != nil {
+		updates["allow_http"] = *req.AllowHTTP
+	}
+	if err := s.validateLimits(plugin.MemoryLimitMB, plugin.FuelLimit, plugin.TimeoutMs); err != nil {
+		return nil, err
+	}
+	if len(req.Module) > 0 {
+		if err := s.runtime.Validate(ctx, req.Module); err != nil {
+			return nil, err
+		}
+		// Compiled modules are cached by hash, so running nodes pick up the new module on their next run
+		updates["module"] = req.Module
+		updates["sha256"] = executors.WasmModuleHash(req.Module)
+		updates["size_bytes"] = len(req.Module)
+	}
+	if len(updates) == 0 {
+		return plugin, nil
+	}
+
+	if err := s.db.WithContext(ctx).Model(&models.WasmPlugin{}).
+		Where("id = ? AND account_id = ?", id, accountID).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update plugin: %w", err)
+	}
+	return s.GetPluginByID(ctx, accountID, id)
+}
+
+// DeletePlugin removes a plugin; workflows still referencing it fail at the wasm node
+func (s *WasmPluginService) DeletePlugin(ctx context.Context, accountID, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND account_id = ?", id, accountID).Delete(&models.WasmPlugin{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete plugin: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("plugin not found: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// GetPlugin implements executors.WasmPluginStore
+func (s *WasmPluginService) GetPlugin(ctx context.Context, accountID, name string) (*executors.WasmPlugin, error) {
+	var plugin models.WasmPlugin
+	err := s.db.WithContext(ctx).Select(wasmPluginColumns).
+		Where("account_id = ? AND name = ?", accountID, name).First(&plugin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("plugin %s not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugin: %w", err)
+	}
+
+	return &executors.WasmPlugin{
+		ID:   plugin.ID,
+		Name: plugin.Name,
+		Hash: plugin.SHA256,
+		Limits: executors.WasmLimits{
+			MemoryLimitMB: plugin.MemoryLimitMB,
+			Fuel:          plugin.FuelLimit,
+			Timeout:       time.Duration(plugin.TimeoutMs) * time.Millisecond,
+		},
+		AllowHTTP: plugin.AllowHTTP,
+	}, nil
+}
+
+// GetModule implements executors.WasmPluginStore
+func (s *WasmPluginService) GetModule(ctx context.Context, pluginID string) ([]byte, error) {
+	var plugin models.WasmPlugin
+	if err := s.db.WithContext(ctx).Select("module").Where("id = ?", pluginID).First(&plugin).Error; err != nil {
+		return nil, fmt.Errorf("failed to load plugin module: %w", err)
+	}
+	return plugin.Module, nil
+}
+
+// validateLimits rejects negative limits and limits above the server maximums (0 means the maximum)
+func (s *WasmPluginService) validateLimits(memoryLimitMB int, fuel int64, timeoutMs int) error {
+	max := s.runtime.Limits()
+	if memoryLimitMB < 0 || memoryLimitMB > max.MemoryLimitMB {
+		return fmt.Errorf("memoryLimitMb must be between 0 and %d", max.MemoryLimitMB)
+	}
+	if fuel < 0 || fuel > max.Fuel {
+		return fmt.Errorf("fuelLimit must be between 0 and %d", max.Fuel)
+	}
+	if timeoutMs < 0 || time.Duration(timeoutMs)*time.Millisecond > max.Timeout {
+		return fmt.Errorf("timeoutMs must be between 0 and %d", max.Timeout.Milliseconds())
+	}
+	return nil
+}
@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/PaesslerAG/gval"
 	"github.com/patali/yantra/src/db/models"
 	"github.com/patali/yantra/src/executors"
+	"github.com/patali/yantra/src/templating"
+	"github.com/patali/yantra/src/workflows"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Abuse prevention limits
@@ -20,6 +24,7 @@ const (
 	MaxExecutionDuration = 30 * time.Minute // Maximum workflow execution time
 	MaxTotalNodes        = 10000            // Maximum total nodes executed in a workflow
 	MaxLoopDepth         = 5                // Maximum nested loop depth
+	MaxLoopConcurrency   = 20               // Maximum loop iterations running in parallel
 	MaxIterations        = 10000            // Global maximum iterations per loop
 	MaxAccumulatorSize   = 10 * 1024 * 1024 // 10MB max accumulated data size
 	MaxDataSize          = 10 * 1024 * 1024 // 10MB max input/output size
@@ -30,21 +35,46 @@ type WorkflowEngineService struct {
 	executorFactory  *executors.ExecutorFactory
 	outboxService    *OutboxService
 	schedulerService *SchedulerService // Optional: for sleep node support
+	definitions      *workflows.Cache  // Compiled definitions by workflow version ID
+	environments     *EnvironmentService
 }
 
-// executionLimits tracks execution limits to prevent abuse
+// executionLimits tracks execution limits to prevent abuse.
+// The node count is shared by concurrent loop iterations; each of them works on a fork with its own depth
 type executionLimits struct {
-	nodesExecuted int
+	nodesExecuted *atomic.Int64
 	currentDepth  int
 	startTime     time.Time
 }
 
+func newExecutionLimits(nodesExecuted int64, startTime time.Time) *executionLimits {
+	limits := &executionLimits{
+		nodesExecuted: &atomic.Int64{},
+		startTime:     startTime,
+	}
+	limits.nodesExecuted.Store(nodesExecuted)
+	return limits
+}
+
+// countNode records one more executed node
+func (l *executionLimits) countNode() {
+	l.nodesExecuted.Add(1)
+}
+
+// fork returns limits for a concurrent loop iteration: same node count and start time, separate depth
+func (l *executionLimits) fork() *executionLimits {
+	forked := *l
+	return &forked
+}
+
 func NewWorkflowEngineService(db *gorm.DB, emailService executors.EmailServiceInterface) *WorkflowEngineService {
 	return &WorkflowEngineService{
 		db:               db,
 		executorFactory:  executors.NewExecutorFactory(db, emailService),
 		outboxService:    NewOutboxService(db),
 		schedulerService: nil, // Set later via SetSchedulerService to avoid circular dependency
+		definitions:      workflows.NewCache(workflows.DefaultCacheSize),
+		environments:     NewEnvironmentService(db),
 	}
 }
 
@@ -53,6 +83,11 @@ func (s *WorkflowEngineService) SetSchedulerService(schedulerService *SchedulerS
 	s.schedulerService = schedulerService
 }
 
+// SetOutboxService replaces the engine's outbox service with one connected to River (called after initialization)
+func (s *WorkflowEngineService) SetOutboxService(outboxService *OutboxService) {
+	s.outboxService = outboxService
+}
+
 // checkDataSize validates that data size is within limits
 func checkDataSize(data interface{}, dataType string) error {
 	jsonData, err := json.Marshal(data)
@@ -69,69 +104,59 @@ func checkDataSize(data interface{}, dataType string) error {
 }
 
 // checkEdgeCondition evaluates an edge condition to determine if the target node should execute
-func (s *WorkflowEngineService) checkEdgeCondition(edges []interface{}, sourceNodeID, targetNodeID string, nodeOutputs map[string]interface{}) bool {
-	// Find the edge between source and target
-	for _, edgeData := range edges {
-		edge, ok := edgeData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		source, _ := edge["source"].(string)
-		target, _ := edge["target"].(string)
-
-		// Check if this is the edge we're looking for
-		if source == sourceNodeID && target == targetNodeID {
-			// Check if there's a condition on this edge
-			condition, hasCondition := edge["condition"].(string)
-			if !hasCondition || condition == "" {
-				// No condition means always execute
-				return true
-			}
-
-			// Build evaluation context with node outputs
-			evalContext := make(map[string]interface{})
-
-			// Add source node output to context
-			if sourceOutput, ok := nodeOutputs[sourceNodeID].(map[string]interface{}); ok {
-				// Add as "data" for easy access to conditional results
-				evalContext["data"] = sourceOutput
-
-				// Also add individual fields to root level (but skip "data" to avoid overwriting)
-				for k, v := range sourceOutput {
-					// Don't overwrite the "data" key we just set above
-					if k != "data" {
-						evalContext[k] = v
-					}
-				}
-			}
-
-			// Add all node outputs for access via nodeId.field
-			for nodeID, output := range nodeOutputs {
-				evalContext[nodeID] = output
+func (s *WorkflowEngineService) checkEdgeCondition(def *workflows.Definition, sourceNodeID, targetNodeID string, nodeOutputs map[string]interface{}) bool {
+	// No edge or no condition means always execute
+	edge, ok := def.EdgeBetween(sourceNodeID, targetNodeID)
+	if !ok || edge.ConditionSource == "" {
+		return true
+	}
+	condition := edge.ConditionSource
+	if edge.ConditionErr != nil {
+		log.Printf("  ⚠️  Failed to evaluate edge condition '%s': %v (defaulting to false)", condition, edge.ConditionErr)
+		return false
+	}
+
+	// Build evaluation context with node outputs
+	evalContext := make(map[string]interface{})
+
+	// Add source node output to context
+	if sourceOutput, ok := nodeOutputs[sourceNodeID].(map[string]interface{}); ok {
+		// Add as "data" for easy access to conditional results
+		evalContext["data"] = sourceOutput
+
+		// Also add individual fields to root level (but skip "data" to avoid overwriting)
+		for k, v := range sourceOutput {
+			// Don't overwrite the "data" key we just set above
+			if k != "data" {
+				evalContext[k] = v
 			}
+		}
+	}
 
-			// Evaluate the condition
-			result, err := gval.Evaluate(condition, evalContext)
-			if err != nil {
-				log.Printf("  ⚠️  Failed to evaluate edge condition '%s': %v (defaulting to false)", condition, err)
-				return false
-			}
+	// Add all node outputs for access via nodeId.field
+	for nodeID, output := range nodeOutputs {
+		evalContext[nodeID] = output
+	}
 
-			// Convert to boolean
-			boolResult, ok := result.(bool)
-			if !ok {
-				log.Printf("  ⚠️  Edge condition '%s' did not evaluate to boolean (got %T), defaulting to false", condition, result)
-				return false
-			}
+	// Evaluate the pre-parsed condition with the shared expression language
+	result, err := edge.Condition.Eval(templating.Context{
+		NodeOutputs: nodeOutputs,
+		Locals:      evalContext,
+	})
+	if err != nil {
+		log.Printf("  ⚠️  Failed to evaluate edge condition '%s': %v (defaulting to false)", condition, err)
+		return false
+	}
 
-			log.Printf("  🔍 Edge condition '%s' evaluated to: %v", condition, boolResult)
-			return boolResult
-		}
+	// Convert to boolean
+	boolResult, ok := result.(bool)
+	if !ok {
+		log.Printf("  ⚠️  Edge condition '%s' did not evaluate to boolean (got %T), defaulting to false", condition, result)
+		return false
 	}
 
-	// No edge found or no condition - default to true
-	return true
+	log.Printf("  🔍 Edge condition '%s' evaluated to: %v", condition, boolResult)
+	return boolResult
 }
 
 // checkExecutionLimits validates execution hasn't exceeded limits
@@ -159,8 +184,8 @@ func (s *WorkflowEngineService) checkExecutionLimits(ctx context.Context, limits
 	}
 
 	// Check node count
-	if limits.nodesExecuted > MaxTotalNodes {
-		return fmt.Errorf("workflow exceeded maximum node executions (%d > %d)", limits.nodesExecuted, MaxTotalNodes)
+	if nodesExecuted := limits.nodesExecuted.Load(); nodesExecuted > MaxTotalNodes {
+		return fmt.Errorf("workflow exceeded maximum node executions (%d > %d)", nodesExecuted, MaxTotalNodes)
 	}
 
 	// Check depth
@@ -189,10 +214,16 @@ func (s *WorkflowEngineService) ExecuteWorkflow(ctx context.Context, workflowID,
 
 	isResuming := len(completedNodes) > 0
 
+	// A run continuing after a node retry gets a fresh time budget; the time the execution sat failed doesn't count
+	budgetStart := execution.StartedAt
+	if triggerType == models.TriggerTypeNodeRetry {
+		budgetStart = time.Now()
+	}
+
 	// When resuming, check if we've already exceeded the time limit
 	// This prevents trying to continue when there's no time remaining
 	if isResuming {
-		elapsed := time.Since(execution.StartedAt)
+		elapsed := time.Since(budgetStart)
 		if elapsed >= MaxExecutionDuration {
 			// Already exceeded limit - fail immediately with clear error
 			return fmt.Errorf("workflow execution exceeded maximum duration: elapsed %v >= limit %v", elapsed, MaxExecutionDuration)
@@ -204,7 +235,7 @@ func (s *WorkflowEngineService) ExecuteWorkflow(ctx context.Context, workflowID,
 	// Calculate timeout based on remaining time when resuming
 	var timeoutDuration time.Duration
 	if isResuming {
-		elapsed := time.Since(execution.StartedAt)
+		elapsed := time.Since(budgetStart)
 		timeoutDuration = MaxExecutionDuration - elapsed
 		// Ensure minimum timeout of 1 second for safety
 		if timeoutDuration < 1*time.Second {
@@ -241,15 +272,13 @@ func (s *WorkflowEngineService) ExecuteWorkflow(ctx context.Context, workflowID,
 		return fmt.Errorf("workflow is not active: %s", workflowID)
 	}
 
-	// Get the latest version
-	var latestVersion models.WorkflowVersion
-	if err := s.db.Where("workflow_id = ?", workflowID).
-		Order("version DESC").
-		First(&latestVersion).Error; err != nil {
-		return fmt.Errorf("no version found for workflow: %w", err)
+	// Run the version the execution was created with, so a resumed execution doesn't pick up later edits
+	version, err := s.executionVersion(&execution)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("📖 Using workflow version %d", latestVersion.Version)
+	log.Printf("📖 Using workflow version %d", version.Version)
 
 	// Parse input
 	var input map[string]interface{}
@@ -282,7 +311,7 @@ func (s *WorkflowEngineService) ExecuteWorkflow(ctx context.Context, workflowID,
 	var actualStartTime time.Time
 	if isResuming {
 		// Resuming: use the original execution start time to properly track total duration
-		actualStartTime = execution.StartedAt
+		actualStartTime = budgetStart
 		log.Printf("🔄 Resuming workflow execution from checkpoint: %d unique nodes already completed, %d total node executions, original start: %v", len(checkpoint), completedCount, actualStartTime)
 	} else {
 		// Fresh execution: use current time
@@ -310,22 +339,25 @@ func (s *WorkflowEngineService) ExecuteWorkflow(ctx context.Context, workflowID,
 		log.Printf("🔄 Clearing stale error message from running workflow execution")
 	}
 
-	// Parse workflow definition
-	var definition map[string]interface{}
-	if err := json.Unmarshal([]byte(latestVersion.Definition), &definition); err != nil {
+	// Compiled definitions are cached per version (versions are immutable)
+	definition, err := s.definitions.Get(version.ID, version.Definition)
+	if err != nil {
 		return fmt.Errorf("failed to parse workflow definition: %w", err)
 	}
 
+	// Resolve {{vars}}: workflow variables with the execution's environment (or the account default) on top
+	// Resolved on every run, so a resumed execution sees the environment's current values
+	vars, err := s.environments.ResolveVariables(execCtx, workflow.AccountID, execution.Environment, definition.Variables)
+	if err != nil {
+		return fmt.Errorf("failed to resolve workflow variables: %w", err)
+	}
+
 	// Initialize execution limits tracker
 	// Start with the count of already-executed nodes and original start time to properly track limits on resume
-	limits := &executionLimits{
-		nodesExecuted: int(completedCount),
-		currentDepth:  0,
-		startTime:     actualStartTime,
-	}
+	limits := newExecutionLimits(completedCount, actualStartTime)
 
 	// Execute workflow with limits and checkpoint
-	err := s.executeWorkflowDefinition(execCtx, execution.ID, workflow.AccountID, definition, input, limits, checkpoint)
+	err = s.executeWorkflowDefinition(execCtx, execution.ID, workflow.AccountID, definition, input, vars, executionMetadata(&execution), limits, checkpoint)
 
 	// Update execution status
 	now := time.Now()
@@ -398,68 +430,25 @@ func (s *WorkflowEngineService) ExecuteWorkflow(ctx context.Context, workflowID,
 	return nil
 }
 
-// executeWorkflowDefinition executes the workflow definition with proper graph-based execution
-// checkpoint contains already-executed nodes for resumption
-func (s *WorkflowEngineService) executeWorkflowDefinition(ctx context.Context, executionID string, accountID *string, definition map[string]interface{}, input map[string]interface{}, limits *executionLimits, checkpoint map[string]*models.WorkflowNodeExecution) error {
-	nodes, ok := definition["nodes"].([]interface{})
-	if !ok {
-		return fmt.Errorf("invalid workflow definition: missing nodes")
-	}
-
-	edges, ok := definition["edges"].([]interface{})
-	if !ok {
-		edges = []interface{}{} // Empty edges is okay
+// executionMetadata is the workflowData "execution" entry executors expose as {{execution.workflowId}} and
+// {{execution.triggerType}}. The trigger is the execution's own, so resumes and retries render the same value
+func executionMetadata(execution *models.WorkflowExecution) map[string]interface{} {
+	return map[string]interface{}{
+		"workflowId":  execution.WorkflowID,
+		"triggerType": execution.TriggerType,
 	}
+}
 
+// executeWorkflowDefinition executes the workflow definition with proper graph-based execution
+// vars are the resolved workflow variables, metadata the {{execution.*}} fields nodes can't derive themselves;
+// checkpoint contains already-executed nodes for resumption
+func (s *WorkflowEngineService) executeWorkflowDefinition(ctx context.Context, executionID string, accountID *string, def *workflows.Definition, input, vars, metadata map[string]interface{}, limits *executionLimits, checkpoint map[string]*models.WorkflowNodeExecution) error {
 	// Check execution limits before starting
 	if err := s.checkExecutionLimits(ctx, limits); err != nil {
 		return err
 	}
 
-	// Build node map and adjacency list
-	nodeMap := make(map[string]map[string]interface{})
-	adjacencyList := make(map[string][]string) // nodeID -> [targetNodeIDs]
-	inDegree := make(map[string]int)           // nodeID -> number of incoming edges
-
-	// Parse nodes
-	for _, nodeData := range nodes {
-		node, ok := nodeData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		nodeID, _ := node["id"].(string)
-		nodeMap[nodeID] = node
-		inDegree[nodeID] = 0
-		adjacencyList[nodeID] = []string{}
-	}
-
-	// Parse edges to build adjacency list
-	for _, edgeData := range edges {
-		edge, ok := edgeData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		source, _ := edge["source"].(string)
-		target, _ := edge["target"].(string)
-
-		if source != "" && target != "" {
-			adjacencyList[source] = append(adjacencyList[source], target)
-			inDegree[target]++
-		}
-	}
-
-	// Find start node
-	var startNodeID string
-	for nodeID, node := range nodeMap {
-		nodeType, _ := node["type"].(string)
-		if nodeType == executors.NodeTypeStart {
-			startNodeID = nodeID
-			break
-		}
-	}
-
+	startNodeID := def.StartNodeID
 	if startNodeID == "" {
 		return fmt.Errorf("no start node found in workflow")
 	}
@@ -500,10 +489,10 @@ func (s *WorkflowEngineService) executeWorkflowDefinition(ctx context.Context, e
 
 			// Mark as executed and add children to queue (check edge conditions)
 			executed[currentNodeID] = true
-			for _, nextNodeID := range adjacencyList[currentNodeID] {
+			for _, nextNodeID := range def.Children(currentNodeID) {
 				if !executed[nextNodeID] {
 					// Check edge conditions even when resuming from checkpoint
-					shouldAdd := s.checkEdgeCondition(edges, currentNodeID, nextNodeID, nodeOutputs)
+					shouldAdd := s.checkEdgeCondition(def, currentNodeID, nextNodeID, nodeOutputs)
 					if shouldAdd {
 						queue = append(queue, nextNodeID)
 					} else {
@@ -515,27 +504,21 @@ func (s *WorkflowEngineService) executeWorkflowDefinition(ctx context.Context, e
 		}
 
 		executed[currentNodeID] = true
-		currentNode := nodeMap[currentNodeID]
-		nodeType, _ := currentNode["type"].(string)
+		currentNode, _ := def.Node(currentNodeID)
+		nodeType := currentNode.Type
 
 		// Skip start and end nodes for execution
 		if !executors.IsSkippableNode(nodeType) {
 			// Increment node execution counter
-			limits.nodesExecuted++
-			// Get node config
-			data, _ := currentNode["data"].(map[string]interface{})
-			config, _ := data["config"].(map[string]interface{})
+			limits.countNode()
+			config := currentNode.Config
 
-			// Get input from previous node (source of the first incoming edge)
+			// Get input from previous node (the first incoming edge whose source produced output)
 			nodeInput := input // Default to workflow input
-			for sourceNodeID := range nodeMap {
-				for _, targetID := range adjacencyList[sourceNodeID] {
-					if targetID == currentNodeID {
-						if output, ok := nodeOutputs[sourceNodeID]; ok {
-							nodeInput = output.(map[string]interface{})
-						}
-						break
-					}
+			for _, sourceNodeID := range def.Parents(currentNodeID) {
+				if output, ok := nodeOutputs[sourceNodeID].(map[string]interface{}); ok {
+					nodeInput = output
+					break
 				}
 			}
 
@@ -543,16 +526,18 @@ func (s *WorkflowEngineService) executeWorkflowDefinition(ctx context.Context, e
 			workflowData := map[string]interface{}{
 				"nodeOutputs": nodeOutputs,
 				"input":       input,
+				"vars":        vars,
+				"execution":   metadata,
 			}
 
 			// Check if this is a loop node
 			if nodeType == "loop" {
 				// Increment depth for nested loop tracking
 				limits.currentDepth++
-				defer func() { limits.currentDepth-- }()
 
 				// Execute loop and its child nodes iteratively
-				err := s.executeLoopWithChildren(ctx, executionID, accountID, currentNodeID, config, nodeInput, workflowData, nodeMap, adjacencyList, edges, executed, nodeOutputs, limits)
+				err := s.executeLoopWithChildren(ctx, executionID, accountID, currentNodeID, config, nodeInput, workflowData, def, executed, nodeOutputs, limits)
+				limits.currentDepth--
 				if err != nil {
 					return fmt.Errorf("loop execution failed (%s): %w", currentNodeID, err)
 				}
@@ -561,35 +546,17 @@ func (s *WorkflowEngineService) executeWorkflowDefinition(ctx context.Context, e
 			} else if nodeType == "loop-accumulator" {
 				// Increment depth for nested loop tracking
 				limits.currentDepth++
-				defer func() { limits.currentDepth-- }()
 
 				// Execute loop accumulator with feedback loop
-				err := s.executeLoopAccumulatorWithChildren(ctx, executionID, accountID, currentNodeID, config, nodeInput, workflowData, nodeMap, adjacencyList, edges, executed, nodeOutputs, limits)
+				err := s.executeLoopAccumulatorWithChildren(ctx, executionID, accountID, currentNodeID, config, nodeInput, workflowData, def, executed, nodeOutputs, limits)
+				limits.currentDepth--
 				if err != nil {
 					return fmt.Errorf("loop accumulator execution failed (%s): %w", currentNodeID, err)
 				}
 				// Add nodes connected to the "output" handle (Final Output) to the queue
-				log.Printf("  🔍 Searching for final output edges from loop accumulator %s", currentNodeID)
-				log.Printf("  🔍 Total edges in workflow: %d", len(edges))
-
 				foundFinalOutputEdge := false
-				for _, edgeData := range edges {
-					edge, ok := edgeData.(map[string]interface{})
-					if !ok {
-						continue
-					}
-
-					source, _ := edge["source"].(string)
-					sourceHandle, _ := edge["sourceHandle"].(string)
-					target, _ := edge["target"].(string)
-
-					// Log all edges from this loop accumulator node for debugging
-					if source == currentNodeID {
-						log.Printf("  🔍 Edge from loop accumulator: sourceHandle='%s', target='%s', executed=%v", sourceHandle, target, executed[target])
-					}
-
-					// Only add nodes connected to the final "output" handle
-					if source == currentNodeID && sourceHandle == "output" && !executed[target] {
+				for _, target := range def.LoopExits(currentNodeID) {
+					if !executed[target] {
 						queue = append(queue, target)
 						log.Printf("  📤 Adding final output node to queue: %s", target)
 						foundFinalOutputEdge = true
@@ -618,10 +585,10 @@ func (s *WorkflowEngineService) executeWorkflowDefinition(ctx context.Context, e
 		// IMPORTANT: This must happen BEFORE checking for sleeping state to ensure
 		// that when workflow resumes, next nodes are properly queued
 		// Check edge conditions before adding nodes to queue
-		for _, nextNodeID := range adjacencyList[currentNodeID] {
+		for _, nextNodeID := range def.Children(currentNodeID) {
 			if !executed[nextNodeID] {
 				// Check if there's an edge condition that needs to be satisfied
-				shouldAdd := s.checkEdgeCondition(edges, currentNodeID, nextNodeID, nodeOutputs)
+				shouldAdd := s.checkEdgeCondition(def, currentNodeID, nextNodeID, nodeOutputs)
 				if shouldAdd {
 					queue = append(queue, nextNodeID)
 				} else {
@@ -638,7 +605,7 @@ func (s *WorkflowEngineService) executeWorkflowDefinition(ctx context.Context, e
 			if err := s.db.First(&currentExecution, "id = ?", executionID).Error; err == nil {
 				if currentExecution.Status == "sleeping" {
 					log.Printf("  💤 Workflow entered sleeping state after node %s - stopping execution", currentNodeID)
-					log.Printf("  📋 Next nodes already queued: %v", adjacencyList[currentNodeID])
+					log.Printf("  📋 Next nodes already queued: %v", def.Children(currentNodeID))
 					return nil // Stop execution gracefully
 				}
 			}
@@ -653,7 +620,7 @@ func (s *WorkflowEngineService) executeNodeAndGetOutput(ctx context.Context, exe
 	log.Printf("  ▶ Executing node %s (type: %s)", nodeID, nodeType)
 
 	// Check if node requires outbox pattern (side effects)
-	if executors.NodeRequiresOutbox(nodeType) {
+	if executors.NodeRequiresOutbox(nodeType, config) {
 		// For outbox nodes, we don't get immediate output
 		// They execute asynchronously
 		err := s.executeNodeWithOutbox(ctx, executionID, accountID, nodeID, nodeType, config, input, workflowData)
@@ -676,7 +643,7 @@ func (s *WorkflowEngineService) executeNode(ctx context.Context, executionID str
 	log.Printf("  ▶ Executing node %s (type: %s)", nodeID, nodeType)
 
 	// Check if node requires outbox pattern (side effects)
-	if executors.NodeRequiresOutbox(nodeType) {
+	if executors.NodeRequiresOutbox(nodeType, config) {
 		return s.executeNodeWithOutbox(ctx, executionID, accountID, nodeID, nodeType, config, input, workflowData)
 	}
 
@@ -686,15 +653,12 @@ func (s *WorkflowEngineService) executeNode(ctx context.Context, executionID str
 
 // executeNodeWithOutbox executes a node with side effects using the outbox pattern
 func (s *WorkflowEngineService) executeNodeWithOutbox(ctx context.Context, executionID string, accountID *string, nodeID, nodeType string, config, input, workflowData map[string]interface{}) error {
-	// Determine event type
-	eventType := fmt.Sprintf("%s.%s", nodeType, "send")
-	if nodeType == "http" {
-		eventType = "http.request"
-	}
+	// Determine event type from the node type registry
+	eventType := executors.EventTypeForNodeType(nodeType)
 
 	// Create node execution and outbox message atomically
 	nodeExecution, outboxMessage, err := s.outboxService.ExecuteNodeWithOutbox(
-		ctx, executionID, accountID, nodeID, nodeType, config, input, eventType,
+		ctx, executionID, accountID, nodeID, nodeType, config, input, workflowData, eventType,
 	)
 
 	if err != nil {
@@ -1070,7 +1034,9 @@ func (s *WorkflowEngineService) executeSynchronousNodeWithOutput(ctx context.Con
 	return result.Output, nil
 }
 
-// executeLoopWithChildren executes a loop node and iteratively executes its child nodes
+// executeLoopWithChildren executes a loop node and iteratively executes its child nodes.
+// The loop node's execution record only succeeds once every iteration has finished (a failed loop closes it
+// as an error), and finished iterations of a top-level loop are checkpointed so a resume skips them
 func (s *WorkflowEngineService) executeLoopWithChildren(
 	ctx context.Context,
 	executionID string,
@@ -1079,13 +1045,11 @@ func (s *WorkflowEngineService) executeLoopWithChildren(
 	loopConfig map[string]interface{},
 	loopInput map[string]interface{},
 	workflowData map[string]interface{},
-	nodeMap map[string]map[string]interface{},
-	adjacencyList map[string][]string,
-	edges []interface{},
+	def *workflows.Definition,
 	executed map[string]bool,
 	nodeOutputs map[string]interface{},
 	limits *executionLimits,
-) error {
+) (err error) {
 	log.Printf("  🔄 Executing loop node %s", loopNodeID)
 
 	// Check execution limits
@@ -1094,79 +1058,307 @@ func (s *WorkflowEngineService) executeLoopWithChildren(
 	}
 
 	// First, execute the loop node itself to get the items array
-	loopOutput, err := s.executeNodeAndGetOutput(ctx, executionID, accountID, loopNodeID, "loop", loopConfig, loopInput, workflowData)
+	nodeExecution, loopOutput, err := s.prepareLoop(ctx, executionID, accountID, loopNodeID, "loop", loopConfig, loopInput, workflowData)
 	if err != nil {
 		return fmt.Errorf("loop node execution failed: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			s.failLoop(ctx, nodeExecution, err)
+		}
+	}()
 
 	// Extract items/results from loop output
 	results, ok := loopOutput["results"].([]interface{})
 	if !ok {
 		log.Printf("  ⚠️  Loop node did not return results array, skipping iteration")
 		nodeOutputs[loopNodeID] = loopOutput
+		s.completeLoop(nodeExecution, loopOutput)
 		return nil
 	}
 
 	iterationCount := len(results)
 	log.Printf("  🔄 Loop will iterate %d times", iterationCount)
 
-	// Get iteration delay from config (default: 0ms = no delay)
-	iterationDelay := 0
-	if delay, ok := loopConfig["iterationDelay"].(float64); ok {
-		iterationDelay = int(delay)
-	}
+	// Iteration delay (default: 0ms = no delay), concurrency and error handling come from the compiled loop options
+	options := loopOptions(def, loopNodeID)
+	iterationDelay := options.IterationDelayMs
 	if iterationDelay > 0 {
 		log.Printf("  ⏱️  Iteration delay: %dms", iterationDelay)
 	}
+	concurrency := loopConcurrency(options)
+	if concurrency > 1 {
+		log.Printf("  🔀 Running up to %d iterations concurrently", concurrency)
+	}
 
 	// Find all child nodes (nodes that are directly connected after the loop)
-	childNodeIDs := adjacencyList[loopNodeID]
+	childNodeIDs := def.LoopBody(loopNodeID)
 	if len(childNodeIDs) == 0 {
 		log.Printf("  ⚠️  Loop node has no child nodes")
 		nodeOutputs[loopNodeID] = loopOutput
+		s.completeLoop(nodeExecution, loopOutput)
 		return nil
 	}
 
+	// Only top-level loops are checkpointed; a resumed outer iteration re-runs its nested loops
+	checkpointed := limits.currentDepth == 1
+	var finished map[int]*models.LoopIteration
+	if checkpointed {
+		finished = s.loadLoopIterations(executionID, loopNodeID)
+	}
+	failures := newLoopFailures(options, iterationCount)
+
+	// A batched loop concatenates the output of each chunk's body back into one list
+	batched := options.BatchSize > 1
+	var chunkOutputs []interface{}
+	if batched {
+		chunkOutputs = make([]interface{}, iterationCount)
+	}
+
+	for i, checkpoint := range finished {
+		if checkpoint.Error != nil {
+			failures.replay(i, *checkpoint.Error)
+		}
+		if batched && checkpoint.Status == "success" && checkpoint.Output != nil && i < iterationCount {
+			if err := json.Unmarshal([]byte(*checkpoint.Output), &chunkOutputs[i]); err != nil {
+				return fmt.Errorf("invalid checkpoint for loop iteration %d: %w", i, err)
+			}
+		}
+	}
+
 	// For each iteration
-	for i, resultData := range results {
+	done := func(i int) bool {
+		_, ok := finished[i]
+		return ok
+	}
+	err = runLoopIterations(ctx, iterationCount, concurrency, time.Duration(iterationDelay)*time.Millisecond, done, func(ctx context.Context, i int) error {
 		log.Printf("  🔄 Loop iteration %d/%d", i+1, iterationCount)
 
+		// Execution limits stop the whole loop, whatever its error handling
+		if err := s.checkExecutionLimits(ctx, limits); err != nil {
+			return err
+		}
+
 		// Each result should be a map with index and item
-		iterationInput, ok := resultData.(map[string]interface{})
+		iterationInput, ok := results[i].(map[string]interface{})
 		if !ok {
 			log.Printf("  ⚠️  Iteration %d: result is not an object, skipping", i)
-			continue
+			return nil
+		}
+
+		// Concurrent iterations get their own node outputs, executed set and depth
+		iterationData, iterationExecuted, iterationLimits := workflowData, executed, limits
+		if concurrency > 1 {
+			iterationData, iterationExecuted, iterationLimits = forkWorkflowData(workflowData), make(map[string]bool), limits.fork()
 		}
 
 		// Execute child nodes for this iteration
 		// We need to execute the subgraph starting from child nodes
+		var iterationOutput map[string]interface{}
+		var iterationErr error
 		for _, childNodeID := range childNodeIDs {
-			err := s.executeSubgraph(ctx, executionID, accountID, childNodeID, iterationInput, workflowData, nodeMap, adjacencyList, edges, executed, limits)
+			output, err := s.executeSubgraph(ctx, executionID, accountID, childNodeID, iterationInput, iterationData, def, iterationExecuted, iterationLimits)
+			if output != nil {
+				iterationOutput = output
+			}
 			if err != nil {
+				// Running out of time or being cancelled interrupts the loop: the iteration is unfinished
+				if ctx.Err() != nil {
+					return fmt.Errorf("loop interrupted at iteration %d: %w", i, err)
+				}
 				log.Printf("  ❌ Loop iteration %d failed at node %s: %v", i, childNodeID, err)
-				// Continue with next iteration even if this one fails
+				// Continue with the other child nodes even if this one fails
+				iterationErr = err
 			}
 		}
 
-		// Delay before next iteration (if configured and not the last iteration)
-		if iterationDelay > 0 && i < iterationCount-1 {
-			log.Printf("  ⏱️  Waiting %dms before next iteration...", iterationDelay)
-			time.Sleep(time.Duration(iterationDelay) * time.Millisecond)
+		status := "success"
+		var value interface{}
+		if iterationErr != nil {
+			if err := failures.record(i, iterationErr); err != nil {
+				return err
+			}
+			status = "error"
+		} else if batched {
+			value = chunkOutput(iterationOutput, options.UnwrapData)
+			chunkOutputs[i] = value
+		}
+		if checkpointed {
+			s.recordLoopIteration(executionID, loopNodeID, i, status, value, iterationErr)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	log.Printf("  ✅ Loop completed %d iterations", iterationCount)
 
 	// Mark all child nodes and their descendants as executed
-	s.markSubgraphAsExecuted(loopNodeID, adjacencyList, executed)
+	s.markSubgraphAsExecuted(loopNodeID, def, executed)
 
-	// Store loop output for any nodes after the loop subgraph
+	// With batchSize, data holds the concatenated chunk outputs instead of the chunks
+	if batched {
+		data := concatChunkOutputs(chunkOutputs)
+		if err := checkDataSize(data, "loop output"); err != nil {
+			return fmt.Errorf("loop output size limit exceeded: %w", err)
+		}
+		loopOutput["data"] = data
+	}
+
+	// Store loop output (with the failed iterations) for any nodes after the loop subgraph
+	loopOutput["errors"] = failures.list()
 	nodeOutputs[loopNodeID] = loopOutput
+	s.completeLoop(nodeExecution, loopOutput)
 
 	return nil
 }
 
+// prepareLoop creates the loop node's execution record and runs its executor to get the iteration data.
+// The record stays running until completeLoop, so a resume never mistakes a half-finished loop for a done one
+func (s *WorkflowEngineService) prepareLoop(ctx context.Context, executionID string, accountID *string, loopNodeID, nodeType string, config, input, workflowData map[string]interface{}) (*models.WorkflowNodeExecution, map[string]interface{}, error) {
+	nodeExecution := models.WorkflowNodeExecution{
+		ExecutionID: executionID,
+		NodeID:      loopNodeID,
+		NodeType:    nodeType,
+		Status:      "running",
+	}
+
+	inputJSON, _ := json.Marshal(input)
+	inputStr := string(inputJSON)
+	nodeExecution.Input = &inputStr
+
+	if err := s.db.Create(&nodeExecution).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create %s node execution: %w", nodeType, err)
+	}
+
+	log.Printf("  📝 Created %s node execution record: %s", nodeType, nodeExecution.ID)
+
+	executor, err := s.executorFactory.GetExecutor(nodeType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get %s executor: %w", nodeType, err)
+	}
+
+	accountIDStr := ""
+	if accountID != nil {
+		accountIDStr = *accountID
+	}
+	execCtx := executors.ExecutionContext{
+		NodeID:       loopNodeID,
+		NodeConfig:   config,
+		Input:        input,
+		WorkflowData: workflowData,
+		ExecutionID:  executionID,
+		AccountID:    accountIDStr,
+	}
+
+	result, err := executor.Execute(ctx, execCtx)
+	if err != nil || !result.Success {
+		// Update node execution with error
+		now := time.Now()
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		} else {
+			errMsg = result.Error
+		}
+		s.db.Model(&nodeExecution).Updates(map[string]interface{}{
+			"status":       "error",
+			"error":        errMsg,
+			"completed_at": now,
+		})
+		return nil, nil, fmt.Errorf("%s preparation failed: %s", nodeType, errMsg)
+	}
+
+	return &nodeExecution, result.Output, nil
+}
+
+// completeLoop marks a loop node's execution record as successful
+func (s *WorkflowEngineService) completeLoop(nodeExecution *models.WorkflowNodeExecution, output map[string]interface{}) {
+	outputJSON, _ := json.Marshal(output)
+	outputStr := string(outputJSON)
+	now := time.Now()
+	s.db.Model(nodeExecution).Updates(map[string]interface{}{
+		"status":       "success",
+		"output":       outputStr,
+		"completed_at": now,
+	})
+
+	log.Printf("  ✅ Loop node execution marked complete: %s", nodeExecution.ID)
+}
+
+// failLoop marks a loop node's execution record as failed. An interrupted loop stays running, so a resume
+// picks it up again
+func (s *WorkflowEngineService) failLoop(ctx context.Context, nodeExecution *models.WorkflowNodeExecution, loopErr error) {
+	if ctx.Err() != nil {
+		return
+	}
+	now := time.Now()
+	s.db.Model(nodeExecution).Updates(map[string]interface{}{
+		"status":       "error",
+		"error":        loopErr.Error(),
+		"completed_at": now,
+	})
+
+	log.Printf("  ❌ Loop node execution marked failed: %s", nodeExecution.ID)
+}
+
+// loadLoopIterations returns the checkpointed iterations of a loop, keyed by iteration index
+func (s *WorkflowEngineService) loadLoopIterations(executionID, loopNodeID string) map[int]*models.LoopIteration {
+	var iterations []models.LoopIteration
+	if err := s.db.Where("execution_id = ? AND loop_node_id = ?", executionID, loopNodeID).
+		Order("iteration").
+		Find(&iterations).Error; err != nil {
+		log.Printf("  ⚠️  Failed to load loop checkpoints for %s, running all iterations: %v", loopNodeID, err)
+		return nil
+	}
+
+	finished := make(map[int]*models.LoopIteration, len(iterations))
+	for i := range iterations {
+		finished[iterations[i].Iteration] = &iterations[i]
+	}
+	if len(finished) > 0 {
+		log.Printf("  ⏭️  Resuming loop %s: %d iterations already finished", loopNodeID, len(finished))
+	}
+	return finished
+}
+
+// recordLoopIteration checkpoints a finished loop iteration; output is what it added to an accumulator or a
+// batched loop's data (or nil)
+// and iterationErr the failure the loop's error handling let it continue past
+func (s *WorkflowEngineService) recordLoopIteration(executionID, loopNodeID string, iteration int, status string, output interface{}, iterationErr error) {
+	checkpoint := models.LoopIteration{
+		ExecutionID: executionID,
+		LoopNodeID:  loopNodeID,
+		Iteration:   iteration,
+		Status:      status,
+	}
+	if iterationErr != nil {
+		errMsg := iterationErr.Error()
+		checkpoint.Error = &errMsg
+	}
+	if output != nil {
+		outputJSON, _ := json.Marshal(output)
+		outputStr := string(outputJSON)
+		checkpoint.Output = &outputStr
+	}
+
+	// A failed checkpoint only costs a re-run of this iteration on resume
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&checkpoint).Error; err != nil {
+		log.Printf("  ⚠️  Failed to checkpoint iteration %d of loop %s: %v", iteration, loopNodeID, err)
+	}
+}
+
+// loopOptions returns the compiled options of a loop node (defaults when the node is unknown)
+func loopOptions(def *workflows.Definition, loopNodeID string) *workflows.LoopOptions {
+	if node, ok := def.Node(loopNodeID); ok && node.Loop != nil {
+		return node.Loop
+	}
+	return &workflows.LoopOptions{ErrorHandling: "skip", UnwrapData: true}
+}
+
 // executeSubgraph executes a node and all its descendants (for loop iterations)
+// It returns the output of the last node executed, or nil when none ran
 func (s *WorkflowEngineService) executeSubgraph(
 	ctx context.Context,
 	executionID string,
@@ -1174,45 +1366,41 @@ func (s *WorkflowEngineService) executeSubgraph(
 	startNodeID string,
 	input map[string]interface{},
 	workflowData map[string]interface{},
-	nodeMap map[string]map[string]interface{},
-	adjacencyList map[string][]string,
-	edges []interface{},
+	def *workflows.Definition,
 	executedGlobal map[string]bool,
 	limits *executionLimits,
-) error {
+) (map[string]interface{}, error) {
 	// Track what we execute in this subgraph iteration
 	queue := []string{startNodeID}
 	currentOutput := input
+	var lastOutput map[string]interface{}
 
 	for len(queue) > 0 {
 		// Check execution limits
 		if err := s.checkExecutionLimits(ctx, limits); err != nil {
-			return err
+			return nil, err
 		}
 
 		nodeID := queue[0]
 		queue = queue[1:]
 
-		node, exists := nodeMap[nodeID]
+		node, exists := def.Node(nodeID)
 		if !exists {
 			continue
 		}
 
-		nodeType, _ := node["type"].(string)
+		nodeType := node.Type
 
 		// Skip start and end nodes in subgraph
 		if executors.IsSkippableNode(nodeType) {
 			// Add children to queue but don't execute
-			for _, nextNodeID := range adjacencyList[nodeID] {
-				queue = append(queue, nextNodeID)
-			}
+			queue = append(queue, def.Children(nodeID)...)
 			continue
 		}
 
 		// Handle nested loops - track depth and execute
 		if nodeType == "loop" {
-			data, _ := node["data"].(map[string]interface{})
-			config, _ := data["config"].(map[string]interface{})
+			config := node.Config
 
 			log.Printf("    🔄 Executing nested loop node %s (current depth: %d)", nodeID, limits.currentDepth)
 
@@ -1222,24 +1410,23 @@ func (s *WorkflowEngineService) executeSubgraph(
 			// Check depth limit before executing
 			if err := s.checkExecutionLimits(ctx, limits); err != nil {
 				limits.currentDepth-- // Restore depth before returning error
-				return fmt.Errorf("nested loop depth limit exceeded at node %s: %w", nodeID, err)
+				return nil, fmt.Errorf("nested loop depth limit exceeded at node %s: %w", nodeID, err)
 			}
 
-			err := s.executeLoopWithChildren(ctx, executionID, accountID, nodeID, config, currentOutput, workflowData, nodeMap, adjacencyList, edges, executedGlobal, workflowData["nodeOutputs"].(map[string]interface{}), limits)
+			err := s.executeLoopWithChildren(ctx, executionID, accountID, nodeID, config, currentOutput, workflowData, def, executedGlobal, workflowData["nodeOutputs"].(map[string]interface{}), limits)
 
 			// Decrement depth after execution
 			limits.currentDepth--
 
 			if err != nil {
-				return fmt.Errorf("nested loop execution failed: %w", err)
+				return nil, fmt.Errorf("nested loop execution failed: %w", err)
 			}
 			// Loop handles its own children, don't add them to queue
 			continue
 		}
 
 		if nodeType == "loop-accumulator" {
-			data, _ := node["data"].(map[string]interface{})
-			config, _ := data["config"].(map[string]interface{})
+			config := node.Config
 
 			log.Printf("    🔄 Executing nested loop accumulator node %s (current depth: %d)", nodeID, limits.currentDepth)
 
@@ -1249,68 +1436,68 @@ func (s *WorkflowEngineService) executeSubgraph(
 			// Check depth limit before executing
 			if err := s.checkExecutionLimits(ctx, limits); err != nil {
 				limits.currentDepth-- // Restore depth before returning error
-				return fmt.Errorf("nested loop accumulator depth limit exceeded at node %s: %w", nodeID, err)
+				return nil, fmt.Errorf("nested loop accumulator depth limit exceeded at node %s: %w", nodeID, err)
 			}
 
 			// Execute loop accumulator with edges
-			err := s.executeLoopAccumulatorWithChildren(ctx, executionID, accountID, nodeID, config, currentOutput, workflowData, nodeMap, adjacencyList, edges, executedGlobal, workflowData["nodeOutputs"].(map[string]interface{}), limits)
+			err := s.executeLoopAccumulatorWithChildren(ctx, executionID, accountID, nodeID, config, currentOutput, workflowData, def, executedGlobal, workflowData["nodeOutputs"].(map[string]interface{}), limits)
 
 			// Decrement depth after execution
 			limits.currentDepth--
 
 			if err != nil {
-				return fmt.Errorf("nested loop accumulator execution failed: %w", err)
+				return nil, fmt.Errorf("nested loop accumulator execution failed: %w", err)
 			}
 			// Loop accumulator handles its own children, don't add them to queue
 			continue
 		}
 
-		// Get node config
-		data, _ := node["data"].(map[string]interface{})
-		config, _ := data["config"].(map[string]interface{})
+		config := node.Config
 
-		// Execute this node
+		// Execute this node (loop body nodes count towards MaxTotalNodes too)
+		limits.countNode()
 		log.Printf("    ▶ Executing child node %s (type: %s)", nodeID, nodeType)
 		output, err := s.executeNodeAndGetOutput(ctx, executionID, accountID, nodeID, nodeType, config, currentOutput, workflowData)
 		if err != nil {
-			return fmt.Errorf("subgraph node %s execution failed: %w", nodeID, err)
+			return nil, fmt.Errorf("subgraph node %s execution failed: %w", nodeID, err)
 		}
 
 		// Update current output for next node in chain
 		currentOutput = output
+		lastOutput = output
 
 		// Add child nodes to queue (check edge conditions in subgraph)
 		// Note: In loop subgraphs, we typically don't have conditional edges,
 		// but we should still check for completeness
-		for _, nextNodeID := range adjacencyList[nodeID] {
+		for _, nextNodeID := range def.Children(nodeID) {
 			// For subgraphs, we use a simple nodeOutputs map
 			subgraphNodeOutputs := map[string]interface{}{
 				nodeID: currentOutput,
 			}
-			shouldAdd := s.checkEdgeCondition(edges, nodeID, nextNodeID, subgraphNodeOutputs)
+			shouldAdd := s.checkEdgeCondition(def, nodeID, nextNodeID, subgraphNodeOutputs)
 			if shouldAdd {
 				queue = append(queue, nextNodeID)
 			}
 		}
 	}
 
-	return nil
+	return lastOutput, nil
 }
 
 // markSubgraphAsExecuted marks all nodes in a subgraph as executed
 func (s *WorkflowEngineService) markSubgraphAsExecuted(
 	startNodeID string,
-	adjacencyList map[string][]string,
+	def *workflows.Definition,
 	executed map[string]bool,
 ) {
-	s.markSubgraphAsExecutedWithStop(startNodeID, "", adjacencyList, executed)
+	s.markSubgraphAsExecutedWithStop(startNodeID, "", def, executed)
 }
 
 // markSubgraphAsExecutedWithStop marks all nodes in a subgraph as executed, stopping at stopNodeID
 func (s *WorkflowEngineService) markSubgraphAsExecutedWithStop(
 	startNodeID string,
 	stopNodeID string,
-	adjacencyList map[string][]string,
+	def *workflows.Definition,
 	executed map[string]bool,
 ) {
 	queue := []string{startNodeID}
@@ -1334,7 +1521,7 @@ func (s *WorkflowEngineService) markSubgraphAsExecutedWithStop(
 		executed[nodeID] = true
 
 		// Add children to queue
-		for _, childID := range adjacencyList[nodeID] {
+		for _, childID := range def.Children(nodeID) {
 			if !visited[childID] {
 				// Don't follow edges to the stop node
 				if stopNodeID != "" && childID == stopNodeID {
@@ -1355,13 +1542,11 @@ func (s *WorkflowEngineService) executeLoopAccumulatorWithChildren(
 	loopConfig map[string]interface{},
 	loopInput map[string]interface{},
 	workflowData map[string]interface{},
-	nodeMap map[string]map[string]interface{},
-	adjacencyList map[string][]string,
-	edges []interface{},
+	def *workflows.Definition,
 	executed map[string]bool,
 	nodeOutputs map[string]interface{},
 	limits *executionLimits,
-) error {
+) (err error) {
 	log.Printf("  🔄 Executing loop accumulator node %s", loopNodeID)
 
 	// Check execution limits
@@ -1369,69 +1554,23 @@ func (s *WorkflowEngineService) executeLoopAccumulatorWithChildren(
 		return err
 	}
 
-	// Create node execution record at the START
-	nodeExecution := models.WorkflowNodeExecution{
-		ExecutionID: executionID,
-		NodeID:      loopNodeID,
-		NodeType:    "loop-accumulator",
-		Status:      "running",
-	}
-
-	inputJSON, _ := json.Marshal(loopInput)
-	inputStr := string(inputJSON)
-	nodeExecution.Input = &inputStr
-
-	if err := s.db.Create(&nodeExecution).Error; err != nil {
-		return fmt.Errorf("failed to create loop accumulator node execution: %w", err)
-	}
-
-	log.Printf("  📝 Created loop accumulator node execution record: %s", nodeExecution.ID)
-
-	// Get the executor to prepare iteration data (but don't create node execution)
-	executor, err := s.executorFactory.GetExecutor("loop-accumulator")
+	// Create the node execution record at the START and get the iteration data
+	nodeExecution, loopOutput, err := s.prepareLoop(ctx, executionID, accountID, loopNodeID, "loop-accumulator", loopConfig, loopInput, workflowData)
 	if err != nil {
-		return fmt.Errorf("failed to get loop accumulator executor: %w", err)
-	}
-
-	// Execute to get the iteration data
-	accountIDStr := ""
-	if accountID != nil {
-		accountIDStr = *accountID
-	}
-	execCtx := executors.ExecutionContext{
-		NodeID:       loopNodeID,
-		NodeConfig:   loopConfig,
-		Input:        loopInput,
-		WorkflowData: workflowData,
-		ExecutionID:  executionID,
-		AccountID:    accountIDStr,
+		return err
 	}
-
-	result, err := executor.Execute(ctx, execCtx)
-	if err != nil || !result.Success {
-		// Update node execution with error
-		now := time.Now()
-		errMsg := ""
+	defer func() {
 		if err != nil {
-			errMsg = err.Error()
-		} else {
-			errMsg = result.Error
+			s.failLoop(ctx, nodeExecution, err)
 		}
-		s.db.Model(&nodeExecution).Updates(map[string]interface{}{
-			"status":       "error",
-			"error":        errMsg,
-			"completed_at": now,
-		})
-		return fmt.Errorf("loop accumulator preparation failed: %w", err)
-	}
-
-	loopOutput := result.Output
+	}()
 
 	// Extract items/results from loop output
 	results, ok := loopOutput["results"].([]interface{})
 	if !ok {
 		log.Printf("  ⚠️  Loop accumulator node did not return results array, skipping iteration")
 		nodeOutputs[loopNodeID] = loopOutput
+		s.completeLoop(nodeExecution, loopOutput)
 		return nil
 	}
 
@@ -1451,44 +1590,22 @@ func (s *WorkflowEngineService) executeLoopAccumulatorWithChildren(
 		accumulatorVariable = "accumulated"
 	}
 
-	// Get error handling mode (default: "skip")
-	errorHandling, _ := loopConfig["errorHandling"].(string)
-	if errorHandling == "" {
-		errorHandling = "skip"
-	}
+	// Error handling (default: "skip"), iteration delay, concurrency and unwrapping come from the compiled loop options
+	options := loopOptions(def, loopNodeID)
+	errorHandling := options.ErrorHandling
 	log.Printf("  🛡️  Error handling: %s", errorHandling)
 
-	// Get iteration delay from config (default: 0ms = no delay)
-	iterationDelay := 0
-	if delay, ok := loopConfig["iterationDelay"].(float64); ok {
-		iterationDelay = int(delay)
-	}
+	iterationDelay := options.IterationDelayMs
 	if iterationDelay > 0 {
 		log.Printf("  ⏱️  Iteration delay: %dms", iterationDelay)
 	}
+	concurrency := loopConcurrency(options)
+	if concurrency > 1 {
+		log.Printf("  🔀 Running up to %d iterations concurrently", concurrency)
+	}
 
 	// Find loop body nodes - nodes connected to the "loop-output" handle (left side)
-	// We need to identify which edges come from the loop-output handle
-	var loopBodyNodeIDs []string
-
-	log.Printf("  🔍 Searching for loop body nodes connected to loop-output handle")
-	// Parse edges to find which ones originate from loop-output handle
-	for _, edgeData := range edges {
-		edge, ok := edgeData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		source, _ := edge["source"].(string)
-		sourceHandle, _ := edge["sourceHandle"].(string)
-		target, _ := edge["target"].(string)
-
-		// Check if this edge comes from our loop node's loop-output handle
-		if source == loopNodeID && sourceHandle == "loop-output" {
-			loopBodyNodeIDs = append(loopBodyNodeIDs, target)
-			log.Printf("  ✅ Added loop body node: %s", target)
-		}
-	}
+	loopBodyNodeIDs := def.LoopBody(loopNodeID)
 
 	if len(loopBodyNodeIDs) == 0 {
 		log.Printf("  ⚠️  Loop accumulator node has no loop body nodes (no connections from loop-output handle)")
@@ -1499,6 +1616,7 @@ func (s *WorkflowEngineService) executeLoopAccumulatorWithChildren(
 			accumulatorVariable: []interface{}{},
 		}
 		nodeOutputs[loopNodeID] = finalOutput
+		s.completeLoop(nodeExecution, finalOutput)
 		return nil
 	}
 
@@ -1512,55 +1630,149 @@ func (s *WorkflowEngineService) executeLoopAccumulatorWithChildren(
 		accumulated = nil
 	}
 
+	// Iterations may finish out of order; their values are folded into the accumulator in index order.
+	// settled marks finished iterations, values holds what each adds (when adds is set) until it is folded
+	var mu sync.Mutex
+	settled := make([]bool, iterationCount)
+	adds := make([]bool, iterationCount)
+	values := make([]interface{}, iterationCount)
+	next := 0
+
+	// settle records iteration i and folds every finished iteration at the front of the order
+	settle := func(i int, value interface{}, add bool) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		settled[i] = true
+		adds[i] = add
+		values[i] = value
+		for next < iterationCount && settled[next] {
+			if adds[next] {
+				if accumulationMode == "array" {
+					// Add to array; a batched loop concatenates the results of each chunk
+					if accArray, ok := accumulated.([]interface{}); ok {
+						if chunkResults, isArray := values[next].([]interface{}); isArray && options.BatchSize > 1 {
+							accumulated = append(accArray, chunkResults...)
+						} else {
+							accumulated = append(accArray, values[next])
+						}
+					}
+				} else if accumulationMode == "last" {
+					// Replace with latest
+					accumulated = values[next]
+				}
+
+				// Check accumulated data size to prevent memory abuse
+				if err := checkDataSize(accumulated, "accumulated data"); err != nil {
+					return fmt.Errorf("accumulator size limit exceeded at iteration %d: %w", next, err)
+				}
+				values[next] = nil
+			}
+			next++
+		}
+		return nil
+	}
+
+	// current returns the accumulated value so far (iterations running concurrently don't see each other's results)
+	current := func() interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return accumulated
+	}
+
+	// Only top-level loops are checkpointed; a resumed outer iteration re-runs its nested loops
+	checkpointed := limits.currentDepth == 1
+	var finished map[int]*models.LoopIteration
+	if checkpointed {
+		finished = s.loadLoopIterations(executionID, loopNodeID)
+	}
+	failures := newLoopFailures(options, iterationCount)
+
+	// Replay finished iterations from their checkpoint instead of re-running them
+	for i := 0; i < iterationCount; i++ {
+		checkpoint, done := finished[i]
+		if !done {
+			continue
+		}
+		var value interface{}
+		if checkpoint.Status == "success" && checkpoint.Output != nil {
+			if err := json.Unmarshal([]byte(*checkpoint.Output), &value); err != nil {
+				return fmt.Errorf("invalid checkpoint for loop accumulator iteration %d: %w", i, err)
+			}
+		}
+		if checkpoint.Error != nil {
+			failures.replay(i, *checkpoint.Error)
+		}
+		if err := settle(i, value, checkpoint.Status == "success"); err != nil {
+			return err
+		}
+	}
+
 	// For each iteration
-	for i, resultData := range results {
+	done := func(i int) bool {
+		_, ok := finished[i]
+		return ok
+	}
+	err = runLoopIterations(ctx, iterationCount, concurrency, time.Duration(iterationDelay)*time.Millisecond, done, func(ctx context.Context, i int) error {
 		log.Printf("  🔄 Loop accumulator iteration %d/%d", i+1, iterationCount)
 
+		// Execution limits stop the whole loop, whatever its error handling
+		if err := s.checkExecutionLimits(ctx, limits); err != nil {
+			return err
+		}
+
 		// Each result should be a map with index, item, and accumulated
-		iterationInput, ok := resultData.(map[string]interface{})
+		iterationInput, ok := results[i].(map[string]interface{})
 		if !ok {
 			log.Printf("  ⚠️  Iteration %d: result is not an object, skipping", i)
-			continue
+			return settle(i, nil, false)
 		}
 
 		// Add current accumulated value to the iteration input
-		iterationInput[accumulatorVariable] = accumulated
+		iterationInput[accumulatorVariable] = current()
+
+		// Concurrent iterations get their own node outputs and depth
+		iterationData, iterationLimits := workflowData, limits
+		if concurrency > 1 {
+			iterationData, iterationLimits = forkWorkflowData(workflowData), limits.fork()
+		}
 
 		// Execute loop body nodes for this iteration
 		var iterationOutput map[string]interface{}
-		iterationFailed := false
+		var iterationErr error
 		for _, bodyNodeID := range loopBodyNodeIDs {
-			output, err := s.executeSubgraphAndGetOutputWithParent(ctx, executionID, accountID, bodyNodeID, loopNodeID, iterationInput, workflowData, nodeMap, adjacencyList, edges, limits)
+			output, err := s.executeSubgraphAndGetOutputWithParent(ctx, executionID, accountID, bodyNodeID, loopNodeID, iterationInput, iterationData, def, iterationLimits)
 			if err != nil {
-				log.Printf("  ❌ Loop accumulator iteration %d failed at node %s: %v", i, bodyNodeID, err)
-				iterationFailed = true
-
-				// Check error handling mode
-				if errorHandling == "fail" {
-					// Fail the entire loop
-					return fmt.Errorf("loop accumulator failed at iteration %d: %w", i, err)
+				// Running out of time or being cancelled interrupts the loop: the iteration is unfinished
+				if ctx.Err() != nil {
+					return fmt.Errorf("loop accumulator interrupted at iteration %d: %w", i, err)
 				}
-				// Otherwise skip this iteration
+				log.Printf("  ❌ Loop accumulator iteration %d failed at node %s: %v", i, bodyNodeID, err)
+				iterationErr = err
+				// Skip the rest of this iteration
 				break
 			}
 			iterationOutput = output
 		}
 
 		// Skip accumulation if iteration failed or returned nil/null/undefined
-		if iterationFailed || iterationOutput == nil {
-			log.Printf("  ⚠️  Skipping iteration %d (failed or null result)", i)
-			// Continue to next iteration
+		status := "skipped"
+		var valueToAccumulate interface{}
+		if iterationErr != nil {
+			// Check error handling mode: "fail" and an exceeded "threshold" fail the entire loop
+			if err := failures.record(i, iterationErr); err != nil {
+				return fmt.Errorf("loop accumulator failed: %w", err)
+			}
+			log.Printf("  ⚠️  Skipping iteration %d (failed)", i)
+			status = "error"
+		} else if iterationOutput == nil {
+			log.Printf("  ⚠️  Skipping iteration %d (null result)", i)
 		} else {
 			// Extract the value to accumulate
 			// By default, unwrap "data" key if it exists (most executors wrap output in "data")
 			// Users can set "unwrapData" to false in config to keep the full output
-			var valueToAccumulate interface{} = iterationOutput
-			unwrapData := true // Default to true
-			if unwrap, ok := loopConfig["unwrapData"].(bool); ok {
-				unwrapData = unwrap
-			}
-
-			if unwrapData {
+			valueToAccumulate = iterationOutput
+			if options.UnwrapData {
 				if dataValue, hasData := iterationOutput["data"]; hasData {
 					valueToAccumulate = dataValue
 				}
@@ -1580,36 +1792,25 @@ func (s *WorkflowEngineService) executeLoopAccumulatorWithChildren(
 					shouldSkip = true
 				}
 			}
-
 			if !shouldSkip {
-				// Accumulate the result based on mode
-				if accumulationMode == "array" {
-					// Add to array
-					if accArray, ok := accumulated.([]interface{}); ok {
-						accumulated = append(accArray, valueToAccumulate)
-
-						// Check accumulated data size to prevent memory abuse
-						if err := checkDataSize(accumulated, "accumulated data"); err != nil {
-							return fmt.Errorf("accumulator size limit exceeded at iteration %d: %w", i, err)
-						}
-					}
-				} else if accumulationMode == "last" {
-					// Replace with latest
-					accumulated = valueToAccumulate
-
-					// Check output size
-					if err := checkDataSize(accumulated, "accumulated data"); err != nil {
-						return fmt.Errorf("accumulator size limit exceeded at iteration %d: %w", i, err)
-					}
-				}
+				status = "success"
 			}
 		}
 
-		// Delay before next iteration (if configured and not the last iteration)
-		if iterationDelay > 0 && i < iterationCount-1 {
-			log.Printf("  ⏱️  Waiting %dms before next iteration...", iterationDelay)
-			time.Sleep(time.Duration(iterationDelay) * time.Millisecond)
+		if err := settle(i, valueToAccumulate, status == "success"); err != nil {
+			return err
+		}
+		if checkpointed {
+			if status == "success" {
+				s.recordLoopIteration(executionID, loopNodeID, i, status, valueToAccumulate, nil)
+			} else {
+				s.recordLoopIteration(executionID, loopNodeID, i, status, nil, iterationErr)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	log.Printf("  ✅ Loop accumulator completed %d iterations", iterationCount)
@@ -1618,7 +1819,7 @@ func (s *WorkflowEngineService) executeLoopAccumulatorWithChildren(
 	// We need to mark the loop body subgraph, but NOT nodes connected to the "output" handle
 	// Stop marking at the loop node to prevent marking nodes after the loop
 	for _, bodyNodeID := range loopBodyNodeIDs {
-		s.markSubgraphAsExecutedWithStop(bodyNodeID, loopNodeID, adjacencyList, executed)
+		s.markSubgraphAsExecutedWithStop(bodyNodeID, loopNodeID, def, executed)
 	}
 
 	// Also mark the loop accumulator node itself as executed
@@ -1628,22 +1829,14 @@ func (s *WorkflowEngineService) executeLoopAccumulatorWithChildren(
 	finalOutput := map[string]interface{}{
 		"iteration_count":   iterationCount,
 		accumulatorVariable: accumulated,
+		"errors":            failures.list(),
 	}
 	nodeOutputs[loopNodeID] = finalOutput
 
 	log.Printf("  📤 Loop accumulator final output: %v", finalOutput)
 
 	// NOW mark the loop accumulator node execution as complete
-	outputJSON, _ := json.Marshal(finalOutput)
-	outputStr := string(outputJSON)
-	now := time.Now()
-	s.db.Model(&nodeExecution).Updates(map[string]interface{}{
-		"status":       "success",
-		"output":       outputStr,
-		"completed_at": now,
-	})
-
-	log.Printf("  ✅ Loop accumulator node execution marked complete: %s", nodeExecution.ID)
+	s.completeLoop(nodeExecution, finalOutput)
 
 	return nil
 }
@@ -1656,12 +1849,10 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutput(
 	startNodeID string,
 	input map[string]interface{},
 	workflowData map[string]interface{},
-	nodeMap map[string]map[string]interface{},
-	adjacencyList map[string][]string,
-	edges []interface{},
+	def *workflows.Definition,
 	limits *executionLimits,
 ) (map[string]interface{}, error) {
-	return s.executeSubgraphAndGetOutputWithParent(ctx, executionID, accountID, startNodeID, "", input, workflowData, nodeMap, adjacencyList, edges, limits)
+	return s.executeSubgraphAndGetOutputWithParent(ctx, executionID, accountID, startNodeID, "", input, workflowData, def, limits)
 }
 
 func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
@@ -1672,9 +1863,7 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 	parentLoopNodeID string,
 	input map[string]interface{},
 	workflowData map[string]interface{},
-	nodeMap map[string]map[string]interface{},
-	adjacencyList map[string][]string,
-	edges []interface{},
+	def *workflows.Definition,
 	limits *executionLimits,
 ) (map[string]interface{}, error) {
 	queue := []string{startNodeID}
@@ -1689,19 +1878,17 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 		nodeID := queue[0]
 		queue = queue[1:]
 
-		node, exists := nodeMap[nodeID]
+		node, exists := def.Node(nodeID)
 		if !exists {
 			continue
 		}
 
-		nodeType, _ := node["type"].(string)
+		nodeType := node.Type
 
 		// Skip start and end nodes in subgraph
 		if executors.IsSkippableNode(nodeType) {
 			// Add children to queue but don't execute
-			for _, nextNodeID := range adjacencyList[nodeID] {
-				queue = append(queue, nextNodeID)
-			}
+			queue = append(queue, def.Children(nodeID)...)
 			continue
 		}
 
@@ -1713,8 +1900,7 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 
 		// Handle nested loops in loop bodies - track depth and execute
 		if nodeType == "loop" {
-			data, _ := node["data"].(map[string]interface{})
-			config, _ := data["config"].(map[string]interface{})
+			config := node.Config
 
 			log.Printf("    🔄 Executing nested loop node %s in loop body (current depth: %d)", nodeID, limits.currentDepth)
 
@@ -1729,7 +1915,7 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 
 			// Create a local executed map for the nested loop
 			nestedExecuted := make(map[string]bool)
-			err := s.executeLoopWithChildren(ctx, executionID, accountID, nodeID, config, currentOutput, workflowData, nodeMap, adjacencyList, edges, nestedExecuted, workflowData["nodeOutputs"].(map[string]interface{}), limits)
+			err := s.executeLoopWithChildren(ctx, executionID, accountID, nodeID, config, currentOutput, workflowData, def, nestedExecuted, workflowData["nodeOutputs"].(map[string]interface{}), limits)
 
 			// Decrement depth after execution
 			limits.currentDepth--
@@ -1746,8 +1932,7 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 		}
 
 		if nodeType == "loop-accumulator" {
-			data, _ := node["data"].(map[string]interface{})
-			config, _ := data["config"].(map[string]interface{})
+			config := node.Config
 
 			log.Printf("    🔄 Executing nested loop accumulator node %s in loop body (current depth: %d)", nodeID, limits.currentDepth)
 
@@ -1762,7 +1947,7 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 
 			// Create a local executed map for the nested loop accumulator
 			nestedExecuted := make(map[string]bool)
-			err := s.executeLoopAccumulatorWithChildren(ctx, executionID, accountID, nodeID, config, currentOutput, workflowData, nodeMap, adjacencyList, edges, nestedExecuted, workflowData["nodeOutputs"].(map[string]interface{}), limits)
+			err := s.executeLoopAccumulatorWithChildren(ctx, executionID, accountID, nodeID, config, currentOutput, workflowData, def, nestedExecuted, workflowData["nodeOutputs"].(map[string]interface{}), limits)
 
 			// Decrement depth after execution
 			limits.currentDepth--
@@ -1778,11 +1963,11 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 			continue
 		}
 
-		// Get node config
-		data, _ := node["data"].(map[string]interface{})
-		config, _ := data["config"].(map[string]interface{})
+		config := node.Config
 
 		// Execute this node and create node execution record with parent context
+		// (loop body nodes count towards MaxTotalNodes too)
+		limits.countNode()
 		log.Printf("    ▶ Executing loop body node %s (type: %s)", nodeID, nodeType)
 		output, err := s.executeNodeInLoop(ctx, executionID, accountID, nodeID, nodeType, config, currentOutput, workflowData, parentLoopNodeID)
 		if err != nil {
@@ -1793,7 +1978,7 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 		currentOutput = output
 
 		// Add child nodes to queue, but skip if child is the parent loop node
-		for _, nextNodeID := range adjacencyList[nodeID] {
+		for _, nextNodeID := range def.Children(nodeID) {
 			// Don't follow edges back to the parent loop node
 			if parentLoopNodeID != "" && nextNodeID == parentLoopNodeID {
 				log.Printf("    🔙 Skipping edge to parent loop node %s", nextNodeID)
@@ -1804,7 +1989,7 @@ func (s *WorkflowEngineService) executeSubgraphAndGetOutputWithParent(
 			subgraphNodeOutputs := map[string]interface{}{
 				nodeID: currentOutput,
 			}
-			shouldAdd := s.checkEdgeCondition(edges, nodeID, nextNodeID, subgraphNodeOutputs)
+			shouldAdd := s.checkEdgeCondition(def, nodeID, nextNodeID, subgraphNodeOutputs)
 			if shouldAdd {
 				queue = append(queue, nextNodeID)
 			}
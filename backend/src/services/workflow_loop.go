@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patali/yantra/src/workflows"
+)
+
+// loopConcurrency returns how many iterations of a loop may run at once
+func loopConcurrency(options *workflows.LoopOptions) int {
+	if options.Concurrency < 1 {
+		return 1
+	}
+	if options.Concurrency > MaxLoopConcurrency {
+		return MaxLoopConcurrency
+	}
+	return options.Concurrency
+}
+
+// chunkOutput is the value a batched loop keeps from a chunk's body output: its data unless unwrapping is off
+func chunkOutput(output map[string]interface{}, unwrapData bool) interface{} {
+	if output == nil {
+		return nil
+	}
+	if unwrapData {
+		if data, ok := output["data"]; ok {
+			return data
+		}
+	}
+	return output
+}
+
+// concatChunkOutputs flattens the outputs of a batched loop's chunks in chunk order.
+// Array outputs are spread, other values appended, and chunks without output skipped
+func concatChunkOutputs(outputs []interface{}) []interface{} {
+	flat := make([]interface{}, 0, len(outputs))
+	for _, output := range outputs {
+		switch v := output.(type) {
+		case nil:
+		case []interface{}:
+			flat = append(flat, v...)
+		default:
+			flat = append(flat, v)
+		}
+	}
+	return flat
+}
+
+// runLoopIterations calls run for every index not reported done, with up to concurrency iterations in flight.
+// Iteration starts are spaced by delay. The first error returned by run cancels the context passed to the
+// other iterations, stops new ones from starting and is returned once the in-flight iterations have finished
+func runLoopIterations(ctx context.Context, count, concurrency int, delay time.Duration, done func(i int) bool, run func(ctx context.Context, i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	slots := make(chan struct{}, concurrency)
+	started := false
+dispatch:
+	for i := 0; i < count; i++ {
+		if done != nil && done(i) {
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-runCtx.Done():
+			break dispatch
+		}
+
+		if started && delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-runCtx.Done():
+				<-slots
+				break dispatch
+			}
+		}
+		started = true
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			defer func() {
+				if r := recover(); r != nil {
+					fail(fmt.Errorf("iteration %d panicked: %v", i, r))
+				}
+			}()
+			if err := run(runCtx, i); err != nil {
+				fail(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("loop interrupted: %w", err)
+	}
+	return nil
+}
+
+// loopFailures collects the failed iterations of a loop and applies its error handling mode
+type loopFailures struct {
+	mu       sync.Mutex
+	mode     string
+	maxRatio float64
+	total    int
+	errors   map[int]string
+}
+
+func newLoopFailures(options *workflows.LoopOptions, total int) *loopFailures {
+	return &loopFailures{
+		mode:     options.ErrorHandling,
+		maxRatio: options.MaxFailureRatio,
+		total:    total,
+		errors:   make(map[int]string),
+	}
+}
+
+// record adds a failed iteration and returns an error when the loop must stop:
+// right away in "fail" mode, or once too many iterations failed in "threshold" mode
+func (f *loopFailures) record(i int, err error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.errors[i] = err.Error()
+	switch f.mode {
+	case "fail":
+		return fmt.Errorf("iteration %d failed: %w", i, err)
+	case "threshold":
+		if f.total > 0 && float64(len(f.errors))/float64(f.total) > f.maxRatio {
+			return fmt.Errorf("%d of %d iterations failed, more than the allowed ratio of %g (last: iteration %d: %w)", len(f.errors), f.total, f.maxRatio, i, err)
+		}
+	}
+	return nil
+}
+
+// replay adds a failure restored from a loop checkpoint
+func (f *loopFailures) replay(i int, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[i] = message
+}
+
+// list returns the collected failures ordered by iteration index
+func (f *loopFailures) list() []interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	indexes := make([]int, 0, len(f.errors))
+	for i := range f.errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	list := make([]interface{}, 0, len(indexes))
+	for _, i := range indexes {
+		list = append(list, map[string]interface{}{"index": i, "error": f.errors[i]})
+	}
+	return list
+}
+
+// forkWorkflowData gives a concurrent iteration its own nodeOutputs, so nested loops can store outputs
+// without racing other iterations; node outputs recorded before the loop stay visible
+func forkWorkflowData(workflowData map[string]interface{}) map[string]interface{} {
+	forked := make(map[string]interface{}, len(workflowData))
+	for k, v := range workflowData {
+		forked[k] = v
+	}
+	if nodeOutputs, ok := workflowData["nodeOutputs"].(map[string]interface{}); ok {
+		outputs := make(map[string]interface{}, len(nodeOutputs))
+		for k, v := range nodeOutputs {
+			outputs[k] = v
+		}
+		forked["nodeOutputs"] = outputs
+	}
+	return forked
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/patali/yantra/src/workflows"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunLoopIterations_BoundedConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	ran := map[int]bool{}
+
+	err := runLoopIterations(context.Background(), 20, 4, 0, func(i int) bool { return i == 3 }, func(ctx context.Context, i int) error {
+		now := running.Add(1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+
+		mu.Lock()
+		ran[i] = true
+		mu.Unlock()
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, ran, 19)
+	assert.False(t, ran[3], "iterations reported done are not run again")
+	assert.LessOrEqual(t, peak.Load(), int32(4))
+	assert.Greater(t, peak.Load(), int32(1))
+}
+
+func TestRunLoopIterations_FailFast(t *testing.T) {
+	var started atomic.Int32
+	err := runLoopIterations(context.Background(), 100, 2, 0, nil, func(ctx context.Context, i int) error {
+		started.Add(1)
+		if i == 1 {
+			return errors.New("boom")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.EqualError(t, err, "boom")
+	assert.Less(t, started.Load(), int32(100), "no new iterations start after a failure")
+}
+
+func TestRunLoopIterations_DelaySpacesStarts(t *testing.T) {
+	started := time.Now()
+	err := runLoopIterations(context.Background(), 3, 3, 20*time.Millisecond, nil, func(ctx context.Context, i int) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(started), 40*time.Millisecond)
+}
+
+func TestRunLoopIterations_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runLoopIterations(ctx, 3, 1, 0, nil, func(ctx context.Context, i int) error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLoopFailures(t *testing.T) {
+	skip := newLoopFailures(&workflows.LoopOptions{ErrorHandling: "skip"}, 4)
+	assert.NoError(t, skip.record(2, errors.New("second")))
+	assert.NoError(t, skip.record(0, errors.New("first")))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"index": 0, "error": "first"},
+		map[string]interface{}{"index": 2, "error": "second"},
+	}, skip.list())
+
+	fail := newLoopFailures(&workflows.LoopOptions{ErrorHandling: "fail"}, 4)
+	assert.Error(t, fail.record(1, errors.New("boom")))
+
+	threshold := newLoopFailures(&workflows.LoopOptions{ErrorHandling: "threshold", MaxFailureRatio: 0.25}, 8)
+	threshold.replay(0, "from checkpoint")
+	assert.NoError(t, threshold.record(5, errors.New("boom")), "2 of 8 is within the ratio")
+	assert.Error(t, threshold.record(6, errors.New("boom")), "3 of 8 exceeds it")
+}
+
+func TestConcatChunkOutputs(t *testing.T) {
+	outputs := []interface{}{
+		chunkOutput(map[string]interface{}{"data": []interface{}{"a", "b"}}, true),
+		nil, // A failed chunk
+		chunkOutput(map[string]interface{}{"data": []interface{}{"c"}}, true),
+		chunkOutput(map[string]interface{}{"data": "d"}, true),
+	}
+	assert.Equal(t, []interface{}{"a", "b", "c", "d"}, concatChunkOutputs(outputs))
+
+	kept := chunkOutput(map[string]interface{}{"data": []interface{}{"a"}, "count": 1}, false)
+	assert.Equal(t, []interface{}{kept}, concatChunkOutputs([]interface{}{kept}))
+	assert.Equal(t, []interface{}{}, concatChunkOutputs(nil))
+}
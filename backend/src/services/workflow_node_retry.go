@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/executors"
+	"gorm.io/gorm"
+)
+
+// ErrRetryNodeNotFound is returned when the node is not in the workflow version the execution ran
+var ErrRetryNodeNotFound = errors.New("node not found in the execution's workflow version")
+
+// executionVersion loads the workflow version an execution was created with
+func (s *WorkflowEngineService) executionVersion(execution *models.WorkflowExecution) (*models.WorkflowVersion, error) {
+	var version models.WorkflowVersion
+	err := s.db.Where("workflow_id = ? AND version = ?", execution.WorkflowID, execution.Version).First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("version %d of workflow %s not found", execution.Version, execution.WorkflowID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow version: %w", err)
+	}
+	return &version, nil
+}
+
+// retryNodeOutputs rebuilds the outputs of an execution's successful nodes, as templates see them during a run.
+// The start node's output is the execution input, and the latest run of a node wins (loops run nodes many times)
+func (s *WorkflowEngineService) retryNodeOutputs(ctx context.Context, executionID, startNodeID string, input map[string]interface{}) (map[string]interface{}, error) {
+	var nodeExecutions []models.WorkflowNodeExecution
+	if err := s.db.WithContext(ctx).
+		Where("execution_id = ? AND status = ?", executionID, "success").
+		Order("started_at ASC").
+		Find(&nodeExecutions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load node executions: %w", err)
+	}
+
+	nodeOutputs := make(map[string]interface{})
+	for _, ne := range nodeExecutions {
+		if ne.Output == nil {
+			continue
+		}
+		var output interface{}
+		if err := json.Unmarshal([]byte(*ne.Output), &output); err == nil {
+			nodeOutputs[ne.NodeID] = output
+		}
+	}
+	nodeOutputs[startNodeID] = input
+
+	return nodeOutputs, nil
+}
+
+// RetryNode queues a failed node of an execution again through the outbox. The node's config comes from the
+// workflow version the execution ran, and its templates see the execution's input, resolved variables and the
+// outputs of the nodes that already succeeded.
+// With resume set, the execution continues downstream from the node once the retry succeeds
+func (s *WorkflowEngineService) RetryNode(ctx context.Context, executionID, accountID, nodeID string, input map[string]interface{}, resume bool) (*models.OutboxMessage, error) {
+	var execution models.WorkflowExecution
+	if err := s.db.First(&execution, "id = ?", executionID).Error; err != nil {
+		return nil, fmt.Errorf("execution not found: %w", err)
+	}
+
+	version, err := s.executionVersion(&execution)
+	if err != nil {
+		return nil, err
+	}
+	definition, err := s.definitions.Get(version.ID, version.Definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse workflow definition: %w", err)
+	}
+
+	node, ok := definition.Node(nodeID)
+	if !ok {
+		return nil, ErrRetryNodeNotFound
+	}
+	if !executors.SupportsAsync(node.Type) {
+		return nil, fmt.Errorf("node type %s cannot be retried individually", node.Type)
+	}
+
+	vars, err := s.environments.ResolveVariables(ctx, &accountID, execution.Environment, definition.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workflow variables: %w", err)
+	}
+
+	var executionInput map[string]interface{}
+	if execution.Input != nil {
+		if err := json.Unmarshal([]byte(*execution.Input), &executionInput); err != nil {
+			return nil, fmt.Errorf("failed to parse execution input: %w", err)
+		}
+	}
+	nodeOutputs, err := s.retryNodeOutputs(ctx, executionID, definition.StartNodeID, executionInput)
+	if err != nil {
+		return nil, err
+	}
+	workflowData := map[string]interface{}{
+		"input":       executionInput,
+		"vars":        vars,
+		"nodeOutputs": nodeOutputs,
+		"execution":   executionMetadata(&execution),
+	}
+
+	_, message, err := s.outboxService.RetryNodeWithOutbox(ctx, executionID, &accountID, nodeID, node.Type, node.Config, input, workflowData, resume)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔁 Node %s of execution %s queued for retry with version %d config (message: %s, resume: %t)",
+		nodeID, executionID, version.Version, message.ID, resume)
+	return message, nil
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/patali/yantra/src/dto"
+	"gorm.io/gorm"
+)
+
+// MaxWorkflowSchedules caps the schedules of one workflow
+const MaxWorkflowSchedules = 50
+
+// MaxScheduleJitter caps how late a schedule's runs may start
+const MaxScheduleJitter = time.Hour
+
+// MaxSchedulePreviewCount caps the fire times a preview returns
+const MaxSchedulePreviewCount = 100
+
+// DefaultSchedulePreviewCount is how many fire times a preview returns when the count is not given
+const DefaultSchedulePreviewCount = 10
+
+// maxScheduleInputSize caps the static input stored with a schedule
+const maxScheduleInputSize = 64 * 1024
+
+// ErrWorkflowNotFound is returned when a workflow does not exist or belongs to another account
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
+// ErrWorkflowScheduleNotFound is returned when a schedule does not exist or belongs to another workflow
+var ErrWorkflowScheduleNotFound = errors.New("schedule not found")
+
+// ListWorkflowSchedules returns a workflow's schedules with their next fire time
+func (s *WorkflowService) ListWorkflowSchedules(ctx context.Context, workflowID, accountID string) ([]dto.WorkflowScheduleResponse, error) {
+	workflow, err := s.findAccountWorkflow(ctx, workflowID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.WorkflowSchedule
+	if err := s.db.WithContext(ctx).Where("workflow_id = ?", workflowID).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	response := make([]dto.WorkflowScheduleResponse, 0, len(rows))
+	for i := range rows {
+		schedule, err := toWorkflowScheduleResponse(&rows[i], workflow.IsActive)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, *schedule)
+	}
+	return response, nil
+}
+
+// CreateWorkflowSchedule adds a schedule to a workflow and registers it with the scheduler
+func (s *WorkflowService) CreateWorkflowSchedule(ctx context.Context, workflowID, accountID string, req dto.CreateWorkflowScheduleRequest) (*dto.WorkflowScheduleResponse, error) {
+	workflow, err := s.findAccountWorkflow(ctx, workflowID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	row := models.WorkflowSchedule{
+		WorkflowID:     workflowID,
+		Name:           req.Name,
+		CronExpression: req.CronExpression,
+		Timezone:       req.Timezone,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		StartAt:        req.StartAt,
+		EndAt:          req.EndAt,
+		JitterSeconds:  req.JitterSeconds,
+		MisfirePolicy:  models.MisfireSkip,
+		MaxMissedRuns:  models.DefaultMaxMissedRuns,
+	}
+	if row.Timezone == "" {
+		row.Timezone = "UTC"
+	}
+	if req.MisfirePolicy != nil {
+		row.MisfirePolicy = *req.MisfirePolicy
+	}
+	if req.MaxMissedRuns != nil {
+		row.MaxMissedRuns = *req.MaxMissedRuns
+	}
+	input, err := encodeScheduleInput(req.Input)
+	if err != nil {
+		return nil, err
+	}
+	row.Input = input
+
+	if err := validateWorkflowScheduleRow(&row); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.WorkflowSchedule{}).Where("workflow_id = ?", workflowID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count schedules: %w", err)
+	}
+	if count >= MaxWorkflowSchedules {
+		return nil, fmt.Errorf("a workflow can have at most %d schedules", MaxWorkflowSchedules)
+	}
+
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	s.refreshScheduleRow(row.ID)
+	return toWorkflowScheduleResponse(&row, workflow.IsActive)
+}
+
+// UpdateWorkflowSchedule changes a schedule; disabling it pauses its runs without touching the workflow's webhooks
+func (s *WorkflowService) UpdateWorkflowSchedule(ctx context.Context, workflowID, scheduleID, accountID string, req dto.UpdateWorkflowScheduleRequest) (*dto.WorkflowScheduleResponse, error) {
+	workflow, row, err := s.findWorkflowSchedule(ctx, workflowID, scheduleID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	timingChanged := false
+	if req.Name != nil {
+		row.Name = *req.Name
+	}
+	if req.CronExpression != nil && *req.CronExpression != row.CronExpression {
+		row.CronExpression = *req.CronExpression
+		timingChanged = true
+	}
+	if req.Timezone != nil && *req.Timezone != row.Timezone {
+		row.Timezone = *req.Timezone
+		timingChanged = true
+	}
+	if req.Input != nil {
+		input, err := encodeScheduleInput(req.Input)
+		if err != nil {
+			return nil, err
+		}
+		row.Input = input
+	}
+	if req.Enabled != nil {
+		// Resuming a paused schedule owes no runs from while it was paused
+		timingChanged = timingChanged || (*req.Enabled && !row.Enabled)
+		row.Enabled = *req.Enabled
+	}
+	if (req.ClearStartAt && req.StartAt != nil) || (req.ClearEndAt && req.EndAt != nil) {
+		return nil, &WorkflowValidationError{Issues: []dto.WorkflowValidationIssue{
+			{Code: IssueInvalidSchedule, Message: "a window bound cannot be set and cleared in the same update"},
+		}}
+	}
+	if req.ClearStartAt {
+		row.StartAt = nil
+	} else if req.StartAt != nil {
+		row.StartAt = req.StartAt
+	}
+	if req.ClearEndAt {
+		row.EndAt = nil
+	} else if req.EndAt != nil {
+		row.EndAt = req.EndAt
+	}
+	if req.JitterSeconds != nil {
+		row.JitterSeconds = *req.JitterSeconds
+	}
+	if req.MisfirePolicy != nil {
+		row.MisfirePolicy = *req.MisfirePolicy
+	}
+	if req.MaxMissedRuns != nil {
+		row.MaxMissedRuns = *req.MaxMissedRuns
+	}
+	if timingChanged && row.LastScheduledAt != nil {
+		now := time.Now()
+		row.LastScheduledAt = &now
+	}
+
+	if err := validateWorkflowScheduleRow(row); err != nil {
+		return nil, err
+	}
+
+	// Save writes every column, so clearing startAt or pausing the schedule is stored too
+	if err := s.db.WithContext(ctx).Save(row).Error; err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	s.refreshScheduleRow(row.ID)
+	return toWorkflowScheduleResponse(row, workflow.IsActive)
+}
+
+// DeleteWorkflowSchedule removes a schedule and its cron entry
+func (s *WorkflowService) DeleteWorkflowSchedule(ctx context.Context, workflowID, scheduleID, accountID string) error {
+	_, row, err := s.findWorkflowSchedule(ctx, workflowID, scheduleID, accountID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(row).Error; err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+
+	s.refreshScheduleRow(row.ID)
+	return nil
+}
+
+// PreviewWorkflowSchedules returns the next count fire times of a workflow across its own schedule and its
+// enabled schedules, earliest first. An inactive workflow has none, since the scheduler does not run it.
+// With cronExpr set, it previews that expression instead, so editors can check an expression before saving it.
+// Times are nominal: jitter is not applied
+func (s *WorkflowService) PreviewWorkflowSchedules(ctx context.Context, workflowID, accountID string, count int, cronExpr, timezone string) ([]dto.ScheduledRunPreview, error) {
+	workflow, err := s.findAccountWorkflow(ctx, workflowID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var runs []dto.ScheduledRunPreview
+
+	if cronExpr != "" {
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		schedule, err := newValidatedTimezoneSchedule(cronExpr, timezone)
+		if err != nil {
+			return nil, &WorkflowValidationError{Issues: []dto.WorkflowValidationIssue{
+				{Code: IssueInvalidSchedule, Message: err.Error()},
+			}}
+		}
+		return previewRuns(schedule, now, count, nil, "", timezone), nil
+	}
+
+	if !workflow.IsActive {
+		return []dto.ScheduledRunPreview{}, nil
+	}
+
+	if workflow.Schedule != nil && *workflow.Schedule != "" {
+		if schedule, err := newTimezoneSchedule(*workflow.Schedule, workflow.Timezone); err == nil {
+			runs = append(runs, previewRuns(schedule, now, count, nil, "", workflow.Timezone)...)
+		}
+	}
+
+	var rows []models.WorkflowSchedule
+	if err := s.db.WithContext(ctx).Where("workflow_id = ? AND enabled = ?", workflowID, true).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	for i := range rows {
+		entry, err := newScheduleRowEntry(&rows[i])
+		if err != nil {
+			continue
+		}
+		runs = append(runs, previewRuns(entry.schedule, now, count, entry.scheduleID, rows[i].Name, rows[i].Timezone)...)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Time.Before(runs[j].Time) })
+	if len(runs) > count {
+		runs = runs[:count]
+	}
+	if runs == nil {
+		runs = []dto.ScheduledRunPreview{}
+	}
+	return runs, nil
+}
+
+// previewRuns returns up to count fire times of a schedule after now, shown in the schedule's timezone
+func previewRuns(schedule *TimezoneSchedule, now time.Time, count int, scheduleID *string, name, timezone string) []dto.ScheduledRunPreview {
+	runs := make([]dto.ScheduledRunPreview, 0, count)
+	for t := schedule.Next(now); !t.IsZero() && len(runs) < count; t = schedule.Next(t) {
+		runs = append(runs, dto.ScheduledRunPreview{
+			ScheduleID: scheduleID,
+			Name:       name,
+			Time:       t.In(schedule.location),
+			Timezone:   timezone,
+		})
+	}
+	return runs
+}
+
+// findAccountWorkflow loads a workflow of an account
+func (s *WorkflowService) findAccountWorkflow(ctx context.Context, workflowID, accountID string) (*models.Workflow, error) {
+	var workflow models.Workflow
+	err := s.db.WithContext(ctx).Where("id = ? AND account_id = ?", workflowID, accountID).First(&workflow).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWorkflowNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find workflow: %w", err)
+	}
+	return &workflow, nil
+}
+
+// findWorkflowSchedule loads a schedule of an account's workflow, along with the workflow
+func (s *WorkflowService) findWorkflowSchedule(ctx context.Context, workflowID, scheduleID, accountID string) (*models.Workflow, *models.WorkflowSchedule, error) {
+	workflow, err := s.findAccountWorkflow(ctx, workflowID, accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var row models.WorkflowSchedule
+	err = s.db.WithContext(ctx).Where("id = ? AND workflow_id = ?", scheduleID, workflowID).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrWorkflowScheduleNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find schedule: %w", err)
+	}
+	return workflow, &row, nil
+}
+
+// refreshScheduleRow brings the scheduler in line with a schedule row; failures are picked up by the next sync
+func (s *WorkflowService) refreshScheduleRow(scheduleID string) {
+	if s.schedulerService == nil {
+		return
+	}
+	if err := s.schedulerService.RefreshScheduleRow(scheduleID); err != nil {
+		log.Printf("⚠️  Failed to refresh schedule %s: %v", scheduleID, err)
+	}
+}
+
+// validateWorkflowScheduleRow checks a schedule before it is stored, reporting every problem at once
+func validateWorkflowScheduleRow(row *models.WorkflowSchedule) error {
+	var issues []dto.WorkflowValidationIssue
+	if _, err := newValidatedTimezoneSchedule(row.CronExpression, row.Timezone); err != nil {
+		issues = append(issues, dto.WorkflowValidationIssue{Code: IssueInvalidSchedule, Message: err.Error()})
+	}
+	if row.StartAt != nil && row.EndAt != nil && !row.EndAt.After(*row.StartAt) {
+		issues = append(issues, dto.WorkflowValidationIssue{Code: IssueInvalidSchedule, Message: "endAt must be after startAt"})
+	}
+	if row.JitterSeconds < 0 || time.Duration(row.JitterSeconds)*time.Second > MaxScheduleJitter {
+		issues = append(issues, dto.WorkflowValidationIssue{
+			Code:    IssueInvalidSchedule,
+			Message: fmt.Sprintf("jitterSeconds must be between 0 and %d", int(MaxScheduleJitter/time.Second)),
+		})
+	}
+	if err := validateMisfirePolicy(&row.MisfirePolicy, &row.MaxMissedRuns); err != nil {
+		validationErr, _ := AsWorkflowValidationError(err)
+		issues = append(issues, validationErr.Issues...)
+	}
+	if len(issues) > 0 {
+		return &WorkflowValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// newValidatedTimezoneSchedule parses a schedule, rejecting unknown timezones instead of falling back to UTC
+func newValidatedTimezoneSchedule(cronExpr, timezone string) (*TimezoneSchedule, error) {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("unknown timezone '%s'", timezone)
+	}
+	return newTimezoneSchedule(cronExpr, timezone)
+}
+
+// encodeScheduleInput serializes a schedule's static input
+func encodeScheduleInput(input map[string]interface{}) (string, error) {
+	if input == nil {
+		return "{}", nil
+	}
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize input: %w", err)
+	}
+	if len(inputJSON) > maxScheduleInputSize {
+		return "", fmt.Errorf("schedule input size (%d bytes) exceeds maximum allowed (%d bytes)", len(inputJSON), maxScheduleInputSize)
+	}
+	return string(inputJSON), nil
+}
+
+// toWorkflowScheduleResponse builds a schedule's response; nextRunAt is only set when the scheduler will run it
+func toWorkflowScheduleResponse(row *models.WorkflowSchedule, workflowActive bool) (*dto.WorkflowScheduleResponse, error) {
+	input := map[string]interface{}{}
+	if row.Input != "" {
+		if err := json.Unmarshal([]byte(row.Input), &input); err != nil {
+			return nil, fmt.Errorf("failed to parse schedule input: %w", err)
+		}
+	}
+
+	var nextRunAt *time.Time
+	if row.Enabled && workflowActive {
+		if entry, err := newScheduleRowEntry(row); err == nil {
+			if next := entry.schedule.Next(time.Now()); !next.IsZero() {
+				nextRunAt = &next
+			}
+		}
+	}
+
+	return &dto.WorkflowScheduleResponse{
+		ID:              row.ID,
+		WorkflowID:      row.WorkflowID,
+		Name:            row.Name,
+		CronExpression:  row.CronExpression,
+		Timezone:        row.Timezone,
+		Input:           input,
+		Enabled:         row.Enabled,
+		StartAt:         row.StartAt,
+		EndAt:           row.EndAt,
+		JitterSeconds:   row.JitterSeconds,
+		MisfirePolicy:   row.MisfirePolicy,
+		MaxMissedRuns:   row.MaxMissedRuns,
+		LastScheduledAt: row.LastScheduledAt,
+		NextRunAt:       nextRunAt,
+		CreatedAt:       row.CreatedAt,
+		UpdatedAt:       row.UpdatedAt,
+	}, nil
+}
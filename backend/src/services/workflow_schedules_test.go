@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/patali/yantra/src/db/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWorkflowScheduleRow(t *testing.T) {
+	valid := models.WorkflowSchedule{
+		CronExpression: "0 9 * * 1-5",
+		Timezone:       "Europe/Berlin",
+		JitterSeconds:  300,
+		MisfirePolicy:  models.MisfireRunOnce,
+		MaxMissedRuns:  models.DefaultMaxMissedRuns,
+	}
+	assert.NoError(t, validateWorkflowScheduleRow(&valid))
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Hour)
+	invalid := models.WorkflowSchedule{
+		CronExpression: "not a cron",
+		Timezone:       "UTC",
+		StartAt:        &start,
+		EndAt:          &end,
+		JitterSeconds:  7200,
+		MisfirePolicy:  "sometimes",
+		MaxMissedRuns:  models.DefaultMaxMissedRuns,
+	}
+	err := validateWorkflowScheduleRow(&invalid)
+	validationErr, ok := AsWorkflowValidationError(err)
+	assert.True(t, ok)
+	assert.Len(t, validationErr.Issues, 4)
+
+	unknownZone := valid
+	unknownZone.Timezone = "Mars/Olympus_Mons"
+	assert.Error(t, validateWorkflowScheduleRow(&unknownZone))
+}
+
+func TestPreviewRuns(t *testing.T) {
+	schedule, err := newTimezoneSchedule("0 9 * * *", "America/New_York")
+	assert.NoError(t, err)
+	now := time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC) // 10:00 in New York
+
+	runs := previewRuns(schedule, now, 3, nil, "", "America/New_York")
+	assert.Len(t, runs, 3)
+	assert.Equal(t, 9, runs[0].Time.Hour())
+	assert.Equal(t, 2, runs[0].Time.Day())
+	assert.Equal(t, "America/New_York", runs[0].Time.Location().String())
+
+	// A schedule whose window has ended has nothing to preview
+	end := now.Add(-time.Hour)
+	schedule.end = &end
+	assert.Empty(t, previewRuns(schedule, now, 3, nil, "", "America/New_York"))
+}
+
+func TestToWorkflowScheduleResponse_NextRunAt(t *testing.T) {
+	row := models.WorkflowSchedule{
+		ID:             "schedule-1",
+		CronExpression: "0 9 * * *",
+		Timezone:       "UTC",
+		Enabled:        true,
+	}
+
+	response, err := toWorkflowScheduleResponse(&row, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, response.NextRunAt)
+
+	// The scheduler does not run schedules of inactive workflows
+	response, err = toWorkflowScheduleResponse(&row, false)
+	assert.NoError(t, err)
+	assert.Nil(t, response.NextRunAt)
+
+	row.Enabled = false
+	response, err = toWorkflowScheduleResponse(&row, true)
+	assert.NoError(t, err)
+	assert.Nil(t, response.NextRunAt)
+}
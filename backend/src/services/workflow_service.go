@@ -24,6 +24,7 @@ type WorkflowService struct {
 	repo             repositories.Repository
 	queueService     *QueueService
 	schedulerService *SchedulerService
+	environments     *EnvironmentService
 }
 
 func NewWorkflowService(db *gorm.DB, queueService *QueueService) *WorkflowService {
@@ -31,6 +32,7 @@ func NewWorkflowService(db *gorm.DB, queueService *QueueService) *WorkflowServic
 		db:           db,
 		repo:         repositories.NewRepository(db),
 		queueService: queueService,
+		environments: NewEnvironmentService(db),
 	}
 }
 
@@ -72,6 +74,9 @@ func (s *WorkflowService) GetAllWorkflows(accountID string) ([]dto.WorkflowRespo
 			IsActive:           w.IsActive,
 			Schedule:           w.Schedule,
 			Timezone:           w.Timezone,
+			MisfirePolicy:      w.MisfirePolicy,
+			MaxMissedRuns:      w.MaxMissedRuns,
+			LastScheduledAt:    w.LastScheduledAt,
 			WebhookPath:        w.WebhookPath,
 			WebhookRequireAuth: w.WebhookRequireAuth,
 			CurrentVersion:     w.CurrentVersion,
@@ -137,70 +142,10 @@ func validateWebhookPath(path *string) (*string, error) {
 	return &webhookPath, nil
 }
 
-// validateWorkflowDefinition validates a workflow definition structure
-func validateWorkflowDefinition(definition map[string]interface{}) error {
-	// Check for nodes
-	nodesInterface, ok := definition["nodes"]
-	if !ok {
-		return fmt.Errorf("workflow definition must contain 'nodes' field")
-	}
-
-	nodes, ok := nodesInterface.([]interface{})
-	if !ok {
-		return fmt.Errorf("'nodes' field must be an array")
-	}
-
-	if len(nodes) == 0 {
-		return fmt.Errorf("workflow must contain at least one node")
-	}
-
-	startCount := 0
-	endCount := 0
-
-	// Validate each node
-	for i, nodeInterface := range nodes {
-		node, ok := nodeInterface.(map[string]interface{})
-		if !ok {
-			return fmt.Errorf("node at index %d is not a valid object", i)
-		}
-
-		// Check node ID
-		nodeID, ok := node["id"].(string)
-		if !ok || nodeID == "" {
-			return fmt.Errorf("node at index %d missing or invalid 'id' field", i)
-		}
-
-		// Check node type
-		nodeType, ok := node["type"].(string)
-		if !ok || nodeType == "" {
-			return fmt.Errorf("node '%s' missing or invalid 'type' field", nodeID)
-		}
-
-		// Validate node type is supported
-		if !executors.IsValidNodeType(nodeType) {
-			return fmt.Errorf("node '%s' has unsupported type '%s'", nodeID, nodeType)
-		}
-
-		// Count start and end nodes
-		if nodeType == executors.NodeTypeStart {
-			startCount++
-		}
-		if nodeType == executors.NodeTypeEnd {
-			endCount++
-		}
-	}
-
-	// Validate start node count
-	if startCount != 1 {
-		return fmt.Errorf("workflow must have exactly one start node, found %d", startCount)
-	}
-
-	// Validate end node count
-	if endCount < 1 {
-		return fmt.Errorf("workflow must have at least one end node, found %d", endCount)
-	}
-
-	return nil
+// ValidateWorkflow checks a definition and schedule without saving anything
+func (s *WorkflowService) ValidateWorkflow(req dto.ValidateWorkflowRequest) dto.ValidateWorkflowResponse {
+	issues := ValidateWorkflowDefinition(req.Definition, req.Schedule)
+	return dto.ValidateWorkflowResponse{Valid: len(issues) == 0, Issues: issues}
 }
 
 // CreateWorkflow creates a new workflow with optional scheduling
@@ -216,11 +161,15 @@ func (s *WorkflowService) CreateWorkflow(ctx context.Context, req dto.CreateWork
 		return nil, fmt.Errorf("invalid webhook path: %w", err)
 	}
 
-	// Validate workflow definition structure
-	if err := validateWorkflowDefinition(req.Definition); err != nil {
+	// Validate workflow definition and schedule, reporting every problem at once
+	if err := validateWorkflowDefinition(req.Definition, req.Schedule); err != nil {
 		return nil, fmt.Errorf("invalid workflow definition: %w", err)
 	}
 
+	if err := validateMisfirePolicy(req.MisfirePolicy, req.MaxMissedRuns); err != nil {
+		return nil, err
+	}
+
 	// Handle empty string for schedule - treat as nil (no schedule)
 	var schedule *string
 	if req.Schedule != nil && *req.Schedule != "" {
@@ -245,6 +194,8 @@ func (s *WorkflowService) CreateWorkflow(ctx context.Context, req dto.CreateWork
 			Description:        req.Description,
 			Schedule:           schedule,
 			Timezone:           timezone,
+			MisfirePolicy:      models.MisfireSkip,
+			MaxMissedRuns:      models.DefaultMaxMissedRuns,
 			WebhookPath:        validatedWebhookPath,
 			WebhookRequireAuth: req.WebhookRequireAuth != nil && *req.WebhookRequireAuth,
 			CurrentVersion:     1,
@@ -252,6 +203,13 @@ func (s *WorkflowService) CreateWorkflow(ctx context.Context, req dto.CreateWork
 			CreatedBy:          createdBy,
 		}
 
+		if req.MisfirePolicy != nil {
+			workflow.MisfirePolicy = *req.MisfirePolicy
+		}
+		if req.MaxMissedRuns != nil {
+			workflow.MaxMissedRuns = *req.MaxMissedRuns
+		}
+
 		if err := txRepo.Workflow().Create(ctx, &workflow); err != nil {
 			return err
 		}
@@ -353,7 +311,7 @@ func (s *WorkflowService) UpdateWorkflow(id string, req dto.UpdateWorkflowReques
 
 	// Validate workflow definition if provided
 	if req.Definition != nil {
-		if err := validateWorkflowDefinition(req.Definition); err != nil {
+		if err := validateWorkflowDefinition(req.Definition, req.Schedule); err != nil {
 			return nil, fmt.Errorf("invalid workflow definition: %w", err)
 		}
 	}
@@ -387,10 +345,23 @@ func (s *WorkflowService) UpdateWorkflowByAccount(id, accountID string, req dto.
 
 	newVersion := workflow.CurrentVersion
 
+	if err := validateMisfirePolicy(req.MisfirePolicy, req.MaxMissedRuns); err != nil {
+		return nil, err
+	}
+
+	// Validate the schedule on its own when the definition is unchanged
+	if req.Definition == nil && req.Schedule != nil && *req.Schedule != "" {
+		if _, err := ParseCronExpression(*req.Schedule); err != nil {
+			return nil, &WorkflowValidationError{Issues: []dto.WorkflowValidationIssue{
+				{Code: IssueInvalidSchedule, Message: err.Error()},
+			}}
+		}
+	}
+
 	// If definition is updated, create a new version
 	if req.Definition != nil {
-		// Validate workflow definition structure
-		if err := validateWorkflowDefinition(req.Definition); err != nil {
+		// Validate workflow definition and schedule
+		if err := validateWorkflowDefinition(req.Definition, req.Schedule); err != nil {
 			return nil, fmt.Errorf("invalid workflow definition: %w", err)
 		}
 
@@ -433,11 +404,20 @@ func (s *WorkflowService) UpdateWorkflowByAccount(id, accountID string, req dto.
 			updates["schedule"] = nil
 		} else {
 			updates["schedule"] = req.Schedule
+			if scheduleReplaced(workflow, req.Schedule) {
+				updates["last_scheduled_at"] = time.Now()
+			}
 		}
 	}
 	if req.Timezone != nil {
 		updates["timezone"] = *req.Timezone
 	}
+	if req.MisfirePolicy != nil {
+		updates["misfire_policy"] = *req.MisfirePolicy
+	}
+	if req.MaxMissedRuns != nil {
+		updates["max_missed_runs"] = *req.MaxMissedRuns
+	}
 	if req.WebhookPath != nil {
 		// SECURITY: Validate webhook path before updating
 		validatedWebhookPath, err := validateWebhookPath(req.WebhookPath)
@@ -504,6 +484,10 @@ func (s *WorkflowService) UpdateSchedule(ctx context.Context, id string, req dto
 		return err
 	}
 
+	if err := validateMisfirePolicy(req.MisfirePolicy, req.MaxMissedRuns); err != nil {
+		return err
+	}
+
 	// Determine timezone (use provided or keep existing)
 	timezone := workflow.Timezone
 	if req.Timezone != nil {
@@ -541,6 +525,15 @@ func (s *WorkflowService) UpdateSchedule(ctx context.Context, id string, req dto
 		"timezone":  timezone,
 		"is_active": isActive,
 	}
+	if req.MisfirePolicy != nil {
+		updates["misfire_policy"] = *req.MisfirePolicy
+	}
+	if req.MaxMissedRuns != nil {
+		updates["max_missed_runs"] = *req.MaxMissedRuns
+	}
+	if schedule != nil && (!workflow.IsActive || scheduleReplaced(workflow, schedule)) {
+		updates["last_scheduled_at"] = time.Now()
+	}
 
 	if err := s.repo.Workflow().Update(ctx, id, updates); err != nil {
 		return fmt.Errorf("failed to update schedule: %w", err)
@@ -566,6 +559,33 @@ func (s *WorkflowService) UpdateSchedule(ctx context.Context, id string, req dto
 	return nil
 }
 
+// validateMisfirePolicy checks the misfire settings of a schedule update; nil fields are left unchanged
+func validateMisfirePolicy(policy *string, maxMissedRuns *int) error {
+	var issues []dto.WorkflowValidationIssue
+	if policy != nil && !models.IsValidMisfirePolicy(*policy) {
+		issues = append(issues, dto.WorkflowValidationIssue{
+			Code:    IssueInvalidMisfire,
+			Message: fmt.Sprintf("misfire policy must be one of %s", strings.Join(models.AllMisfirePolicies, ", ")),
+		})
+	}
+	if maxMissedRuns != nil && (*maxMissedRuns < 1 || *maxMissedRuns > models.MaxMissedRunsLimit) {
+		issues = append(issues, dto.WorkflowValidationIssue{
+			Code:    IssueInvalidMisfire,
+			Message: fmt.Sprintf("maxMissedRuns must be between 1 and %d", models.MaxMissedRunsLimit),
+		})
+	}
+	if len(issues) > 0 {
+		return &WorkflowValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// scheduleReplaced reports whether a schedule differs from the workflow's current one.
+// A new schedule owes no runs from before it was set, so callers restart its missed-run tracking
+func scheduleReplaced(workflow *models.Workflow, schedule *string) bool {
+	return workflow.Schedule == nil || *workflow.Schedule != *schedule
+}
+
 // DeleteWorkflow deletes a workflow and unschedules it
 func (s *WorkflowService) DeleteWorkflow(ctx context.Context, id string) error {
 	workflow, err := s.repo.Workflow().FindByID(ctx, id)
@@ -598,13 +618,14 @@ func (s *WorkflowService) DeleteWorkflowByAccount(ctx context.Context, id, accou
 	return s.repo.Workflow().Delete(ctx, id)
 }
 
-// ExecuteWorkflow queues a workflow for execution
+// ExecuteWorkflow queues a workflow for execution in the account's default environment
 func (s *WorkflowService) ExecuteWorkflow(ctx context.Context, id string, input map[string]interface{}) (jobID string, executionID string, err error) {
-	return s.ExecuteWorkflowWithTrigger(ctx, id, input, models.TriggerTypeManual)
+	return s.ExecuteWorkflowWithTrigger(ctx, id, input, models.TriggerTypeManual, "")
 }
 
 // ExecuteWorkflowWithTrigger queues a workflow for execution with a specific trigger type
-func (s *WorkflowService) ExecuteWorkflowWithTrigger(ctx context.Context, id string, input map[string]interface{}, triggerType string) (jobID string, executionID string, err error) {
+// environment names the account environment whose variables apply (empty = account default)
+func (s *WorkflowService) ExecuteWorkflowWithTrigger(ctx context.Context, id string, input map[string]interface{}, triggerType, environment string) (jobID string, executionID string, err error) {
 	// SECURITY: Validate workflow ID format (must be valid UUID)
 	// This provides defense in depth even though route params should already be validated
 	if _, err := uuid.Parse(id); err != nil {
@@ -612,7 +633,7 @@ func (s *WorkflowService) ExecuteWorkflowWithTrigger(ctx context.Context, id str
 	}
 
 	// Check if workflow exists
-	_, err = s.repo.Workflow().FindByID(ctx, id)
+	workflow, err := s.repo.Workflow().FindByID(ctx, id)
 	if err != nil {
 		return "", "", err
 	}
@@ -623,6 +644,20 @@ func (s *WorkflowService) ExecuteWorkflowWithTrigger(ctx context.Context, id str
 		return "", "", err
 	}
 
+	// Pin the environment on the execution so resumes use the same variables
+	var environmentName *string
+	if workflow.AccountID != nil {
+		env, err := s.environments.ResolveEnvironment(ctx, *workflow.AccountID, environment)
+		if err != nil {
+			return "", "", err
+		}
+		if env != nil {
+			environmentName = &env.Name
+		}
+	} else if environment != "" {
+		return "", "", fmt.Errorf("%w: %s", ErrEnvironmentNotFound, environment)
+	}
+
 	// SECURITY: Validate input size before creating execution record
 	// This prevents DoS attacks via large payloads
 	inputJSON, err := json.Marshal(input)
@@ -643,6 +678,7 @@ func (s *WorkflowService) ExecuteWorkflowWithTrigger(ctx context.Context, id str
 		Version:     latestVersion.Version,
 		Status:      "queued",
 		TriggerType: triggerType,
+		Environment: environmentName,
 	}
 	if len(inputStr) > 0 && inputStr != "null" {
 		execution.Input = &inputStr
@@ -959,11 +995,20 @@ func (s *WorkflowService) RestoreWorkflowVersion(id string, version int) error {
 	ctx := context.Background()
 
 	// Get the version to ensure it exists
-	_, err := s.repo.WorkflowVersion().FindByWorkflowIDAndVersion(ctx, id, version)
+	workflowVersion, err := s.repo.WorkflowVersion().FindByWorkflowIDAndVersion(ctx, id, version)
 	if err != nil {
 		return err
 	}
 
+	// Older versions may predate current validation rules; don't restore a definition that can't run
+	var definition map[string]interface{}
+	if err := json.Unmarshal([]byte(workflowVersion.Definition), &definition); err != nil {
+		return fmt.Errorf("failed to parse version definition: %w", err)
+	}
+	if err := validateWorkflowDefinition(definition, nil); err != nil {
+		return fmt.Errorf("cannot restore version %d: %w", version, err)
+	}
+
 	// Update the workflow with the version
 	return s.repo.Workflow().Update(ctx, id, map[string]interface{}{
 		"current_version": version,
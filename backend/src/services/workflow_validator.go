@@ -0,0 +1,447 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
+	"github.com/patali/yantra/src/retry"
+	"github.com/patali/yantra/src/workflows"
+)
+
+// Workflow validation issue codes
+const (
+	IssueInvalidDefinition   = "invalid_definition"
+	IssueInvalidNode         = "invalid_node"
+	IssueDuplicateNodeID     = "duplicate_node_id"
+	IssueUnsupportedNodeType = "unsupported_node_type"
+	IssueStartNodeCount      = "start_node_count"
+	IssueMissingEndNode      = "missing_end_node"
+	IssueInvalidEdge         = "invalid_edge"
+	IssueMissingConfig       = "missing_config"
+	IssueInvalidCondition    = "invalid_condition"
+	IssueInvalidSchedule     = "invalid_schedule"
+	IssueCycle               = "cycle"
+	IssueUnreachableNode     = "unreachable_node"
+	IssueLoopBodyNoEnd       = "loop_body_no_end"
+	IssueInvalidVariable     = "invalid_variable"
+	IssueInvalidLoopOption   = "invalid_loop_option"
+	IssueInvalidRetryPolicy  = "invalid_retry_policy"
+	IssueInvalidMisfire      = "invalid_misfire_policy"
+)
+
+// WorkflowValidationError is returned when a workflow definition has problems; it carries all of them
+type WorkflowValidationError struct {
+	Issues []dto.WorkflowValidationIssue
+}
+
+func (e *WorkflowValidationError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, issue.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// AsWorkflowValidationError unwraps a WorkflowValidationError
+func AsWorkflowValidationError(err error) (*WorkflowValidationError, bool) {
+	var validationErr *WorkflowValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr, true
+	}
+	return nil, false
+}
+
+// validateWorkflowDefinition validates a definition and optional schedule, returning every problem found
+func validateWorkflowDefinition(definition map[string]interface{}, schedule *string) error {
+	if issues := ValidateWorkflowDefinition(definition, schedule); len(issues) > 0 {
+		return &WorkflowValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// workflowValidator collects issues while walking a definition.
+// Structural problems are found on the raw JSON; graph checks run on the compiled definition the engine uses
+type workflowValidator struct {
+	issues     []dto.WorkflowValidationIssue
+	nodeTypes  map[string]string
+	startCount int
+	def        *workflows.Definition
+}
+
+func (v *workflowValidator) add(code, nodeID, edgeID, format string, args ...interface{}) {
+	v.issues = append(v.issues, dto.WorkflowValidationIssue{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		NodeID:  nodeID,
+		EdgeID:  edgeID,
+	})
+}
+
+// ValidateWorkflowDefinition statically checks a workflow definition and returns all problems at once:
+// node structure and types, required config, conditions, edges, cycles outside loops,
+// reachability from start, loop bodies and options, variable names and the cron schedule (when given)
+func ValidateWorkflowDefinition(definition map[string]interface{}, schedule *string) []dto.WorkflowValidationIssue {
+	v := &workflowValidator{
+		issues:    []dto.WorkflowValidationIssue{},
+		nodeTypes: make(map[string]string),
+	}
+
+	if schedule != nil && strings.TrimSpace(*schedule) != "" {
+		if _, err := ParseCronExpression(*schedule); err != nil {
+			v.add(IssueInvalidSchedule, "", "", "%s", err.Error())
+		}
+	}
+
+	nodesInterface, ok := definition["nodes"]
+	if !ok {
+		v.add(IssueInvalidDefinition, "", "", "workflow definition must contain 'nodes' field")
+		return v.issues
+	}
+	nodes, ok := nodesInterface.([]interface{})
+	if !ok {
+		v.add(IssueInvalidDefinition, "", "", "'nodes' field must be an array")
+		return v.issues
+	}
+	if len(nodes) == 0 {
+		v.add(IssueInvalidDefinition, "", "", "workflow must contain at least one node")
+		return v.issues
+	}
+
+	v.checkNodes(nodes)
+	v.checkEdges(definition["edges"])
+	v.checkVariables(definition["variables"])
+
+	def, err := workflows.Compile(definition)
+	if err != nil {
+		v.add(IssueInvalidDefinition, "", "", "%s", err.Error())
+		return v.issues
+	}
+	v.def = def
+	v.checkConditions()
+
+	if v.startCount == 1 {
+		v.checkCycles()
+		v.checkReachability()
+		v.checkLoopBodies()
+	}
+	v.checkLoopOptions()
+
+	return v.issues
+}
+
+func (v *workflowValidator) checkNodes(nodes []interface{}) {
+	endCount := 0
+
+	for i, nodeInterface := range nodes {
+		node, ok := nodeInterface.(map[string]interface{})
+		if !ok {
+			v.add(IssueInvalidNode, "", "", "node at index %d is not a valid object", i)
+			continue
+		}
+
+		nodeID, ok := node["id"].(string)
+		if !ok || nodeID == "" {
+			v.add(IssueInvalidNode, "", "", "node at index %d missing or invalid 'id' field", i)
+			continue
+		}
+		if _, exists := v.nodeTypes[nodeID]; exists {
+			v.add(IssueDuplicateNodeID, nodeID, "", "node id '%s' is used by more than one node", nodeID)
+			continue
+		}
+
+		nodeType, ok := node["type"].(string)
+		if !ok || nodeType == "" {
+			v.add(IssueInvalidNode, nodeID, "", "node '%s' missing or invalid 'type' field", nodeID)
+		} else if !executors.IsValidNodeType(nodeType) {
+			v.add(IssueUnsupportedNodeType, nodeID, "", "node '%s' has unsupported type '%s'", nodeID, nodeType)
+		}
+
+		v.nodeTypes[nodeID] = nodeType
+
+		switch nodeType {
+		case executors.NodeTypeStart:
+			v.startCount++
+		case executors.NodeTypeEnd:
+			endCount++
+		}
+
+		v.checkNodeConfig(nodeID, nodeType, nodeConfig(node))
+	}
+
+	if v.startCount != 1 {
+		v.add(IssueStartNodeCount, "", "", "workflow must have exactly one start node, found %d", v.startCount)
+	}
+	if endCount < 1 {
+		v.add(IssueMissingEndNode, "", "", "workflow must have at least one end node, found %d", endCount)
+	}
+}
+
+// nodeConfig returns node.data.config, or an empty map when the node has none
+func nodeConfig(node map[string]interface{}) map[string]interface{} {
+	if data, ok := node["data"].(map[string]interface{}); ok {
+		if config, ok := data["config"].(map[string]interface{}); ok {
+			return config
+		}
+	}
+	return map[string]interface{}{}
+}
+
+func hasConfigValue(config map[string]interface{}, key string) bool {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return false
+	}
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s) != ""
+	}
+	return true
+}
+
+func (v *workflowValidator) checkNodeConfig(nodeID, nodeType string, config map[string]interface{}) {
+	// Required fields come from the node type's registered config schema
+	if registered, ok := executors.DefaultRegistry().Lookup(nodeType); ok {
+		for _, field := range registered.Config {
+			if field.Required && !hasConfigValue(config, field.Name) {
+				v.add(IssueMissingConfig, nodeID, "", "node '%s' (%s) is missing required config '%s'", nodeID, nodeType, field.Name)
+			}
+		}
+	}
+
+	if executors.NodeRequiresOutbox(nodeType, config) {
+		if _, err := retry.FromConfig(config); err != nil {
+			v.add(IssueInvalidRetryPolicy, nodeID, "", "node '%s' (%s) has an invalid retry policy: %s", nodeID, nodeType, err.Error())
+		}
+	}
+
+	switch nodeType {
+	case executors.NodeTypeConditional:
+		// String conditions are parsed in checkConditions
+		condition, _ := config["condition"].(string)
+		if conditions, ok := config["conditions"].([]interface{}); strings.TrimSpace(condition) == "" && (!ok || len(conditions) == 0) {
+			v.add(IssueMissingConfig, nodeID, "", "node '%s' (conditional) needs a 'condition' or 'conditions'", nodeID)
+		}
+
+	case executors.NodeTypeSleep:
+		mode, _ := config["mode"].(string)
+		var required []string
+		switch mode {
+		case "":
+			// Reported as missing above
+		case "absolute":
+			required = []string{"target_date"}
+		case "relative":
+			required = []string{"duration_value", "duration_unit"}
+		default:
+			v.add(IssueMissingConfig, nodeID, "", "node '%s' (sleep) has invalid mode '%s' (must be 'absolute' or 'relative')", nodeID, mode)
+		}
+		for _, key := range required {
+			if !hasConfigValue(config, key) {
+				v.add(IssueMissingConfig, nodeID, "", "node '%s' (sleep) is missing required config '%s' for %s mode", nodeID, key, mode)
+			}
+		}
+	}
+}
+
+// checkConditions reports node and edge conditions that do not parse, with the position of the problem
+func (v *workflowValidator) checkConditions() {
+	for _, node := range v.def.Nodes {
+		if node.ConditionErr != nil {
+			v.add(IssueInvalidCondition, node.ID, "", "node '%s' has an invalid condition: %s", node.ID, node.ConditionErr.Error())
+		}
+	}
+	for _, edge := range v.def.Edges {
+		if edge.ConditionErr == nil {
+			continue
+		}
+		label := fmt.Sprintf("'%s'", edge.ID)
+		if edge.ID == "" {
+			label = fmt.Sprintf("'%s' -> '%s'", edge.Source, edge.Target)
+		}
+		v.add(IssueInvalidCondition, edge.Source, edge.ID, "edge %s has an invalid condition: %s", label, edge.ConditionErr.Error())
+	}
+}
+
+func (v *workflowValidator) checkEdges(edgesInterface interface{}) {
+	if edgesInterface == nil {
+		return
+	}
+	edges, ok := edgesInterface.([]interface{})
+	if !ok {
+		v.add(IssueInvalidDefinition, "", "", "'edges' field must be an array")
+		return
+	}
+
+	for i, edgeInterface := range edges {
+		edge, ok := edgeInterface.(map[string]interface{})
+		if !ok {
+			v.add(IssueInvalidEdge, "", "", "edge at index %d is not a valid object", i)
+			continue
+		}
+
+		edgeID, _ := edge["id"].(string)
+		label := edgeID
+		if label == "" {
+			label = fmt.Sprintf("at index %d", i)
+		} else {
+			label = fmt.Sprintf("'%s'", label)
+		}
+
+		source, _ := edge["source"].(string)
+		target, _ := edge["target"].(string)
+		if source == "" || target == "" {
+			v.add(IssueInvalidEdge, "", edgeID, "edge %s missing 'source' or 'target'", label)
+			continue
+		}
+
+		if _, ok := v.nodeTypes[source]; !ok {
+			v.add(IssueInvalidEdge, "", edgeID, "edge %s references missing source node '%s'", label, source)
+		}
+		if _, ok := v.nodeTypes[target]; !ok {
+			v.add(IssueInvalidEdge, "", edgeID, "edge %s references missing target node '%s'", label, target)
+		}
+	}
+}
+
+// checkVariables checks the optional workflow-level variables object
+func (v *workflowValidator) checkVariables(variablesInterface interface{}) {
+	if variablesInterface == nil {
+		return
+	}
+	variables, ok := variablesInterface.(map[string]interface{})
+	if !ok {
+		v.add(IssueInvalidVariable, "", "", "'variables' field must be an object")
+		return
+	}
+	for name := range variables {
+		if !workflows.ValidVariableName(name) {
+			v.add(IssueInvalidVariable, "", "", "variable '%s' must start with a letter or underscore and contain only letters, digits and underscores", name)
+		}
+	}
+}
+
+// checkCycles reports cycles, except the feedback edges from a loop body back to its loop node
+func (v *workflowValidator) checkCycles() {
+	// An edge into a loop node from a node inside its body closes the loop construct
+	feedback := make(map[*workflows.Edge]bool)
+	for _, node := range v.def.Nodes {
+		if !node.IsLoop() {
+			continue
+		}
+		body := v.def.Reachable(node.ID, "")
+		for _, edge := range node.Incoming {
+			if body[edge.Source] {
+				feedback[edge] = true
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int)
+	reported := make(map[string]bool)
+	var stack []string
+
+	var visit func(node *workflows.Node)
+	visit = func(node *workflows.Node) {
+		state[node.ID] = inProgress
+		stack = append(stack, node.ID)
+
+		for _, edge := range node.Outgoing {
+			if feedback[edge] {
+				continue
+			}
+			next := edge.Target
+			switch state[next] {
+			case unvisited:
+				nextNode, _ := v.def.Node(next)
+				visit(nextNode)
+			case inProgress:
+				if reported[next] {
+					continue
+				}
+				reported[next] = true
+				var cycle []string
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == next {
+						cycle = append(append(cycle, stack[i:]...), next)
+						break
+					}
+				}
+				v.add(IssueCycle, next, "", "cycle outside a loop: %s", strings.Join(cycle, " -> "))
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[node.ID] = done
+	}
+
+	for _, node := range v.def.Nodes {
+		if state[node.ID] == unvisited {
+			visit(node)
+		}
+	}
+}
+
+func (v *workflowValidator) checkReachability() {
+	reachable := v.def.Reachable(v.def.StartNodeID, "")
+	for _, node := range v.def.Nodes {
+		if !reachable[node.ID] {
+			v.add(IssueUnreachableNode, node.ID, "", "node '%s' is not reachable from the start node", node.ID)
+		}
+	}
+}
+
+// checkLoopBodies requires every loop body branch to reach an end node or return to its loop
+func (v *workflowValidator) checkLoopBodies() {
+	for _, loop := range v.def.Nodes {
+		if !loop.IsLoop() {
+			continue
+		}
+
+		for _, root := range v.def.LoopBody(loop.ID) {
+			body := v.def.Reachable(root, loop.ID)
+			terminates := body[loop.ID]
+			for nodeID := range body {
+				if node, ok := v.def.Node(nodeID); ok && node.IsEnd() {
+					terminates = true
+					break
+				}
+			}
+			if !terminates {
+				v.add(IssueLoopBodyNoEnd, loop.ID, "", "loop body of '%s' starting at node '%s' never reaches an end node or returns to the loop", loop.ID, root)
+			}
+		}
+	}
+}
+
+// checkLoopOptions reports loop concurrency, batching and error handling settings the engine can't honour
+func (v *workflowValidator) checkLoopOptions() {
+	for _, loop := range v.def.Nodes {
+		if !loop.IsLoop() {
+			continue
+		}
+
+		options := loop.Loop
+		if options.Concurrency < 1 || options.Concurrency > MaxLoopConcurrency {
+			v.add(IssueInvalidLoopOption, loop.ID, "", "loop '%s': concurrency must be between 1 and %d", loop.ID, MaxLoopConcurrency)
+		}
+		if batchSize, ok := loop.Config["batchSize"].(float64); ok && (batchSize < 1 || batchSize != float64(int(batchSize))) {
+			v.add(IssueInvalidLoopOption, loop.ID, "", "loop '%s': batchSize must be a whole number of at least 1", loop.ID)
+		}
+		switch options.ErrorHandling {
+		case "skip", "fail":
+		case "threshold":
+			if _, ok := loop.Config["maxFailureRatio"].(float64); !ok || options.MaxFailureRatio < 0 || options.MaxFailureRatio > 1 {
+				v.add(IssueInvalidLoopOption, loop.ID, "", "loop '%s': errorHandling 'threshold' needs a maxFailureRatio between 0 and 1", loop.ID)
+			}
+		default:
+			v.add(IssueInvalidLoopOption, loop.ID, "", "loop '%s': errorHandling must be 'skip', 'fail' or 'threshold'", loop.ID)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/patali/yantra/src/dto"
+	"github.com/patali/yantra/src/executors"
+	"github.com/stretchr/testify/assert"
+)
+
+func testNode(id, nodeType string, config map[string]interface{}) map[string]interface{} {
+	n := map[string]interface{}{"id": id, "type": nodeType}
+	if config != nil {
+		n["data"] = map[string]interface{}{"config": config}
+	}
+	return n
+}
+
+func testEdge(id, source, target, sourceHandle string) map[string]interface{} {
+	e := map[string]interface{}{"id": id, "source": source, "target": target}
+	if sourceHandle != "" {
+		e["sourceHandle"] = sourceHandle
+	}
+	return e
+}
+
+func testDefinition(nodes []map[string]interface{}, edges []map[string]interface{}) map[string]interface{} {
+	n := make([]interface{}, len(nodes))
+	for i := range nodes {
+		n[i] = nodes[i]
+	}
+	e := make([]interface{}, len(edges))
+	for i := range edges {
+		e[i] = edges[i]
+	}
+	return map[string]interface{}{"nodes": n, "edges": e}
+}
+
+func issueCodes(issues []dto.WorkflowValidationIssue) map[string][]string {
+	codes := make(map[string][]string)
+	for _, issue := range issues {
+		codes[issue.Code] = append(codes[issue.Code], issue.NodeID)
+	}
+	return codes
+}
+
+func TestValidateWorkflowDefinition_Valid(t *testing.T) {
+	def := testDefinition(
+		[]map[string]interface{}{
+			testNode("start", "start", nil),
+			testNode("fetch", "http", map[string]interface{}{"url": "https://api.example.com"}),
+			testNode("loop", "loop-accumulator", map[string]interface{}{"arrayPath": "data"}),
+			testNode("check", "conditional", map[string]interface{}{"condition": "item.id > 2"}),
+			testNode("shape", "transform", nil),
+			testNode("end", "end", nil),
+		},
+		[]map[string]interface{}{
+			testEdge("e1", "start", "fetch", ""),
+			testEdge("e2", "fetch", "loop", ""),
+			testEdge("e3", "loop", "check", "loop-output"),
+			testEdge("e4", "check", "shape", "true"),
+			testEdge("e5", "shape", "loop", ""),
+			testEdge("e6", "check", "loop", "false"),
+			testEdge("e7", "loop", "end", "output"),
+		},
+	)
+
+	schedule := "0 9 * * *"
+	assert.Empty(t, ValidateWorkflowDefinition(def, &schedule))
+}
+
+func TestValidateWorkflowDefinition_ReportsAllProblems(t *testing.T) {
+	def := testDefinition(
+		[]map[string]interface{}{
+			testNode("start", "start", nil),
+			testNode("fetch", "http", nil),
+			testNode("fetch", "transform", nil),
+			testNode("a", "transform", nil),
+			testNode("b", "conditional", map[string]interface{}{"condition": "count >"}),
+			testNode("orphan", "delay", nil),
+			testNode("loop", "loop", map[string]interface{}{"concurrency": float64(500), "batchSize": float64(0.5), "errorHandling": "retry"}),
+			testNode("body", "transform", nil),
+			testNode("notify", "slack", map[string]interface{}{"webhookUrl": "https://hooks.slack.com/x", "message": "hi", "retry": map[string]interface{}{"backoff": "random"}}),
+			testNode("post", "http", map[string]interface{}{"url": "https://api.example.com", "async": true, "retry": map[string]interface{}{"maxAttempts": float64(-1)}}),
+			testNode("end", "end", nil),
+		},
+		[]map[string]interface{}{
+			testEdge("e1", "start", "fetch", ""),
+			testEdge("e2", "fetch", "a", ""),
+			testEdge("e3", "a", "b", ""),
+			testEdge("e4", "b", "a", ""),
+			testEdge("e5", "a", "ghost", ""),
+			testEdge("e6", "fetch", "loop", ""),
+			testEdge("e7", "loop", "body", ""),
+			testEdge("e8", "fetch", "end", ""),
+			testEdge("e10", "fetch", "notify", ""),
+			testEdge("e11", "notify", "end", ""),
+			testEdge("e12", "fetch", "post", ""),
+			testEdge("e13", "post", "end", ""),
+		},
+	)
+	def["edges"] = append(def["edges"].([]interface{}), map[string]interface{}{
+		"id": "e9", "source": "fetch", "target": "end", "condition": "data ==",
+	})
+
+	schedule := "not a cron"
+	codes := issueCodes(ValidateWorkflowDefinition(def, &schedule))
+
+	assert.Equal(t, []string{"fetch"}, codes[IssueDuplicateNodeID])
+	assert.Equal(t, []string{"fetch"}, codes[IssueMissingConfig])
+	assert.Equal(t, []string{"b", "fetch"}, codes[IssueInvalidCondition])
+	assert.Len(t, codes[IssueInvalidEdge], 1)
+	assert.Equal(t, []string{"a"}, codes[IssueCycle])
+	assert.Equal(t, []string{"orphan"}, codes[IssueUnreachableNode])
+	assert.Equal(t, []string{"loop"}, codes[IssueLoopBodyNoEnd])
+	assert.Equal(t, []string{"loop", "loop", "loop"}, codes[IssueInvalidLoopOption])
+	assert.Equal(t, []string{"notify", "post"}, codes[IssueInvalidRetryPolicy])
+	assert.Len(t, codes[IssueInvalidSchedule], 1)
+}
+
+func TestValidateWorkflowDefinition_StructuralErrors(t *testing.T) {
+	issues := ValidateWorkflowDefinition(map[string]interface{}{}, nil)
+	assert.Equal(t, IssueInvalidDefinition, issues[0].Code)
+
+	issues = ValidateWorkflowDefinition(testDefinition(
+		[]map[string]interface{}{testNode("x", "bogus", nil)}, nil,
+	), nil)
+	codes := issueCodes(issues)
+	assert.Equal(t, []string{"x"}, codes[IssueUnsupportedNodeType])
+	assert.Len(t, codes[IssueStartNodeCount], 1)
+	assert.Len(t, codes[IssueMissingEndNode], 1)
+
+	def := testDefinition([]map[string]interface{}{testNode("start", "start", nil), testNode("end", "end", nil)}, []map[string]interface{}{testEdge("e1", "start", "end", "")})
+	def["variables"] = map[string]interface{}{"API_URL": "https://api.example.com", "bad-name": 1}
+	codes = issueCodes(ValidateWorkflowDefinition(def, nil))
+	assert.Len(t, codes[IssueInvalidVariable], 1)
+
+	err := validateWorkflowDefinition(testDefinition(nil, nil), nil)
+	validationErr, ok := AsWorkflowValidationError(err)
+	assert.True(t, ok)
+	assert.Equal(t, "workflow must contain at least one node", validationErr.Error())
+}
+
+func TestValidateWorkflowDefinition_Examples(t *testing.T) {
+	for _, filename := range []string{
+		"cron_scheduled.json",
+		"http_transform_email.json",
+		"nested_loops_email.json",
+		"sleep_until_example.json",
+		"webhook_example.json",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			data, err := executors.ReadExampleWorkflow(filename)
+			assert.NoError(t, err)
+
+			var def map[string]interface{}
+			assert.NoError(t, json.Unmarshal(data, &def))
+
+			schedule, _ := def["schedule"].(string)
+			assert.Empty(t, ValidateWorkflowDefinition(def, &schedule))
+		})
+	}
+}
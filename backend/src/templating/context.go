@@ -0,0 +1,157 @@
+// Package templating is the single expression and template language used by every node.
+//
+// Templates embed expressions in {{ }} placeholders. An expression is either a path
+// ({{input.user.name}}, {{nodeOutputs.fetch.data}}, {{vars.API_URL}}) or a gval
+// expression with the shared function library ({{upper(input.name)}}, {{input.total * 1.2}}).
+// A trailing pipe applies a function to the value: {{input.name | upper}}.
+// Conditions and edge conditions are evaluated with the same language via Evaluate.
+package templating
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Root names every template and expression can use
+const (
+	RootInput       = "input"
+	RootNodeOutputs = "nodeOutputs"
+	RootVars        = "vars"
+	RootExecution   = "execution"
+)
+
+// Execution holds execution metadata exposed as {{execution.*}}
+type Execution struct {
+	ID          string
+	WorkflowID  string
+	AccountID   string
+	NodeID      string
+	TriggerType string
+}
+
+// Context is the data a template or expression is evaluated against
+type Context struct {
+	Input       interface{}
+	NodeOutputs map[string]interface{}
+	Vars        map[string]interface{}
+	Execution   Execution
+
+	// Locals are extra root-level names a caller keeps for backward compatibility
+	// (e.g. edge conditions expose the source node output as "data")
+	Locals map[string]interface{}
+}
+
+// Scope builds the root object. Later entries win: node outputs by node ID,
+// input fields, the reserved roots (input, nodeOutputs, vars, execution), then Locals
+func (c Context) Scope() map[string]interface{} {
+	scope := make(map[string]interface{})
+
+	for nodeID, output := range c.NodeOutputs {
+		scope[nodeID] = output
+	}
+
+	// Input fields are reachable without the "input." prefix
+	if inputMap, ok := c.Input.(map[string]interface{}); ok {
+		for k, v := range inputMap {
+			scope[k] = v
+		}
+	}
+
+	scope[RootInput] = c.Input
+	scope[RootNodeOutputs] = orEmpty(c.NodeOutputs)
+	scope[RootVars] = orEmpty(c.Vars)
+	scope[RootExecution] = map[string]interface{}{
+		"id":          c.Execution.ID,
+		"workflowId":  c.Execution.WorkflowID,
+		"accountId":   c.Execution.AccountID,
+		"nodeId":      c.Execution.NodeID,
+		"triggerType": c.Execution.TriggerType,
+	}
+
+	for k, v := range c.Locals {
+		scope[k] = v
+	}
+
+	return scope
+}
+
+// Lookup resolves a dotted path such as "input.user.name" or "nodeOutputs.http-1.data.0.id"
+// Input paths keep the legacy resolution: the path is tried against the raw input first,
+// and "input.x" falls back to "x" when the input has no "input" field; other paths
+// fall back to node outputs by node ID
+func (c Context) Lookup(path string) (interface{}, bool) {
+	parts := splitPath(path)
+	if len(parts) == 0 {
+		return nil, false
+	}
+
+	switch parts[0] {
+	case RootNodeOutputs, RootVars, RootExecution:
+		return walk(c.Scope()[parts[0]], parts[1:])
+	}
+
+	if value, ok := c.Locals[parts[0]]; ok {
+		return walk(value, parts[1:])
+	}
+
+	if value, ok := walk(c.Input, parts); ok {
+		return value, true
+	}
+	if parts[0] == RootInput && len(parts) > 1 {
+		return walk(c.Input, parts[1:])
+	}
+	if parts[0] == RootInput {
+		return c.Input, c.Input != nil
+	}
+
+	// {{nodeId.data}} is shorthand for {{nodeOutputs.nodeId.data}}
+	return walk(c.NodeOutputs, parts)
+}
+
+func splitPath(path string) []string {
+	raw := strings.Split(strings.TrimSpace(path), ".")
+	parts := make([]string, 0, len(raw))
+	for _, part := range raw {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// walk navigates maps by key and arrays by numeric index
+func walk(current interface{}, parts []string) (interface{}, bool) {
+	for _, part := range parts {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case map[interface{}]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+func orEmpty(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
@@ -0,0 +1,94 @@
+package templating
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Position is a 1-based line and column in template or expression source
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// ParseError is a syntax error at a position in the source
+type ParseError struct {
+	Message  string
+	Position Position
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at %d:%d: %s", e.Position.Line, e.Position.Column, e.Message)
+}
+
+// AsParseError unwraps a ParseError
+func AsParseError(err error) (*ParseError, bool) {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr, true
+	}
+	return nil, false
+}
+
+var (
+	// gval: "parsing error: <expr>\t:1:6 - 1:10 <message>" (the start position is sometimes missing)
+	gvalRangeError = regexp.MustCompile(`(?s)\t:(\d+):(\d+) - \d+:\d+ (.*)$`)
+	gvalEndError   = regexp.MustCompile(`(?s)\t - (\d+):(\d+) (.*)$`)
+	// text/template: "template: <name>:<line>: <message>" or "template: <name>:<line>:<col>: <message>"
+	goTemplateError = regexp.MustCompile(`^template: [^:]*:(\d+):(?:(\d+):)? (.*)$`)
+)
+
+// gvalParseError converts a gval parse error into a ParseError
+func gvalParseError(err error) error {
+	msg := err.Error()
+	for _, pattern := range []*regexp.Regexp{gvalRangeError, gvalEndError} {
+		if m := pattern.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			col, _ := strconv.Atoi(m[2])
+			return &ParseError{Message: m[3], Position: Position{Line: line, Column: col}}
+		}
+	}
+	return &ParseError{Message: strings.TrimPrefix(msg, "parsing error: "), Position: Position{Line: 1, Column: 1}}
+}
+
+// goTemplateParseError converts a text/template parse error into a ParseError
+func goTemplateParseError(err error) error {
+	if m := goTemplateError.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		col := 1
+		if m[2] != "" {
+			col, _ = strconv.Atoi(m[2])
+		}
+		return &ParseError{Message: m[3], Position: Position{Line: line, Column: col}}
+	}
+	return &ParseError{Message: err.Error(), Position: Position{Line: 1, Column: 1}}
+}
+
+// positionAt returns the position of a byte offset in text
+func positionAt(text string, offset int) Position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	col := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+	return Position{Line: line, Column: col}
+}
+
+// offsetOf returns the byte offset of a position in text
+func offsetOf(text string, pos Position) int {
+	offset := 0
+	for line := 1; line < pos.Line; line++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		offset += next + 1
+	}
+	runes := []rune(text[offset:])
+	col := clamp(pos.Column-1, 0, len(runes))
+	return offset + len(string(runes[:col]))
+}
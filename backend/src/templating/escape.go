@@ -0,0 +1,82 @@
+package templating
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Escape selects how a rendered value is encoded for the place it is inserted into
+type Escape int
+
+const (
+	// EscapeNone inserts values as text; objects and arrays become indented JSON
+	EscapeNone Escape = iota
+	// EscapeURL query-escapes values (spaces become "+") for URLs; a placeholder at the
+	// very start of a URL is the base URL ({{vars.API_URL}}/users) and is inserted as-is
+	EscapeURL
+	// EscapeHeader strips CR and LF so a value cannot inject extra headers
+	EscapeHeader
+	// EscapeHTML HTML-escapes values (&, <, >, ", ') for HTML bodies
+	EscapeHTML
+	// EscapeJSON encodes values for a JSON document: strings are escaped for use
+	// inside quotes, objects and arrays become compact JSON
+	EscapeJSON
+)
+
+// String returns the value as text; numbers avoid exponent notation
+func String(value interface{}) string {
+	return stringify(value, true)
+}
+
+func stringify(value interface{}, indent bool) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case int, int32, int64, uint, uint32, uint64, bool:
+		return fmt.Sprintf("%v", v)
+	case []interface{}, map[string]interface{}:
+		var b []byte
+		var err error
+		if indent {
+			b, err = json.MarshalIndent(v, "", "  ")
+		} else {
+			b, err = json.Marshal(v)
+		}
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// Apply encodes a value for the given context
+func (e Escape) Apply(value interface{}) string {
+	switch e {
+	case EscapeURL:
+		return url.QueryEscape(stringify(value, false))
+	case EscapeHeader:
+		return strings.NewReplacer("\r", "", "\n", "").Replace(stringify(value, false))
+	case EscapeHTML:
+		return html.EscapeString(stringify(value, true))
+	case EscapeJSON:
+		if s, ok := value.(string); ok {
+			b, _ := json.Marshal(s)
+			return string(b[1 : len(b)-1])
+		}
+		return stringify(value, false)
+	default:
+		return stringify(value, true)
+	}
+}
@@ -0,0 +1,75 @@
+package templating
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PaesslerAG/gval"
+)
+
+// language is gval's full language plus the shared function library
+var language = newLanguage()
+
+func newLanguage() gval.Language {
+	extensions := make([]gval.Language, 0, len(Functions()))
+	for name, fn := range Functions() {
+		extensions = append(extensions, gval.Function(name, (func(...interface{}) (interface{}, error))(fn)))
+	}
+	return gval.Full(extensions...)
+}
+
+// Expression is a parsed expression that can be evaluated many times
+type Expression struct {
+	source    string
+	evaluable gval.Evaluable
+}
+
+// Compile parses an expression; parse errors include the line:column of the problem
+func Compile(expression string) (*Expression, error) {
+	evaluable, err := language.NewEvaluable(expression)
+	if err != nil {
+		return nil, gvalParseError(err)
+	}
+	return &Expression{source: expression, evaluable: evaluable}, nil
+}
+
+// String returns the expression source
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against the context scope
+func (e *Expression) Eval(ctx Context) (interface{}, error) {
+	return e.evaluable(context.Background(), ctx.Scope())
+}
+
+// EvalBool evaluates the expression and requires a boolean result
+func (e *Expression) EvalBool(ctx Context) (bool, error) {
+	result, err := e.Eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	b, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition must evaluate to boolean, got %T", result)
+	}
+	return b, nil
+}
+
+// Evaluate parses and evaluates an expression in one step
+func Evaluate(expression string, ctx Context) (interface{}, error) {
+	compiled, err := Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.Eval(ctx)
+}
+
+// EvaluateBool parses and evaluates a condition
+func EvaluateBool(expression string, ctx Context) (bool, error) {
+	compiled, err := Compile(expression)
+	if err != nil {
+		return false, err
+	}
+	return compiled.EvalBool(ctx)
+}
@@ -0,0 +1,598 @@
+package templating
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Function is the shape of every library function; it works in gval and Go templates alike
+type Function func(args ...interface{}) (interface{}, error)
+
+// dateLayouts are the named layouts accepted by formatDate and parseDate
+var dateLayouts = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"iso":      time.RFC3339,
+	"date":     "2006-01-02",
+	"time":     "15:04:05",
+	"datetime": "2006-01-02 15:04:05",
+	"rfc1123":  time.RFC1123,
+}
+
+// parseLayouts are tried in order when a date string has no explicit layout
+var parseLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123,
+}
+
+// nowFunc is replaced in tests
+var nowFunc = time.Now
+
+// Functions returns the shared function library
+func Functions() map[string]Function {
+	return map[string]Function{
+		// Strings
+		"upper":      stringFn(strings.ToUpper),
+		"lower":      stringFn(strings.ToLower),
+		"title":      stringFn(titleCase),
+		"trim":       stringFn(strings.TrimSpace),
+		"replace":    fnReplace,
+		"split":      fnSplit,
+		"join":       fnJoin,
+		"contains":   fnContains,
+		"startsWith": fnStartsWith,
+		"endsWith":   fnEndsWith,
+		"substr":     fnSubstr,
+		"length":     fnLength,
+		"concat":     fnConcat,
+		"default":    fnDefault,
+		"toString":   fnToString,
+
+		// Math
+		"add":      arithmetic(func(a, b float64) float64 { return a + b }),
+		"sub":      arithmetic(func(a, b float64) float64 { return a - b }),
+		"mul":      arithmetic(func(a, b float64) float64 { return a * b }),
+		"div":      fnDiv,
+		"mod":      fnMod,
+		"round":    fnRound,
+		"floor":    numberFn(math.Floor),
+		"ceil":     numberFn(math.Ceil),
+		"abs":      numberFn(math.Abs),
+		"min":      fnMin,
+		"max":      fnMax,
+		"toNumber": fnToNumber,
+
+		// Dates (values are RFC3339 strings in UTC)
+		"now":         fnNow,
+		"formatDate":  fnFormatDate,
+		"parseDate":   fnParseDate,
+		"addDuration": fnAddDuration,
+		"unix":        fnUnix,
+
+		// JSON
+		"json":        fnJSON,
+		"jsonCompact": fnJSONCompact,
+		"toJson":      fnJSONCompact,
+		"parseJson":   fnParseJSON,
+	}
+}
+
+func argCount(name string, args []interface{}, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		if min == max {
+			return fmt.Errorf("%s expects %d argument(s), got %d", name, min, len(args))
+		}
+		return fmt.Errorf("%s expects %d to %d argument(s), got %d", name, min, max, len(args))
+	}
+	return nil
+}
+
+func stringFn(fn func(string) string) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expects 1 argument, got %d", len(args))
+		}
+		return fn(String(args[0])), nil
+	}
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r := []rune(w)
+		words[i] = string(unicode.ToUpper(r[0])) + string(r[1:])
+	}
+	return strings.Join(words, " ")
+}
+
+func fnReplace(args ...interface{}) (interface{}, error) {
+	if err := argCount("replace", args, 3, 3); err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(String(args[0]), String(args[1]), String(args[2])), nil
+}
+
+func fnSplit(args ...interface{}) (interface{}, error) {
+	if err := argCount("split", args, 2, 2); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(String(args[0]), String(args[1]))
+	result := make([]interface{}, len(parts))
+	for i, p := range parts {
+		result[i] = p
+	}
+	return result, nil
+}
+
+func fnJoin(args ...interface{}) (interface{}, error) {
+	if err := argCount("join", args, 2, 2); err != nil {
+		return nil, err
+	}
+	list, ok := toList(args[0])
+	if !ok {
+		return nil, fmt.Errorf("join expects an array, got %T", args[0])
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		parts[i] = stringify(item, false)
+	}
+	return strings.Join(parts, String(args[1])), nil
+}
+
+// fnContains checks substrings, array membership and map keys
+func fnContains(args ...interface{}) (interface{}, error) {
+	if err := argCount("contains", args, 2, 2); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return false, nil
+	case map[string]interface{}:
+		_, ok := v[String(args[1])]
+		return ok, nil
+	}
+	if list, ok := toList(args[0]); ok {
+		for _, item := range list {
+			if reflect.DeepEqual(item, args[1]) || (isNumber(item) && isNumber(args[1]) && toFloatOrZero(item) == toFloatOrZero(args[1])) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return strings.Contains(String(args[0]), String(args[1])), nil
+}
+
+func fnStartsWith(args ...interface{}) (interface{}, error) {
+	if err := argCount("startsWith", args, 2, 2); err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(String(args[0]), String(args[1])), nil
+}
+
+func fnEndsWith(args ...interface{}) (interface{}, error) {
+	if err := argCount("endsWith", args, 2, 2); err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(String(args[0]), String(args[1])), nil
+}
+
+// fnSubstr slices by rune: substr(s, start) or substr(s, start, end)
+func fnSubstr(args ...interface{}) (interface{}, error) {
+	if err := argCount("substr", args, 2, 3); err != nil {
+		return nil, err
+	}
+	runes := []rune(String(args[0]))
+	start, err := toInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	end := len(runes)
+	if len(args) == 3 {
+		if end, err = toInt(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	start = clamp(start, 0, len(runes))
+	end = clamp(end, start, len(runes))
+	return string(runes[start:end]), nil
+}
+
+func fnLength(args ...interface{}) (interface{}, error) {
+	if err := argCount("length", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return 0, nil
+	case string:
+		return len([]rune(v)), nil
+	case map[string]interface{}:
+		return len(v), nil
+	}
+	if list, ok := toList(args[0]); ok {
+		return len(list), nil
+	}
+	return nil, fmt.Errorf("length expects a string, array or object, got %T", args[0])
+}
+
+func fnConcat(args ...interface{}) (interface{}, error) {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(String(arg))
+	}
+	return b.String(), nil
+}
+
+// fnDefault returns the fallback when the value is nil or an empty string
+func fnDefault(args ...interface{}) (interface{}, error) {
+	if err := argCount("default", args, 2, 2); err != nil {
+		return nil, err
+	}
+	if args[0] == nil || args[0] == "" {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+func fnToString(args ...interface{}) (interface{}, error) {
+	if err := argCount("toString", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return stringify(args[0], false), nil
+}
+
+func arithmetic(op func(a, b float64) float64) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("expects at least 2 arguments, got %d", len(args))
+		}
+		result, err := toFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, arg := range args[1:] {
+			n, err := toFloat(arg)
+			if err != nil {
+				return nil, err
+			}
+			result = op(result, n)
+		}
+		return result, nil
+	}
+}
+
+func fnDiv(args ...interface{}) (interface{}, error) {
+	if err := argCount("div", args, 2, 2); err != nil {
+		return nil, err
+	}
+	a, b, err := twoFloats(args)
+	if err != nil {
+		return nil, err
+	}
+	if b == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	return a / b, nil
+}
+
+func fnMod(args ...interface{}) (interface{}, error) {
+	if err := argCount("mod", args, 2, 2); err != nil {
+		return nil, err
+	}
+	a, b, err := twoFloats(args)
+	if err != nil {
+		return nil, err
+	}
+	if b == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	return math.Mod(a, b), nil
+}
+
+// fnRound rounds to the given number of decimal places (default 0)
+func fnRound(args ...interface{}) (interface{}, error) {
+	if err := argCount("round", args, 1, 2); err != nil {
+		return nil, err
+	}
+	n, err := toFloat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	places := 0
+	if len(args) == 2 {
+		if places, err = toInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	factor := math.Pow(10, float64(places))
+	return math.Round(n*factor) / factor, nil
+}
+
+func numberFn(fn func(float64) float64) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expects 1 argument, got %d", len(args))
+		}
+		n, err := toFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return fn(n), nil
+	}
+}
+
+func fnMin(args ...interface{}) (interface{}, error) {
+	return extreme("min", args, func(a, b float64) bool { return a < b })
+}
+
+func fnMax(args ...interface{}) (interface{}, error) {
+	return extreme("max", args, func(a, b float64) bool { return a > b })
+}
+
+// extreme accepts either several numbers or a single array of numbers
+func extreme(name string, args []interface{}, better func(a, b float64) bool) (interface{}, error) {
+	if len(args) == 1 {
+		if list, ok := toList(args[0]); ok {
+			args = list
+		}
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s expects at least 1 number", name)
+	}
+	result, err := toFloat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args[1:] {
+		n, err := toFloat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if better(n, result) {
+			result = n
+		}
+	}
+	return result, nil
+}
+
+func fnToNumber(args ...interface{}) (interface{}, error) {
+	if err := argCount("toNumber", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return toFloat(args[0])
+}
+
+func fnNow(args ...interface{}) (interface{}, error) {
+	if err := argCount("now", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return nowFunc().UTC().Format(time.RFC3339), nil
+}
+
+// fnFormatDate formats a date with a named layout (date, time, datetime, rfc3339, rfc1123) or a Go layout
+func fnFormatDate(args ...interface{}) (interface{}, error) {
+	if err := argCount("formatDate", args, 2, 3); err != nil {
+		return nil, err
+	}
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 3 {
+		loc, err := time.LoadLocation(String(args[2]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %s", String(args[2]))
+		}
+		t = t.In(loc)
+	}
+	return t.Format(resolveLayout(String(args[1]))), nil
+}
+
+func fnParseDate(args ...interface{}) (interface{}, error) {
+	if err := argCount("parseDate", args, 1, 2); err != nil {
+		return nil, err
+	}
+	if len(args) == 2 {
+		t, err := time.Parse(resolveLayout(String(args[1])), String(args[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %w", err)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// fnAddDuration adds a Go duration ("90m", "-1h") or a day count ("7d") to a date
+func fnAddDuration(args ...interface{}) (interface{}, error) {
+	if err := argCount("addDuration", args, 2, 2); err != nil {
+		return nil, err
+	}
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	spec := strings.TrimSpace(String(args[1]))
+	if days, ok := strings.CutSuffix(spec, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %s", spec)
+		}
+		return t.AddDate(0, 0, n).UTC().Format(time.RFC3339), nil
+	}
+	d, err := time.ParseDuration(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration: %s", spec)
+	}
+	return t.Add(d).UTC().Format(time.RFC3339), nil
+}
+
+func fnUnix(args ...interface{}) (interface{}, error) {
+	if err := argCount("unix", args, 1, 1); err != nil {
+		return nil, err
+	}
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return float64(t.Unix()), nil
+}
+
+func fnJSON(args ...interface{}) (interface{}, error) {
+	if err := argCount("json", args, 1, 1); err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(args[0], "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func fnJSONCompact(args ...interface{}) (interface{}, error) {
+	if err := argCount("jsonCompact", args, 1, 1); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func fnParseJSON(args ...interface{}) (interface{}, error) {
+	if err := argCount("parseJson", args, 1, 1); err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(String(args[0])), &v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return v, nil
+}
+
+func resolveLayout(layout string) string {
+	if named, ok := dateLayouts[strings.ToLower(layout)]; ok {
+		return named
+	}
+	return layout
+}
+
+// toTime accepts date strings, unix seconds and time.Time
+func toTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		for _, layout := range parseLayouts {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid date: %s", t)
+	}
+	if isNumber(v) {
+		return time.Unix(int64(toFloatOrZero(v)), 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %v", v)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", n)
+		}
+		return f, nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("not a number: %v", v)
+	}
+}
+
+func toFloatOrZero(v interface{}) float64 {
+	f, _ := toFloat(v)
+	return f
+}
+
+func toInt(v interface{}) (int, error) {
+	f, err := toFloat(v)
+	return int(f), err
+}
+
+func twoFloats(args []interface{}) (float64, float64, error) {
+	a, err := toFloat(args[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := toFloat(args[1])
+	return a, b, err
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case float64, float32, int, int32, int64, uint, uint32, uint64, json.Number:
+		return true
+	}
+	return false
+}
+
+func toList(v interface{}) ([]interface{}, bool) {
+	if list, ok := v.([]interface{}); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(v)
+	if v == nil || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
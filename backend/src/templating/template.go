@@ -0,0 +1,307 @@
+package templating
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template dialects
+const (
+	// DialectSimple is {{path}} / {{expression}} / {{value | fn}} placeholders
+	DialectSimple = "simple"
+	// DialectGo is Go's text/template ({{range}}, {{if}}, {{.field}}) with the shared function library
+	DialectGo = "go"
+	// DialectAuto picks go when the template uses Go template syntax, simple otherwise
+	DialectAuto = "auto"
+)
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{\{([^}]+)\}\}`)
+	pathPattern        = regexp.MustCompile(`^[A-Za-z_$][\w$-]*(\.[\w$-]+)*$`)
+	goActionPattern    = regexp.MustCompile(`\{\{-?\s*(range|if|with|end|else|define|template|block|\$)\s`)
+	goDotPattern       = regexp.MustCompile(`\{\{-?\s*\.[A-Za-z_]`)
+)
+
+// pipeValue is the scope name a piped value is bound to while its filter runs
+const pipeValue = "__pipe"
+
+// DetectDialect reports whether a template uses Go template syntax
+func DetectDialect(text string) string {
+	if goActionPattern.MatchString(text) || goDotPattern.MatchString(text) {
+		return DialectGo
+	}
+	return DialectSimple
+}
+
+// Render renders a template in the given dialect (empty means auto)
+func Render(text, dialect string, ctx Context, escape Escape) (string, error) {
+	if dialect == "" || dialect == DialectAuto {
+		dialect = DetectDialect(text)
+	}
+	switch dialect {
+	case DialectSimple:
+		return RenderSimple(text, ctx, escape)
+	case DialectGo:
+		return RenderGo(text, ctx, escape)
+	default:
+		return text, fmt.Errorf("unsupported template dialect: %s", dialect)
+	}
+}
+
+// RenderSimple replaces each {{...}} placeholder with its escaped value
+// Placeholders whose value is missing or fails to evaluate are kept as-is;
+// the first error is returned along with the rendered text
+func RenderSimple(text string, ctx Context, escape Escape) (string, error) {
+	var firstErr error
+	var scope map[string]interface{}
+	var b strings.Builder
+	last := 0
+
+	for _, loc := range placeholderPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(text[last:loc[0]])
+		last = loc[1]
+		match := text[loc[0]:loc[1]]
+
+		if scope == nil {
+			scope = ctx.Scope()
+		}
+		value, err := evaluatePlaceholder(text, loc[0], ctx, scope)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			b.WriteString(match)
+			continue
+		}
+		if value == nil {
+			b.WriteString(match)
+			continue
+		}
+
+		// A URL that starts with a placeholder takes it as the base URL, unescaped
+		if escape == EscapeURL && loc[0] == 0 {
+			b.WriteString(EscapeNone.Apply(value))
+			continue
+		}
+		b.WriteString(escape.Apply(value))
+	}
+	b.WriteString(text[last:])
+
+	return b.String(), firstErr
+}
+
+// EvaluatePlaceholder evaluates the inside of one {{...}} placeholder; nil means missing
+func EvaluatePlaceholder(source string, ctx Context) (interface{}, error) {
+	text := "{{" + source + "}}"
+	return evaluatePlaceholder(text, 0, ctx, ctx.Scope())
+}
+
+// compiledPlaceholder is a parsed {{...}} placeholder: a path or expression plus filters
+type compiledPlaceholder struct {
+	path    string
+	head    *Expression
+	filters []*Expression
+}
+
+// compilePlaceholder parses the placeholder starting at offset in text
+// Parse errors carry their position in text
+func compilePlaceholder(text string, offset int) (*compiledPlaceholder, error) {
+	end := offset + strings.Index(text[offset:], "}}")
+	source := text[offset+2 : end]
+	segments := splitPipes(source)
+	compiled := &compiledPlaceholder{}
+
+	for i, segment := range segments {
+		trimmed := strings.TrimSpace(segment.text)
+		start := offset + 2 + segment.offset + (len(segment.text) - len(strings.TrimLeft(segment.text, " \t\r\n")))
+
+		if i == 0 && pathPattern.MatchString(trimmed) {
+			compiled.path = trimmed
+			continue
+		}
+
+		expr := trimmed
+		if i > 0 {
+			expr = filterExpression(trimmed)
+		}
+		if trimmed == "" {
+			return nil, &ParseError{Message: "empty expression", Position: positionAt(text, start)}
+		}
+		exp, err := Compile(expr)
+		if err != nil {
+			if parseErr, ok := AsParseError(err); ok {
+				pos := positionAt(text, start)
+				if i == 0 {
+					pos = positionAt(text, start+offsetOf(trimmed, parseErr.Position))
+				}
+				return nil, &ParseError{Message: parseErr.Message, Position: pos}
+			}
+			return nil, err
+		}
+		if i == 0 {
+			compiled.head = exp
+		} else {
+			compiled.filters = append(compiled.filters, exp)
+		}
+	}
+
+	return compiled, nil
+}
+
+func evaluatePlaceholder(text string, offset int, ctx Context, scope map[string]interface{}) (interface{}, error) {
+	compiled, err := compilePlaceholder(text, offset)
+	if err != nil {
+		return nil, err
+	}
+	match := text[offset : offset+strings.Index(text[offset:], "}}")+2]
+
+	var value interface{}
+	if compiled.head == nil {
+		value, _ = ctx.Lookup(compiled.path)
+		if value == nil && len(compiled.filters) == 0 {
+			return nil, nil
+		}
+	} else {
+		value, err = compiled.head.evaluable(context.Background(), scope)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", match, err)
+		}
+	}
+
+	// Each filter receives the current value as its first argument
+	for _, filter := range compiled.filters {
+		scope[pipeValue] = value
+		value, err = filter.evaluable(context.Background(), scope)
+		delete(scope, pipeValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", match, err)
+		}
+	}
+
+	return value, nil
+}
+
+// CheckTemplate parses a template without evaluating it and returns the first ParseError
+func CheckTemplate(text, dialect string) error {
+	if dialect == "" || dialect == DialectAuto {
+		dialect = DetectDialect(text)
+	}
+	switch dialect {
+	case DialectSimple:
+		for _, loc := range placeholderPattern.FindAllStringIndex(text, -1) {
+			if _, err := compilePlaceholder(text, loc[0]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case DialectGo:
+		if _, err := texttemplate.New("template").Funcs(goFuncs()).Parse(text); err != nil {
+			return goTemplateParseError(err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported template dialect: %s", dialect)
+	}
+}
+
+// filterExpression turns "upper" into upper(__pipe) and "default(\"x\")" into default(__pipe, "x")
+func filterExpression(filter string) string {
+	open := strings.Index(filter, "(")
+	if open < 0 {
+		return filter + "(" + pipeValue + ")"
+	}
+	args := strings.TrimSpace(filter[open+1:])
+	if strings.HasPrefix(args, ")") {
+		return filter[:open+1] + pipeValue + args
+	}
+	return filter[:open+1] + pipeValue + ", " + args
+}
+
+// pipeSegment is one "|"-separated part of a placeholder and its byte offset
+type pipeSegment struct {
+	text   string
+	offset int
+}
+
+// splitPipes splits on top-level "|" (not "||", quotes or parentheses)
+func splitPipes(source string) []pipeSegment {
+	var segments []pipeSegment
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == '|' && depth == 0:
+			if i+1 < len(source) && source[i+1] == '|' {
+				i++
+				continue
+			}
+			segments = append(segments, pipeSegment{text: source[start:i], offset: start})
+			start = i + 1
+		}
+	}
+	return append(segments, pipeSegment{text: source[start:], offset: start})
+}
+
+func goFuncs() map[string]interface{} {
+	funcs := make(map[string]interface{})
+	for name, fn := range Functions() {
+		funcs[name] = (func(...interface{}) (interface{}, error))(fn)
+	}
+	return funcs
+}
+
+// RenderGo executes a Go template against the context scope with the shared function library
+// HTML escaping uses html/template's contextual escaping and headers drop CR/LF;
+// other contexts use text/template output as-is
+func RenderGo(text string, ctx Context, escape Escape) (string, error) {
+	var data interface{} = ctx.Scope()
+	if ctx.Input != nil {
+		if _, ok := ctx.Input.(map[string]interface{}); !ok {
+			data = ctx.Input // Keep {{range .}} working for array inputs
+		}
+	}
+
+	var buf bytes.Buffer
+	if escape == EscapeHTML {
+		tmpl, err := htmltemplate.New("template").Funcs(goFuncs()).Parse(text)
+		if err != nil {
+			return text, goTemplateParseError(err)
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return text, err
+		}
+		return buf.String(), nil
+	}
+
+	tmpl, err := texttemplate.New("template").Funcs(goFuncs()).Parse(text)
+	if err != nil {
+		return text, goTemplateParseError(err)
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return text, err
+	}
+	if escape == EscapeHeader {
+		return escape.Apply(buf.String()), nil
+	}
+	// Go templates write raw values; use urlquery or toJson explicitly in URL and JSON contexts
+	return buf.String(), nil
+}
@@ -0,0 +1,166 @@
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testContext() Context {
+	return Context{
+		Input: map[string]interface{}{
+			"name":  "Ada Lovelace",
+			"count": float64(3),
+			"tags":  []interface{}{"a", "b"},
+			"user":  map[string]interface{}{"email": "ada@example.com"},
+		},
+		NodeOutputs: map[string]interface{}{
+			"http-1": map[string]interface{}{"data": map[string]interface{}{"id": float64(42)}},
+		},
+		Vars:      map[string]interface{}{"API_URL": "https://api.example.com"},
+		Execution: Execution{ID: "exec-1", AccountID: "acct-1", NodeID: "node-1"},
+	}
+}
+
+func TestRenderSimple(t *testing.T) {
+	ctx := testContext()
+
+	tests := []struct {
+		name     string
+		template string
+		escape   Escape
+		expected string
+	}{
+		{"Input field without prefix", "Hi {{name}}", EscapeNone, "Hi Ada Lovelace"},
+		{"Input prefix", "Hi {{input.name}}", EscapeNone, "Hi Ada Lovelace"},
+		{"Node output with hyphenated id", "{{nodeOutputs.http-1.data.id}}", EscapeNone, "42"},
+		{"Node output shorthand", "{{http-1.data.id}}", EscapeNone, "42"},
+		{"Vars", "{{vars.API_URL}}/users", EscapeNone, "https://api.example.com/users"},
+		{"Execution metadata", "{{execution.id}}/{{execution.nodeId}}", EscapeNone, "exec-1/node-1"},
+		{"Array index", "{{tags.1}}", EscapeNone, "b"},
+		{"Missing keeps placeholder", "{{missing}}", EscapeNone, "{{missing}}"},
+		{"Function call", "{{upper(name)}}", EscapeNone, "ADA LOVELACE"},
+		{"Arithmetic", "{{count * 2 + 1}}", EscapeNone, "7"},
+		{"Pipe", "{{name | lower}}", EscapeNone, "ada lovelace"},
+		{"Pipe with arguments", "{{missing | default(\"none\")}}", EscapeNone, "none"},
+		{"Chained pipes", "{{user.email | replace(\"@\", \" at \") | upper}}", EscapeNone, "ADA AT EXAMPLE.COM"},
+		{"URL escaping", "?q={{name}}", EscapeURL, "?q=Ada+Lovelace"},
+		{"JSON escaping", `{"tags": {{tags}}, "name": "{{name}}"}`, EscapeJSON, `{"tags": ["a","b"], "name": "Ada Lovelace"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := RenderSimple(tt.template, ctx, tt.escape)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestRenderSimple_Escaping(t *testing.T) {
+	ctx := Context{Input: map[string]interface{}{
+		"quote":  "say \"hi\"\n",
+		"markup": "<b>Tom & Jerry</b>",
+		"header": "value\r\nX-Injected: 1",
+	}}
+
+	result, _ := RenderSimple(`{"q": "{{quote}}"}`, ctx, EscapeJSON)
+	assert.Equal(t, `{"q": "say \"hi\"\n"}`, result)
+
+	result, _ = RenderSimple("<p>{{markup}}</p>", ctx, EscapeHTML)
+	assert.Equal(t, "<p>&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;</p>", result)
+
+	result, _ = RenderSimple("{{header}}", ctx, EscapeHeader)
+	assert.Equal(t, "valueX-Injected: 1", result)
+}
+
+func TestRenderSimple_ErrorKeepsPlaceholder(t *testing.T) {
+	result, err := RenderSimple("a {{div(1, 0)}} b", testContext(), EscapeNone)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "division by zero")
+	assert.Equal(t, "a {{div(1, 0)}} b", result)
+}
+
+func TestRenderGo(t *testing.T) {
+	ctx := testContext()
+
+	result, err := Render("{{range .tags}}[{{. | upper}}]{{end}} {{.vars.API_URL}}", DialectAuto, ctx, EscapeNone)
+	assert.NoError(t, err)
+	assert.Equal(t, "[A][B] https://api.example.com", result)
+
+	result, err = Render("<p>{{.name}}</p>", DialectGo, Context{Input: map[string]interface{}{"name": "<script>"}}, EscapeHTML)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>&lt;script&gt;</p>", result)
+
+	assert.Equal(t, DialectSimple, DetectDialect("Hello {{name}}"))
+	assert.Equal(t, DialectGo, DetectDialect("{{if .ok}}yes{{end}}"))
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := testContext()
+
+	ok, err := EvaluateBool(`count > 2 && contains(tags, "a")`, ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = EvaluateBool(`nodeOutputs["http-1"].data.id == 42`, ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = EvaluateBool(`count + 1`, ctx)
+	assert.Error(t, err)
+
+	_, err = Compile(`count >`)
+	assert.Error(t, err)
+
+	ctx.Locals = map[string]interface{}{"data": true}
+	ok, err = EvaluateBool("data == true", ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestFunctions(t *testing.T) {
+	nowFunc = func() time.Time { return time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = time.Now }()
+
+	ctx := Context{}
+	eval := func(expr string) interface{} {
+		v, err := Evaluate(expr, ctx)
+		assert.NoError(t, err, expr)
+		return v
+	}
+
+	assert.Equal(t, "2025-03-01T12:00:00Z", eval(`now()`))
+	assert.Equal(t, "2025-03-08", eval(`formatDate(addDuration(now(), "7d"), "date")`))
+	assert.Equal(t, "2025-03-01T13:30:00Z", eval(`addDuration("2025-03-01T12:00:00Z", "90m")`))
+	assert.Equal(t, float64(1740830400), eval(`unix(now())`))
+	assert.Equal(t, "a-b", eval(`join(split("a,b", ","), "-")`))
+	assert.Equal(t, "Hello World", eval(`title("hello world")`))
+	assert.Equal(t, "ell", eval(`substr("hello", 1, 4)`))
+	assert.Equal(t, 5, eval(`length("hello")`))
+	assert.Equal(t, 3.14, eval(`round(3.14159, 2)`))
+	assert.Equal(t, float64(7), eval(`max(3, 7, 5)`))
+	assert.Equal(t, float64(2.5), eval(`div(5, 2)`))
+	assert.Equal(t, `{"a":1}`, eval(`jsonCompact(parseJson("{\"a\": 1}"))`))
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	_, err := Compile("count >")
+	parseErr, ok := AsParseError(err)
+	assert.True(t, ok)
+	assert.Equal(t, Position{Line: 1, Column: 8}, parseErr.Position)
+
+	err = CheckTemplate("Hello\n  {{ name }} {{ count >> }}", DialectSimple)
+	parseErr, ok = AsParseError(err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, parseErr.Position.Line)
+	assert.Equal(t, 25, parseErr.Position.Column)
+
+	err = CheckTemplate("line one\n{{if .ok}}missing end", DialectGo)
+	parseErr, ok = AsParseError(err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, parseErr.Position.Line)
+
+	assert.NoError(t, CheckTemplate("{{input.name | upper}} {{vars.X}}", DialectSimple))
+}
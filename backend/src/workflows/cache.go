@@ -0,0 +1,62 @@
+package workflows
+
+import (
+	"sync"
+)
+
+// DefaultCacheSize is the number of compiled definitions kept in memory
+const DefaultCacheSize = 512
+
+// Cache holds compiled definitions keyed by WorkflowVersion ID.
+// Versions are immutable, so an entry never needs invalidating; the oldest entries are evicted past the size limit
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*Definition
+	order   []string // insertion order for eviction
+}
+
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &Cache{
+		size:    size,
+		entries: make(map[string]*Definition),
+	}
+}
+
+// Get returns the compiled definition for a version, compiling and caching it on first use
+func (c *Cache) Get(versionID, definitionJSON string) (*Definition, error) {
+	c.mu.Lock()
+	def, ok := c.entries[versionID]
+	c.mu.Unlock()
+	if ok {
+		return def, nil
+	}
+
+	def, err := Parse([]byte(definitionJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.entries[versionID]; ok {
+		return existing, nil
+	}
+	c.entries[versionID] = def
+	c.order = append(c.order, versionID)
+	for len(c.order) > c.size {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	return def, nil
+}
+
+// Len returns the number of cached definitions
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,310 @@
+// Package workflows holds the compiled, typed form of a workflow definition.
+//
+// A definition is stored as JSON ({nodes, edges}) on each WorkflowVersion. Compile turns it
+// into a Definition once: typed nodes and edges, parent/child indices, loop bodies and
+// parsed edge conditions, so the engine and the validator don't re-walk raw maps.
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/patali/yantra/src/templating"
+)
+
+// Node types the graph structure depends on (mirrors executors' constants)
+const (
+	nodeTypeStart           = "start"
+	nodeTypeEnd             = "end"
+	nodeTypeConditional     = "conditional"
+	nodeTypeLoop            = "loop"
+	nodeTypeLoopAccumulator = "loop-accumulator"
+)
+
+// Loop edge handles
+const (
+	// HandleLoopOutput connects a loop accumulator to its body
+	HandleLoopOutput = "loop-output"
+	// HandleOutput connects a loop accumulator to the nodes that run after all iterations
+	HandleOutput = "output"
+)
+
+// Definition is a compiled workflow definition
+type Definition struct {
+	Nodes       []*Node // definition order
+	Edges       []*Edge // definition order; edges to unknown nodes are dropped
+	StartNodeID string  // first start node, empty if none
+
+	// Variables are the workflow-level defaults for {{vars.NAME}}; environments override them
+	Variables map[string]interface{}
+
+	nodesByID   map[string]*Node
+	edgeBetween map[[2]string]*Edge // first edge from source to target
+}
+
+// Node is a compiled workflow node
+type Node struct {
+	ID     string
+	Type   string
+	Config map[string]interface{} // data.config, never nil
+
+	// Outgoing and Incoming edges in definition order
+	Outgoing []*Edge
+	Incoming []*Edge
+	children []string
+	parents  []string
+
+	// Condition is a conditional node's parsed string condition (nil for structured conditions)
+	Condition    *templating.Expression
+	ConditionErr error
+
+	// Loop holds loop and loop-accumulator settings
+	Loop *LoopOptions
+}
+
+// Edge is a compiled workflow edge
+type Edge struct {
+	ID           string
+	Source       string
+	Target       string
+	SourceHandle string
+
+	// Condition is the parsed edge condition; nil when the edge is unconditional
+	// or the condition failed to parse (ConditionErr is set)
+	ConditionSource string
+	Condition       *templating.Expression
+	ConditionErr    error
+}
+
+// LoopOptions are the loop settings the engine reads between iterations
+type LoopOptions struct {
+	IterationDelayMs int     // delay between iteration starts (0 = none)
+	Concurrency      int     // iterations running at once (default 1 = sequential)
+	BatchSize        int     // items per iteration (default 1); each iteration gets a chunk of up to BatchSize items
+	ErrorHandling    string  // "skip" (default): continue and collect errors, "fail": stop at the first failure, "threshold": fail above MaxFailureRatio
+	MaxFailureRatio  float64 // "threshold": share of iterations (0-1) allowed to fail
+	UnwrapData       bool    // loop-accumulator: accumulate output["data"] instead of the whole output (default true)
+}
+
+// Parse compiles a definition stored as JSON
+func Parse(data []byte) (*Definition, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow definition: %w", err)
+	}
+	return Compile(raw)
+}
+
+// Compile builds a Definition from its raw JSON form. It is lenient like the engine always was:
+// malformed nodes and edges are skipped, and duplicate node IDs keep the first node.
+// Use the validator to report those problems
+func Compile(raw map[string]interface{}) (*Definition, error) {
+	rawNodes, ok := raw["nodes"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid workflow definition: missing nodes")
+	}
+	rawEdges, _ := raw["edges"].([]interface{}) // Empty edges is okay
+
+	def := &Definition{
+		Nodes:       make([]*Node, 0, len(rawNodes)),
+		Edges:       make([]*Edge, 0, len(rawEdges)),
+		nodesByID:   make(map[string]*Node, len(rawNodes)),
+		edgeBetween: make(map[[2]string]*Edge, len(rawEdges)),
+		Variables:   map[string]interface{}{},
+	}
+	if variables, ok := raw["variables"].(map[string]interface{}); ok {
+		def.Variables = variables
+	}
+
+	for _, nodeData := range rawNodes {
+		rawNode, ok := nodeData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nodeID, _ := rawNode["id"].(string)
+		if nodeID == "" {
+			continue
+		}
+		if _, exists := def.nodesByID[nodeID]; exists {
+			continue
+		}
+
+		node := compileNode(nodeID, rawNode)
+		def.Nodes = append(def.Nodes, node)
+		def.nodesByID[nodeID] = node
+		if node.Type == nodeTypeStart && def.StartNodeID == "" {
+			def.StartNodeID = nodeID
+		}
+	}
+
+	for _, edgeData := range rawEdges {
+		rawEdge, ok := edgeData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		edge := compileEdge(rawEdge)
+		source, sourceOK := def.nodesByID[edge.Source]
+		target, targetOK := def.nodesByID[edge.Target]
+		if !sourceOK || !targetOK {
+			continue
+		}
+
+		def.Edges = append(def.Edges, edge)
+		source.Outgoing = append(source.Outgoing, edge)
+		source.children = append(source.children, edge.Target)
+		target.Incoming = append(target.Incoming, edge)
+		target.parents = append(target.parents, edge.Source)
+		key := [2]string{edge.Source, edge.Target}
+		if _, exists := def.edgeBetween[key]; !exists {
+			def.edgeBetween[key] = edge
+		}
+	}
+
+	return def, nil
+}
+
+func compileNode(nodeID string, raw map[string]interface{}) *Node {
+	node := &Node{ID: nodeID, Config: map[string]interface{}{}}
+	node.Type, _ = raw["type"].(string)
+	if data, ok := raw["data"].(map[string]interface{}); ok {
+		if config, ok := data["config"].(map[string]interface{}); ok {
+			node.Config = config
+		}
+	}
+
+	switch node.Type {
+	case nodeTypeConditional:
+		if condition, ok := node.Config["condition"].(string); ok && strings.TrimSpace(condition) != "" {
+			node.Condition, node.ConditionErr = templating.Compile(condition)
+		}
+	case nodeTypeLoop, nodeTypeLoopAccumulator:
+		node.Loop = compileLoopOptions(node.Config)
+	}
+
+	return node
+}
+
+func compileLoopOptions(config map[string]interface{}) *LoopOptions {
+	options := &LoopOptions{Concurrency: 1, BatchSize: 1, ErrorHandling: "skip", UnwrapData: true}
+	if delay, ok := config["iterationDelay"].(float64); ok {
+		options.IterationDelayMs = int(delay)
+	}
+	if concurrency, ok := config["concurrency"].(float64); ok {
+		options.Concurrency = int(concurrency)
+	}
+	if batchSize, ok := config["batchSize"].(float64); ok {
+		options.BatchSize = int(batchSize)
+	}
+	if mode, ok := config["errorHandling"].(string); ok && mode != "" {
+		options.ErrorHandling = mode
+	}
+	if ratio, ok := config["maxFailureRatio"].(float64); ok {
+		options.MaxFailureRatio = ratio
+	}
+	if unwrap, ok := config["unwrapData"].(bool); ok {
+		options.UnwrapData = unwrap
+	}
+	return options
+}
+
+func compileEdge(raw map[string]interface{}) *Edge {
+	edge := &Edge{}
+	edge.ID, _ = raw["id"].(string)
+	edge.Source, _ = raw["source"].(string)
+	edge.Target, _ = raw["target"].(string)
+	edge.SourceHandle, _ = raw["sourceHandle"].(string)
+	if condition, ok := raw["condition"].(string); ok && condition != "" {
+		edge.ConditionSource = condition
+		edge.Condition, edge.ConditionErr = templating.Compile(condition)
+	}
+	return edge
+}
+
+// Node returns a node by ID
+func (d *Definition) Node(id string) (*Node, bool) {
+	node, ok := d.nodesByID[id]
+	return node, ok
+}
+
+// Children returns the targets of a node's outgoing edges, in edge order (callers must not modify it)
+func (d *Definition) Children(id string) []string {
+	if node, ok := d.nodesByID[id]; ok {
+		return node.children
+	}
+	return nil
+}
+
+// Parents returns the sources of a node's incoming edges, in edge order (callers must not modify it)
+func (d *Definition) Parents(id string) []string {
+	if node, ok := d.nodesByID[id]; ok {
+		return node.parents
+	}
+	return nil
+}
+
+// EdgeBetween returns the first edge from source to target
+func (d *Definition) EdgeBetween(source, target string) (*Edge, bool) {
+	edge, ok := d.edgeBetween[[2]string{source, target}]
+	return edge, ok
+}
+
+// IsLoop reports whether the node runs a loop body (loop or loop-accumulator)
+func (n *Node) IsLoop() bool {
+	return n.Type == nodeTypeLoop || n.Type == nodeTypeLoopAccumulator
+}
+
+// IsEnd reports whether the node is an end node
+func (n *Node) IsEnd() bool {
+	return n.Type == nodeTypeEnd
+}
+
+// LoopBody returns the first node of each loop body branch: every child of a loop node,
+// and the targets of a loop accumulator's "loop-output" handle
+func (d *Definition) LoopBody(loopID string) []string {
+	node, ok := d.nodesByID[loopID]
+	if !ok || !node.IsLoop() {
+		return nil
+	}
+	var body []string
+	for _, edge := range node.Outgoing {
+		if node.Type == nodeTypeLoop || edge.SourceHandle == HandleLoopOutput {
+			body = append(body, edge.Target)
+		}
+	}
+	return body
+}
+
+// LoopExits returns the targets of a loop accumulator's final "output" handle
+func (d *Definition) LoopExits(loopID string) []string {
+	node, ok := d.nodesByID[loopID]
+	if !ok {
+		return nil
+	}
+	var exits []string
+	for _, edge := range node.Outgoing {
+		if edge.SourceHandle == HandleOutput {
+			exits = append(exits, edge.Target)
+		}
+	}
+	return exits
+}
+
+// Reachable returns the nodes reachable from startID (inclusive), not expanding past stopID
+func (d *Definition) Reachable(startID, stopID string) map[string]bool {
+	visited := map[string]bool{}
+	queue := []string{startID}
+	for len(queue) > 0 {
+		nodeID := queue[0]
+		queue = queue[1:]
+		if visited[nodeID] {
+			continue
+		}
+		visited[nodeID] = true
+		if nodeID == stopID {
+			continue
+		}
+		queue = append(queue, d.Children(nodeID)...)
+	}
+	return visited
+}
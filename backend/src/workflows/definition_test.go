@@ -0,0 +1,101 @@
+package workflows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDefinitionJSON = `{
+  "nodes": [
+    {"id": "start", "type": "start"},
+    {"id": "fetch", "type": "http", "data": {"config": {"url": "https://api.example.com"}}},
+    {"id": "acc", "type": "loop-accumulator", "data": {"config": {"iterationDelay": 250, "concurrency": 4, "batchSize": 100, "errorHandling": "threshold", "maxFailureRatio": 0.1}}},
+    {"id": "check", "type": "conditional", "data": {"config": {"condition": "item.id >"}}},
+    {"id": "shape", "type": "transform"},
+    {"id": "fetch", "type": "delay"},
+    {"id": "end", "type": "end"}
+  ],
+  "edges": [
+    {"id": "e1", "source": "start", "target": "fetch"},
+    {"id": "e2", "source": "fetch", "target": "acc"},
+    {"id": "e3", "source": "acc", "target": "check", "sourceHandle": "loop-output"},
+    {"id": "e4", "source": "check", "target": "shape", "condition": "data == true"},
+    {"id": "e5", "source": "shape", "target": "acc"},
+    {"id": "e6", "source": "acc", "target": "end", "sourceHandle": "output"},
+    {"id": "e7", "source": "shape", "target": "ghost"}
+  ]
+}`
+
+func TestCompile(t *testing.T) {
+	def, err := Parse([]byte(testDefinitionJSON))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "start", def.StartNodeID)
+	assert.Len(t, def.Nodes, 6, "duplicate IDs keep the first node")
+	assert.Len(t, def.Edges, 6, "edges to unknown nodes are dropped")
+
+	fetch, ok := def.Node("fetch")
+	assert.True(t, ok)
+	assert.Equal(t, "http", fetch.Type)
+	assert.Equal(t, "https://api.example.com", fetch.Config["url"])
+
+	shape, _ := def.Node("shape")
+	assert.NotNil(t, shape.Config)
+	assert.Equal(t, []string{"check"}, def.Parents("shape"))
+	assert.Equal(t, []string{"acc"}, def.Children("shape"))
+	assert.Equal(t, []string{"fetch", "shape"}, def.Parents("acc"))
+
+	acc, _ := def.Node("acc")
+	assert.True(t, acc.IsLoop())
+	assert.Equal(t, &LoopOptions{IterationDelayMs: 250, Concurrency: 4, BatchSize: 100, ErrorHandling: "threshold", MaxFailureRatio: 0.1, UnwrapData: true}, acc.Loop)
+	assert.Equal(t, []string{"check"}, def.LoopBody("acc"))
+	assert.Equal(t, []string{"end"}, def.LoopExits("acc"))
+
+	check, _ := def.Node("check")
+	assert.Error(t, check.ConditionErr)
+
+	edge, ok := def.EdgeBetween("check", "shape")
+	assert.True(t, ok)
+	assert.NoError(t, edge.ConditionErr)
+	assert.NotNil(t, edge.Condition)
+
+	assert.Equal(t, map[string]bool{"check": true, "shape": true, "acc": true}, def.Reachable("check", "acc"))
+
+	_, err = Compile(map[string]interface{}{})
+	assert.Error(t, err)
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache(2)
+
+	first, err := cache.Get("v1", testDefinitionJSON)
+	assert.NoError(t, err)
+	again, _ := cache.Get("v1", "ignored once cached")
+	assert.Same(t, first, again)
+
+	cache.Get("v2", testDefinitionJSON)
+	cache.Get("v3", testDefinitionJSON)
+	assert.Equal(t, 2, cache.Len())
+
+	_, err = cache.Get("bad", "{not json")
+	assert.Error(t, err)
+}
+
+func TestVariables(t *testing.T) {
+	def, err := Parse([]byte(`{"nodes": [{"id": "start", "type": "start"}], "variables": {"API_URL": "https://staging.example.com", "THRESHOLD": 10}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://staging.example.com", def.Variables["API_URL"])
+
+	merged := MergeVariables(def.Variables, map[string]interface{}{"API_URL": "https://api.example.com"})
+	assert.Equal(t, map[string]interface{}{"API_URL": "https://api.example.com", "THRESHOLD": float64(10)}, merged)
+	assert.Equal(t, "https://staging.example.com", def.Variables["API_URL"], "defaults are not modified")
+
+	noVariables, _ := Parse([]byte(`{"nodes": []}`))
+	assert.NotNil(t, noVariables.Variables)
+
+	assert.True(t, ValidVariableName("API_URL"))
+	assert.True(t, ValidVariableName("_private"))
+	assert.False(t, ValidVariableName("1st"))
+	assert.False(t, ValidVariableName("api-url"))
+}
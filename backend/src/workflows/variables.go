@@ -0,0 +1,26 @@
+package workflows
+
+import (
+	"regexp"
+)
+
+// variableNamePattern is the allowed form of workflow and environment variable names
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+
+// ValidVariableName reports whether name can be referenced as {{vars.NAME}}
+func ValidVariableName(name string) bool {
+	return variableNamePattern.MatchString(name)
+}
+
+// MergeVariables returns the workflow variables with environment overrides applied on top.
+// Neither map is modified (compiled definitions are shared between executions)
+func MergeVariables(defaults, overrides map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(defaults)+len(overrides))
+	for name, value := range defaults {
+		merged[name] = value
+	}
+	for name, value := range overrides {
+		merged[name] = value
+	}
+	return merged
+}
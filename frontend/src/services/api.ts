@@ -1,4 +1,5 @@
 import axios from "axios";
+import type { DeadLetterFilter, DeadLetterQuery } from "@/types";
 
 const api = axios.create({
   baseURL: "/api",
@@ -45,8 +46,15 @@ export const recoveryApi = {
   getAllRuns: (status: string = "all") => api.get(`/recovery/runs?status=${status}`),
 
   // Dead letter queue operations (for async node failures)
-  getDeadLetterMessages: () => api.get("/recovery/dead-letter"),
+  getDeadLetterMessages: (params?: DeadLetterQuery) => api.get("/recovery/dead-letter", { params }),
+  getDeadLetterMessage: (messageId: string) => api.get(`/recovery/dead-letter/${messageId}`),
   retryDeadLetterMessage: (messageId: string) => api.post(`/recovery/dead-letter/${messageId}/retry`),
+  discardDeadLetterMessage: (messageId: string, reason?: string) => api.post(`/recovery/dead-letter/${messageId}/discard`, { reason }),
+  updateDeadLetterPayload: (messageId: string, data: { nodeConfig?: Record<string, unknown>; input?: unknown; reason?: string; retry?: boolean }) =>
+    api.put(`/recovery/dead-letter/${messageId}/payload`, data),
+  bulkRetryDeadLetterMessages: (filter: DeadLetterFilter & { reason?: string }) => api.post("/recovery/dead-letter/bulk-retry", filter),
+  bulkDiscardDeadLetterMessages: (filter: DeadLetterFilter & { reason?: string }) => api.post("/recovery/dead-letter/bulk-discard", filter),
+  getAuditLog: (params?: { resourceType?: string; resourceId?: string; limit?: number; offset?: number }) => api.get("/recovery/audit-log", { params }),
 
   // Workflow restart operations
   restartWorkflow: (executionId: string) => api.post(`/recovery/workflows/${executionId}/restart`),
@@ -58,11 +66,24 @@ export const recoveryApi = {
   cancelWorkflow: (executionId: string) => api.post(`/recovery/workflows/${executionId}/cancel`),
 
   // Node re-execution operations
-  reExecuteNode: (executionId: string, nodeId: string) => api.post(`/recovery/executions/${executionId}/nodes/${nodeId}/retry`),
+  reExecuteNode: (executionId: string, nodeId: string, resume = false) =>
+    api.post(`/recovery/executions/${executionId}/nodes/${nodeId}/retry`, { resume }),
 
   // Get execution details with recovery options
   getExecutionWithRecovery: (workflowId: string, executionId: string) => api.get(`/workflows/${workflowId}/executions/${executionId}?includeRecovery=true`),
 };
 
+// Workflow schedule operations (cron entries in addition to the workflow's own schedule)
+export const scheduleApi = {
+  getSchedules: (workflowId: string) => api.get(`/workflows/${workflowId}/schedules`),
+  createSchedule: (workflowId: string, data: Record<string, unknown>) => api.post(`/workflows/${workflowId}/schedules`, data),
+  updateSchedule: (workflowId: string, scheduleId: string, data: Record<string, unknown>) =>
+    api.put(`/workflows/${workflowId}/schedules/${scheduleId}`, data),
+  deleteSchedule: (workflowId: string, scheduleId: string) => api.delete(`/workflows/${workflowId}/schedules/${scheduleId}`),
+  // Next fire times of the workflow; pass cron/timezone to preview an unsaved expression
+  previewSchedules: (workflowId: string, params?: { count?: number; cron?: string; timezone?: string }) =>
+    api.get(`/workflows/${workflowId}/schedules/preview`, { params }),
+};
+
 export default api;
 
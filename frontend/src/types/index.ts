@@ -115,6 +115,28 @@ export interface DeadLetterMessage {
   nodeExecution?: WorkflowNodeExecution;
 }
 
+// Dead letter queue filters (all optional; dates are RFC 3339)
+export interface DeadLetterFilter {
+  workflowId?: string;
+  eventType?: string;
+  error?: string;
+  from?: string;
+  to?: string;
+  messageIds?: string[];
+}
+
+export interface DeadLetterQuery extends Omit<DeadLetterFilter, "messageIds"> {
+  limit?: number;
+  offset?: number;
+}
+
+export interface DeadLetterPage {
+  messages: DeadLetterMessage[];
+  total: number;
+  limit: number;
+  offset: number;
+}
+
 // Recovery operations interface
 export interface RecoveryOptions {
   canRestartWorkflow: boolean;